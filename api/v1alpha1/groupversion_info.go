@@ -0,0 +1,20 @@
+// Package v1alpha1 contains the API of the operator's own resources
+// +kubebuilder:object:generate=true
+// +groupName=virtualservice-operator.io
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is the group version of the operator's resources
+	GroupVersion = schema.GroupVersion{Group: "virtualservice-operator.io", Version: "v1alpha1"}
+
+	// SchemeBuilder adds the resource types to a scheme
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the types of this group version to a scheme
+	AddToScheme = SchemeBuilder.AddToScheme
+)
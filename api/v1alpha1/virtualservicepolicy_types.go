@@ -0,0 +1,49 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VirtualServicePolicySpec configures the routing of a default namespace service. Every field that is set takes
+// precedence over the service's annotation for the same setting and over the global operator config.
+type VirtualServicePolicySpec struct {
+	// TargetService is the name of the service in the default namespace the policy applies to
+	TargetService string `json:"targetService"`
+	// Timeout of the default and developer routes of the service, must be positive
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// Gateways the VirtualService of the service is attached to ("mesh" or "namespace/name")
+	// +optional
+	Gateways []string `json:"gateways,omitempty"`
+	// DefaultWeight is the percentage of header-less traffic kept on the default namespace
+	// +optional
+	DefaultWeight *int32 `json:"defaultWeight,omitempty"`
+	// DisableDeveloperRoutes removes all developer routes and sends all traffic to the default namespace
+	// +optional
+	DisableDeveloperRoutes *bool `json:"disableDeveloperRoutes,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=vspolicy
+// +kubebuilder:printcolumn:name="Target",type=string,JSONPath=`.spec.targetService`
+
+// VirtualServicePolicy declares the routing settings of a default namespace service, instead of annotations
+type VirtualServicePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VirtualServicePolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VirtualServicePolicyList contains a list of VirtualServicePolicy
+type VirtualServicePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VirtualServicePolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VirtualServicePolicy{}, &VirtualServicePolicyList{})
+}
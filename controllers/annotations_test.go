@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/config"
+	"virtualservice-operator/internal/utils"
+)
+
+const annotationsTestConfig = handlerTestConfig + `virtualServiceAnnotations:
+  kiali.io/hint: shared
+  flagger.app/owner: platform
+annotationPassthroughPrefixes: [kiali.io/, example.com/]
+`
+
+// vsAnnotations returns the annotations of the VirtualService without the operator's own bookkeeping
+func vsAnnotations(vs *istionetworkingv1beta1.VirtualService) map[string]string {
+	annotations := map[string]string{}
+	for key, value := range vs.Annotations {
+		if key != utils.ManagedAnnotationsAnnotation {
+			annotations[key] = value
+		}
+	}
+	return annotations
+}
+
+func TestVirtualServiceAnnotations(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, annotationsTestConfig), []client.Object{
+		newService("default", "app", map[string]string{
+			"kiali.io/hint":     "app-specific",
+			"example.com/team":  "web",
+			"unrelated.io/note": "not copied",
+		}),
+	})
+	env.reconcile("default", "app")
+
+	want := map[string]string{
+		// The service's own annotation wins over the static one
+		"kiali.io/hint":     "app-specific",
+		"flagger.app/owner": "platform",
+		"example.com/team":  "web",
+	}
+	if got := vsAnnotations(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, want) {
+		t.Errorf("annotations = %v, want %v", got, want)
+	}
+}
+
+func TestVirtualServiceAnnotationsSync(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, annotationsTestConfig), []client.Object{
+		newService("default", "app", map[string]string{"example.com/team": "web"}),
+	})
+	env.reconcile("default", "app")
+
+	// Someone else annotates the VirtualService
+	vs := env.virtualService("default", "app-virtual-service")
+	vs.Annotations["argocd.argoproj.io/sync-wave"] = "1"
+	if err := env.client.Update(context.Background(), vs); err != nil {
+		t.Fatal(err)
+	}
+
+	env.updateService("default", "app", func(service *corev1.Service) {
+		delete(service.Annotations, "example.com/team")
+	})
+	env.reconcile("default", "app")
+
+	want := map[string]string{
+		"kiali.io/hint":                "shared",
+		"flagger.app/owner":            "platform",
+		"argocd.argoproj.io/sync-wave": "1",
+	}
+	if got := vsAnnotations(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, want) {
+		t.Errorf("annotations after removing the passthrough annotation = %v, want %v", got, want)
+	}
+
+	// Static annotations dropped from the config are removed too
+	env.config.set(testConfig(t, handlerTestConfig))
+	env.reconcile("default", "app")
+	want = map[string]string{"argocd.argoproj.io/sync-wave": "1"}
+	if got := vsAnnotations(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, want) {
+		t.Errorf("annotations after dropping the static annotations = %v, want %v", got, want)
+	}
+}
+
+func TestVirtualServiceAnnotationsRejectOperatorPrefix(t *testing.T) {
+	for _, configYAML := range []string{
+		"annotationPassthroughPrefixes: [virtualservice-operator/]\n",
+		"annotationPassthroughPrefixes: [virtualservice]\n",
+		"annotationPassthroughPrefixes: [\"\"]\n",
+		"virtualServiceAnnotations:\n  virtualservice-operator/managed-annotations: x\n",
+	} {
+		if _, err := config.ParseConfig([]byte(configYAML), config.FormatYAML); err == nil {
+			t.Errorf("config %q accepted, want it rejected for touching the operator's annotations", configYAML)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"context"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// checkCacheScope reports watched namespaces outside the namespaces the manager cache was limited to at
+// startup. Objects in those namespaces are invisible to the operator until it's restarted.
+func (r *ServiceReconciler) checkCacheScope(ctx context.Context, watchedNamespaces []string) {
+	if len(r.CachedNamespaces) == 0 {
+		return
+	}
+
+	cached := make(map[string]bool, len(r.CachedNamespaces))
+	for _, ns := range r.CachedNamespaces {
+		cached[ns] = true
+	}
+
+	var missing []string
+	for _, ns := range watchedNamespaces {
+		if !cached[ns] {
+			missing = append(missing, ns)
+		}
+	}
+	if len(missing) > 0 {
+		ctrl.LoggerFrom(ctx).Error(nil, "Watched namespaces are not cached, restart the operator to pick them up",
+			"namespaces", missing, "cachedNamespaces", r.CachedNamespaces)
+	}
+}
@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// disableDevRoutesAnnotation set to "true" on a default namespace service is an emergency switch: all
+// x-developer routes are removed from its VirtualService, the default route goes back to the default
+// namespace, and no developer routes are added until the annotation is removed
+const disableDevRoutesAnnotation = "virtualservice-operator/disable-dev-routes"
+
+// devRoutesDisabled checks if the developer routes of a default namespace service are switched off
+func devRoutesDisabled(service *corev1.Service) bool {
+	return service != nil && getAnnotation(service, disableDevRoutesAnnotation) == "true"
+}
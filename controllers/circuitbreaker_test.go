@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const circuitBreakerTestConfig = `
+defaultNamespace: default
+developerNamespaces: [alice, bob]
+`
+
+func TestDisableDevRoutes(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, circuitBreakerTestConfig), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+	})
+	env.reconcile("default", "app")
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, []string{"alice"}) {
+		t.Fatalf("developer routes = %v, want [alice]", got)
+	}
+
+	// Enabling the circuit breaker strips the developer routes
+	env.updateService("default", "app", func(service *corev1.Service) {
+		service.Annotations = map[string]string{disableDevRoutesAnnotation: "true"}
+	})
+	env.reconcile("default", "app")
+	vs := env.virtualService("default", "app-virtual-service")
+	if got := routedDeveloperNamespaces(vs); len(got) != 0 {
+		t.Errorf("developer routes with the circuit breaker enabled = %v, want none", got)
+	}
+	if len(vs.Spec.Http) != 1 || vs.Spec.Http[0].Route[0].Destination.Host != "app.default.svc.cluster.local" {
+		t.Errorf("routes = %v, want only the default route", vs.Spec.Http)
+	}
+
+	// Developer services don't get routes while it is enabled
+	if err := env.client.Create(context.Background(), newService("bob", "app", nil)); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile("bob", "app")
+	env.reconcile("alice", "app")
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); len(got) != 0 {
+		t.Errorf("developer routes added with the circuit breaker enabled: %v", got)
+	}
+
+	// Removing the annotation restores the routes of every developer service
+	env.updateService("default", "app", func(service *corev1.Service) {
+		delete(service.Annotations, disableDevRoutesAnnotation)
+	})
+	env.reconcile("default", "app")
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, []string{"alice", "bob"}) {
+		t.Errorf("developer routes after removing the circuit breaker = %v, want [alice bob]", got)
+	}
+}
+
+func TestDisableDevRoutesOverridesDefaultRouteNamespace(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, circuitBreakerTestConfig), []client.Object{
+		newService("default", "app", map[string]string{
+			defaultWeightAnnotation:    "50",
+			disableDevRoutesAnnotation: "true",
+		}),
+		newService("alice", "app", nil),
+	})
+	env.reconcile("default", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	defaultRoute := vs.Spec.Http[len(vs.Spec.Http)-1]
+	if len(defaultRoute.Route) != 1 || defaultRoute.Route[0].Destination.Host != "app.default.svc.cluster.local" {
+		t.Errorf("default route = %v, want all traffic to the default namespace", defaultRoute.Route)
+	}
+}
@@ -0,0 +1,26 @@
+package controllers
+
+import "time"
+
+// Clock tells the time to the time-dependent reconcile logic, such as route ages, rollout steps and
+// reconcile throttling, so it can be driven by a fake clock instead of waiting for real time to pass
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time on the returned channel
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock of the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clock returns the reconciler's clock, falling back to the real clock
+func (r *ServiceReconciler) clock() Clock {
+	if r.Clock == nil {
+		return realClock{}
+	}
+	return r.Clock
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// tickingClock is a fakeClock whose timers fire at once by advancing it, and that cancels the
+// context once it has handed out its last timer
+type tickingClock struct {
+	*fakeClock
+	ticks  int
+	cancel context.CancelFunc
+	waits  []time.Duration
+}
+
+func (c *tickingClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	if len(c.waits) > c.ticks {
+		c.cancel()
+		return nil
+	}
+	c.advance(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+func TestReconcilerDefaultsToRealClock(t *testing.T) {
+	r := &ServiceReconciler{}
+	if _, ok := r.clock().(realClock); !ok {
+		t.Fatalf("clock() = %T, want realClock", r.clock())
+	}
+
+	before := time.Now()
+	now := r.clock().Now()
+	if now.Before(before) || now.After(time.Now()) {
+		t.Errorf("realClock.Now() = %v, want the current time", now)
+	}
+}
+
+func TestStatusReporterWaitsOnClock(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, statusTestConfig), statusTestObjects())
+	start := env.clock.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := &tickingClock{fakeClock: env.clock, ticks: 2, cancel: cancel}
+	env.reconciler.Clock = clock
+
+	if err := (&statusReporter{reconciler: env.reconciler}).Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Three writes, each followed by a wait of the configured interval
+	if want := []time.Duration{30 * time.Second, 30 * time.Second, 30 * time.Second}; !reflect.DeepEqual(clock.waits, want) {
+		t.Errorf("waits = %v, want %v", clock.waits, want)
+	}
+	if got, want := env.statusDocument().GeneratedAt, start.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("generatedAt = %v, want the time of the third write %v", got, want)
+	}
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// defaultRouteHost returns the destination host of the default (no-match) route
+func defaultRouteHost(env *testEnv) string {
+	vs := env.virtualService("default", "app-virtual-service")
+	return vs.Spec.Http[len(vs.Spec.Http)-1].Route[0].Destination.Host
+}
+
+func TestDefaultRouteNamespaceDesignation(t *testing.T) {
+	tests := []struct {
+		name       string
+		service    string
+		namespace  string
+		wantHost   string
+		wantWarned bool
+	}{
+		{name: "none", wantHost: "app.default.svc.cluster.local"},
+		{name: "service annotation", service: "alice", wantHost: "app.alice.svc.cluster.local"},
+		{name: "namespace annotation", namespace: "alice", wantHost: "app.alice.svc.cluster.local"},
+		{name: "service wins on conflict", service: "alice", namespace: "bob", wantHost: "app.alice.svc.cluster.local", wantWarned: true},
+		{name: "several namespaces", service: "alice,bob", wantHost: "app.default.svc.cluster.local", wantWarned: true},
+		{name: "not a developer namespace", service: "prod", wantHost: "app.default.svc.cluster.local", wantWarned: true},
+		{name: "no developer service", service: "bob", wantHost: "app.default.svc.cluster.local"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var annotations map[string]string
+			if tt.service != "" {
+				annotations = map[string]string{defaultRouteNamespaceAnnotation: tt.service}
+			}
+			defaultNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+			if tt.namespace != "" {
+				defaultNamespace.Annotations = map[string]string{defaultRouteNamespaceAnnotation: tt.namespace}
+			}
+			env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+				defaultNamespace,
+				newService("default", "app", annotations),
+				newService("alice", "app", nil),
+			})
+
+			env.reconcile("default", "app")
+
+			if got := defaultRouteHost(env); got != tt.wantHost {
+				t.Errorf("default route host = %q, want %q", got, tt.wantHost)
+			}
+			if warned := recordedEvent(env.recorder, "InvalidDefaultRouteNamespace"); warned != tt.wantWarned {
+				t.Errorf("warned = %v, want %v", warned, tt.wantWarned)
+			}
+		})
+	}
+}
+
+func TestDefaultRouteFollowsDesignatedDeveloperService(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", map[string]string{defaultRouteNamespaceAnnotation: "alice"}),
+	})
+	env.reconcile("default", "app")
+	if got := defaultRouteHost(env); got != "app.default.svc.cluster.local" {
+		t.Fatalf("default route host = %q before alice has a service", got)
+	}
+
+	// The placeholder is replaced by the real developer service
+	env.deleteObject(env.service("alice", "app"))
+	if err := env.client.Create(context.Background(), newService("alice", "app", nil)); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile("alice", "app")
+	if got := defaultRouteHost(env); got != "app.alice.svc.cluster.local" {
+		t.Errorf("default route host = %q once alice has a service, want alice", got)
+	}
+
+	env.deleteObject(env.service("alice", "app"))
+	env.reconcile("alice", "app")
+	if got := defaultRouteHost(env); got != "app.default.svc.cluster.local" {
+		t.Errorf("default route host = %q after deleting the alice service, want the default namespace", got)
+	}
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"virtualservice-operator/internal/config"
+	"virtualservice-operator/internal/utils"
+)
+
+// defaultWeightAnnotation on a default namespace service sets the percentage of header-less traffic that
+// stays on the default namespace, e.g. "0" during a maintenance window. The remainder is diverted to the
+// designated default route namespace or, without one, spread across the developer namespaces.
+const defaultWeightAnnotation = "virtualservice-operator/default-weight"
+
+// parseDefaultWeight parses the value of the default-weight annotation
+func parseDefaultWeight(value string) (int32, error) {
+	weight, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || weight < 0 || weight > 100 {
+		return 0, fmt.Errorf("invalid default weight %q, expected an integer between 0 and 100", value)
+	}
+	return int32(weight), nil
+}
+
+// defaultRouteDiversion returns a function that applies the default-weight annotation of a default namespace
+// service to its VirtualService. It's meant to run inside a VirtualService update after the developer routes
+// have been changed, so the diverted traffic follows the routes. Without a diversion the function does nothing.
+func (r *ServiceReconciler) defaultRouteDiversion(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) func(*istionetworkingv1beta1.VirtualService) {
+	noDiversion := func(*istionetworkingv1beta1.VirtualService) {}
+	if service == nil || !hasAnnotation(service, defaultWeightAnnotation) || devRoutesDisabled(service) {
+		return noDiversion
+	}
+
+	weight, err := parseDefaultWeight(getAnnotation(service, defaultWeightAnnotation))
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Ignoring invalid default weight annotation", "service", service.Name, "namespace", service.Namespace)
+		r.recorder().Eventf(service, corev1.EventTypeWarning, "InvalidAnnotation", "Ignoring default weight: %v", err)
+		return noDiversion
+	}
+	if weight == 100 {
+		return noDiversion
+	}
+
+	// Problems with the designation are reported when the default route options are derived
+	var targets []string
+	if designated, _ := r.designatedDefaultRouteNamespace(ctx, service, config); designated != "" {
+		targets = []string{designated}
+	}
+
+	return func(vs *istionetworkingv1beta1.VirtualService) {
+		utils.ApplyDefaultRouteWeight(vs, service.Name, config.DefaultNamespace, config.ClusterDomain, weight, targets)
+	}
+}
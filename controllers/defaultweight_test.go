@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// defaultRouteWeights returns the weight of each destination host of the default route
+func defaultRouteWeights(env *testEnv) map[string]int32 {
+	vs := env.virtualService("default", "app-virtual-service")
+	weights := map[string]int32{}
+	for _, destination := range vs.Spec.Http[len(vs.Spec.Http)-1].Route {
+		weights[destination.Destination.Host] = destination.Weight
+	}
+	return weights
+}
+
+func TestDefaultWeight(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        map[string]int32
+	}{
+		{
+			name:        "100 keeps all traffic on the default namespace",
+			annotations: map[string]string{defaultWeightAnnotation: "100"},
+			want:        map[string]int32{"app.default.svc.cluster.local": 0},
+		},
+		{
+			name:        "50 spreads the other half across the developer namespaces",
+			annotations: map[string]string{defaultWeightAnnotation: "50"},
+			want:        map[string]int32{"app.default.svc.cluster.local": 50, "app.alice.svc.cluster.local": 25, "app.bob.svc.cluster.local": 25},
+		},
+		{
+			name:        "0 diverts all traffic",
+			annotations: map[string]string{defaultWeightAnnotation: "0"},
+			want:        map[string]int32{"app.alice.svc.cluster.local": 50, "app.bob.svc.cluster.local": 50},
+		},
+		{
+			name:        "0 diverts all traffic to the designated namespace",
+			annotations: map[string]string{defaultWeightAnnotation: "0", defaultRouteNamespaceAnnotation: "bob"},
+			want:        map[string]int32{"app.bob.svc.cluster.local": 100},
+		},
+		{
+			name:        "invalid weight is ignored",
+			annotations: map[string]string{defaultWeightAnnotation: "150"},
+			want:        map[string]int32{"app.default.svc.cluster.local": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+				newService("default", "app", tt.annotations),
+				newService("alice", "app", nil),
+				newService("bob", "app", nil),
+			})
+			env.reconcile("default", "app")
+
+			if got := defaultRouteWeights(env); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("default route weights = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultWeightFollowsDeveloperRoutes(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", map[string]string{defaultWeightAnnotation: "0"}),
+		newService("alice", "app", nil),
+	})
+	env.reconcile("default", "app")
+	if got, want := defaultRouteWeights(env), map[string]int32{"app.alice.svc.cluster.local": 100}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("default route weights = %v, want %v", got, want)
+	}
+
+	// Once the only developer route is gone there is nowhere to divert to
+	env.deleteObject(newService("alice", "app", nil))
+	env.reconcile("alice", "app")
+	if got, want := defaultRouteWeights(env), map[string]int32{"app.default.svc.cluster.local": 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("default route weights after deleting alice = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestConcurrentDeveloperServiceDeletions(t *testing.T) {
+	// The first two VirtualService updates wait for each other, so both are based on the same
+	// version and one of them conflicts
+	arrived := make(chan struct{}, 2)
+	release := make(chan struct{})
+	var waiting, conflicts atomic.Int32
+	barrier := interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			updateOpts := &client.UpdateOptions{}
+			updateOpts.ApplyOptions(opts)
+			if _, ok := obj.(*istionetworkingv1beta1.VirtualService); !ok || len(updateOpts.DryRun) > 0 {
+				return nil
+			}
+			if waiting.Add(1) <= 2 {
+				arrived <- struct{}{}
+				select {
+				case <-release:
+				case <-time.After(5 * time.Second):
+				}
+			}
+			err := c.Update(ctx, obj, opts...)
+			if apierrors.IsConflict(err) {
+				conflicts.Add(1)
+			}
+			return err
+		},
+	}
+
+	alice, bob := newService("alice", "app", nil), newService("bob", "app", nil)
+	env := newTestEnv(t, testConfig(t, `
+defaultNamespace: default
+developerNamespaces: [alice, bob, carol]
+`), []client.Object{newService("default", "app", nil), alice, bob, newService("carol", "app", nil)})
+	env.reconcile("default", "app")
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); len(got) != 3 {
+		t.Fatalf("developer routes = %v before the deletions, want three", got)
+	}
+
+	env.deleteObject(alice)
+	env.deleteObject(bob)
+	env.reconciler.Client = interceptor.NewClient(env.client, barrier)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, ns := range []string{"alice", "bob"} {
+		wg.Add(1)
+		go func(i int, ns string) {
+			defer wg.Done()
+			_, errs[i] = env.reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: ns, Name: "app"}})
+		}(i, ns)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-arrived:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the deletions did not both reach the VirtualService update")
+		}
+	}
+	close(release)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("reconcile %d failed: %v", i, err)
+		}
+	}
+	if conflicts.Load() == 0 {
+		t.Error("the concurrent removals did not conflict")
+	}
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, []string{"carol"}) {
+		t.Errorf("surviving developer routes = %v, want [carol]", got)
+	}
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"virtualservice-operator/internal/config"
+	"virtualservice-operator/internal/utils"
+)
+
+// subsetAnnotation pins the developer route of a service to a DestinationRule subset
+const subsetAnnotation = "virtualservice-operator/subset"
+
+// reconcileDestinationRule creates, updates or deletes the DestinationRule declaring the subset
+// a developer service is pinned to and its consistent hashing. The DestinationRule is owned by the
+// developer service, so it is garbage collected together with it.
+func (r *ServiceReconciler) reconcileDestinationRule(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) error {
+	if !config.GenerateDestinationRules {
+		return nil
+	}
+
+	log := ctrl.LoggerFrom(ctx)
+	subset := r.developerSubset(ctx, service, config)
+	hash := r.consistentHash(ctx, service)
+
+	existing := &istionetworkingv1beta1.DestinationRule{}
+	err := r.Get(ctx, types.NamespacedName{Name: utils.DestinationRuleName(service.Name), Namespace: service.Namespace}, existing)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get DestinationRule for service %s/%s: %w", service.Namespace, service.Name, err)
+	}
+	exists := err == nil
+
+	if exists && !utils.IsManagedByOperator(existing) {
+		log.Info("DestinationRule exists and is not managed by the operator, leaving it alone", "destinationRule", existing.Name, "namespace", existing.Namespace)
+		return nil
+	}
+
+	// Subset and hashing were removed from the service, clean up the DestinationRule we created for it
+	if subset == "" && hash == nil {
+		if exists {
+			if err := r.Delete(ctx, existing); err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete DestinationRule %s/%s: %w", existing.Namespace, existing.Name, err)
+			}
+			log.Info("Deleted DestinationRule for removed subset", "destinationRule", existing.Name, "namespace", existing.Namespace)
+		}
+		return nil
+	}
+
+	var subsets []string
+	if subset != "" {
+		subsets = []string{subset}
+	}
+	desired := utils.GenerateDestinationRule(service, config.ClusterDomain, subsets, config.SubsetLabel, hash)
+	if err := ctrl.SetControllerReference(service, desired, r.Scheme); err != nil {
+		return fmt.Errorf("failed to set owner reference: %w", err)
+	}
+
+	if !exists {
+		if err := r.Create(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create DestinationRule %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+		log.Info("Created DestinationRule", "destinationRule", desired.Name, "namespace", desired.Namespace, "subset", subset, "consistentHash", hash != nil)
+		return nil
+	}
+
+	if proto.Equal(&existing.Spec, &desired.Spec) {
+		return nil
+	}
+
+	existing.Spec.Host = desired.Spec.Host
+	existing.Spec.Subsets = desired.Spec.Subsets
+	existing.Spec.TrafficPolicy = desired.Spec.TrafficPolicy
+	if err := r.Update(ctx, existing); err != nil {
+		return fmt.Errorf("failed to update DestinationRule %s/%s: %w", existing.Namespace, existing.Name, err)
+	}
+	log.Info("Updated DestinationRule", "destinationRule", existing.Name, "namespace", existing.Namespace, "subset", subset, "consistentHash", hash != nil)
+	return nil
+}
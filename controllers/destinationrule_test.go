@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/utils"
+)
+
+func TestSubsetAnnotationPinsDeveloperRoute(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"generateDestinationRules: true\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", map[string]string{subsetAnnotation: "v2"}),
+		newService("bob", "app", nil),
+	})
+	env.reconcile("default", "app")
+	env.reconcile("alice", "app")
+	env.reconcile("bob", "app")
+
+	destinations := developerDestinations(env.virtualService("default", "app-virtual-service"))
+	if got := destinations["alice"]; got.Host != "app.alice.svc.cluster.local" || got.Subset != "v2" {
+		t.Errorf("alice destination = %v, want the v2 subset", got)
+	}
+	if got := destinations["bob"]; got.Host != "app.bob.svc.cluster.local" || got.Subset != "" {
+		t.Errorf("bob destination = %v, want no subset", got)
+	}
+
+	dr := env.destinationRule("alice", utils.DestinationRuleName("app"))
+	if dr == nil {
+		t.Fatal("no DestinationRule declaring the v2 subset")
+	}
+	if !utils.IsManagedByOperator(dr) || dr.Spec.Host != "app.alice.svc.cluster.local" {
+		t.Errorf("DestinationRule = managed %v, host %q", utils.IsManagedByOperator(dr), dr.Spec.Host)
+	}
+	if len(dr.Spec.Subsets) != 1 || dr.Spec.Subsets[0].Name != "v2" || dr.Spec.Subsets[0].Labels["version"] != "v2" {
+		t.Errorf("DestinationRule subsets = %v, want v2 selecting version=v2", dr.Spec.Subsets)
+	}
+	if env.destinationRule("bob", utils.DestinationRuleName("app")) != nil {
+		t.Error("DestinationRule created for a service without a subset")
+	}
+
+	// Removing the annotation unpins the route and deletes the DestinationRule
+	env.updateService("alice", "app", func(service *corev1.Service) {
+		delete(service.Annotations, subsetAnnotation)
+	})
+	env.reconcile("alice", "app")
+
+	if got := developerDestinations(env.virtualService("default", "app-virtual-service"))["alice"]; got.Subset != "" {
+		t.Errorf("alice destination = %v after removing the subset annotation", got)
+	}
+	if env.destinationRule("alice", utils.DestinationRuleName("app")) != nil {
+		t.Error("DestinationRule kept after removing the subset annotation")
+	}
+}
+
+func TestSubsetAnnotationWithoutDestinationRules(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", map[string]string{subsetAnnotation: "v2"}),
+	})
+	env.reconcile("default", "app")
+	env.reconcile("alice", "app")
+
+	// The subset is declared by someone else, the route still references it
+	if got := developerDestinations(env.virtualService("default", "app-virtual-service"))["alice"]; got.Subset != "v2" {
+		t.Errorf("alice destination = %v, want the v2 subset", got)
+	}
+	if env.destinationRule("alice", utils.DestinationRuleName("app")) != nil {
+		t.Error("DestinationRule created without generateDestinationRules")
+	}
+}
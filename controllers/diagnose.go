@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"virtualservice-operator/api/v1alpha1"
+	"virtualservice-operator/internal/config"
+)
+
+// Check is the outcome of one diagnose check, Err is nil when it passed
+type Check struct {
+	Name string
+	Err  error
+}
+
+// accessCheck is a permission the operator needs, an empty namespace means cluster-wide
+type accessCheck struct {
+	group     string
+	resource  string
+	namespace string
+	verbs     []string
+}
+
+// allVerbs are the verbs granted on the resources the operator manages
+var allVerbs = []string{"get", "list", "watch", "create", "update", "patch", "delete"}
+
+// readVerbs are the verbs the manager cache needs to read a resource
+var readVerbs = []string{"get", "list", "watch"}
+
+// istioKinds are the Istio resources the operator always reads and writes, their CRDs must be installed
+var istioKinds = []schema.GroupVersionKind{
+	{Group: "networking.istio.io", Version: "v1beta1", Kind: "VirtualService"},
+	{Group: "networking.istio.io", Version: "v1beta1", Kind: "Sidecar"},
+}
+
+// DiagnoseOptions are the operator flags that enable features needing further permissions
+type DiagnoseOptions struct {
+	// ServiceEntries checks what --enable-service-entries needs
+	ServiceEntries bool
+	// Policies checks what --enable-policies needs
+	Policies bool
+	// LeaderElection checks what --leader-elect needs
+	LeaderElection bool
+}
+
+// Diagnose checks that the operator can run with the credentials of its client: that it has the permissions
+// it needs, that its ConfigMap parses and that the Istio CRDs are installed. The permissions are those the
+// deployment's RBAC grants, the ones of optional features only when the config or the options enable them.
+// Every check runs whatever the outcome of the others, so a single run reports every problem.
+func (r *ServiceReconciler) Diagnose(ctx context.Context, opts DiagnoseOptions) []Check {
+	key := r.ConfigManager.ConfigMapKey()
+	operatorConfig, configErr := r.ConfigManager.GetConfig(ctx)
+	if configErr != nil {
+		// Only the checks of the features that are always on run without a config
+		operatorConfig = &config.OperatorConfig{}
+	}
+
+	accessChecks := []accessCheck{
+		{resource: "services", verbs: allVerbs},
+		{resource: "pods", verbs: readVerbs},
+		{resource: "configmaps", verbs: readVerbs},
+		// The status ConfigMap is only written next to the operator ConfigMap
+		{resource: "configmaps", namespace: key.Namespace, verbs: []string{"create", "update"}},
+		{resource: "namespaces", verbs: readVerbs},
+		{resource: "events", verbs: []string{"create", "patch"}},
+		{group: "discovery.k8s.io", resource: "endpointslices", verbs: readVerbs},
+		{group: "networking.istio.io", resource: "virtualservices", verbs: allVerbs},
+		// Sidecars created earlier are removed even with Sidecar generation disabled
+		{group: "networking.istio.io", resource: "sidecars", verbs: []string{"get", "list", "watch", "delete"}},
+	}
+	kinds := append([]schema.GroupVersionKind{}, istioKinds...)
+	if operatorConfig.EnablePlaceholderServices {
+		accessChecks = append(accessChecks, accessCheck{resource: "endpoints", verbs: []string{"get", "list", "watch", "create", "update", "delete"}})
+	}
+	if operatorConfig.GenerateSidecars {
+		accessChecks = append(accessChecks, accessCheck{group: "networking.istio.io", resource: "sidecars", verbs: []string{"create", "update", "patch"}})
+	}
+	if operatorConfig.GenerateDestinationRules {
+		accessChecks = append(accessChecks, accessCheck{group: "networking.istio.io", resource: "destinationrules", verbs: allVerbs})
+		kinds = append(kinds, schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1beta1", Kind: "DestinationRule"})
+	}
+	if opts.ServiceEntries {
+		accessChecks = append(accessChecks, accessCheck{group: "networking.istio.io", resource: "serviceentries", verbs: readVerbs})
+		kinds = append(kinds, schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1beta1", Kind: "ServiceEntry"})
+	}
+	if opts.Policies {
+		accessChecks = append(accessChecks, accessCheck{group: v1alpha1.GroupVersion.Group, resource: "virtualservicepolicies", verbs: readVerbs})
+		kinds = append(kinds, v1alpha1.GroupVersion.WithKind("VirtualServicePolicy"))
+	}
+	if opts.LeaderElection {
+		accessChecks = append(accessChecks, accessCheck{group: "coordination.k8s.io", resource: "leases", verbs: allVerbs})
+	}
+
+	var checks []Check
+	for _, access := range accessChecks {
+		for _, verb := range access.verbs {
+			checks = append(checks, Check{
+				Name: accessCheckName(access, verb),
+				Err:  r.checkAccess(ctx, access, verb),
+			})
+		}
+	}
+
+	checks = append(checks, Check{Name: fmt.Sprintf("ConfigMap %s parses", key), Err: configErr})
+
+	for _, gvk := range kinds {
+		checks = append(checks, Check{
+			Name: fmt.Sprintf("CRD for %s installed", gvk.GroupKind()),
+			Err:  r.checkKind(gvk),
+		})
+	}
+	return checks
+}
+
+// accessCheckName describes an access check, e.g. "create virtualservices.networking.istio.io in all namespaces"
+func accessCheckName(access accessCheck, verb string) string {
+	resource := access.resource
+	if access.group != "" {
+		resource += "." + access.group
+	}
+	scope := "in all namespaces"
+	if access.namespace != "" {
+		scope = "in namespace " + access.namespace
+	}
+	return fmt.Sprintf("%s %s %s", verb, resource, scope)
+}
+
+// checkAccess asks the API server with a SelfSubjectAccessReview whether the client may use the verb
+func (r *ServiceReconciler) checkAccess(ctx context.Context, access accessCheck, verb string) error {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: access.namespace,
+				Verb:      verb,
+				Group:     access.group,
+				Resource:  access.resource,
+			},
+		},
+	}
+	if err := r.Create(ctx, review); err != nil {
+		return fmt.Errorf("failed to review access: %w", err)
+	}
+	if !review.Status.Allowed {
+		if review.Status.Reason != "" {
+			return fmt.Errorf("denied: %s", review.Status.Reason)
+		}
+		return fmt.Errorf("denied")
+	}
+	return nil
+}
+
+// checkKind checks that the API server serves the kind, which fails when its CRD isn't installed
+func (r *ServiceReconciler) checkKind(gvk schema.GroupVersionKind) error {
+	_, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		return fmt.Errorf("not installed")
+	}
+	return err
+}
@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"virtualservice-operator/api/v1alpha1"
+	"virtualservice-operator/internal/config"
+)
+
+// diagnoseReconciler creates a reconciler whose client is granted every access except the denied
+// "<verb> <resource>" pairs, with the operator ConfigMap holding configYAML and the given Istio kinds served
+func diagnoseReconciler(t *testing.T, configYAML string, denied map[string]bool, kinds ...schema.GroupVersionKind) *ServiceReconciler {
+	t.Helper()
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range kinds {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testConfigMapKey.Namespace, Name: testConfigMapKey.Name},
+		Data:       map[string]string{"config.yaml": configYAML},
+	}
+	c := interceptor.NewClient(fake.NewClientBuilder().
+		WithScheme(testScheme).
+		WithRESTMapper(mapper).
+		WithObjects(configMap).
+		Build(), interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			review, ok := obj.(*authorizationv1.SelfSubjectAccessReview)
+			if !ok {
+				return c.Create(ctx, obj, opts...)
+			}
+			attributes := review.Spec.ResourceAttributes
+			review.Status.Allowed = !denied[attributes.Verb+" "+attributes.Resource]
+			if !review.Status.Allowed {
+				review.Status.Reason = "RBAC: forbidden"
+			}
+			return nil
+		},
+	})
+
+	return &ServiceReconciler{
+		Client:        c,
+		Scheme:        testScheme,
+		ConfigManager: config.NewConfigManager(c, testConfigMapKey.Namespace, testConfigMapKey.Name),
+	}
+}
+
+// failedChecks returns the failed checks by name
+func failedChecks(checks []Check) map[string]error {
+	failed := map[string]error{}
+	for _, check := range checks {
+		if check.Err != nil {
+			failed[check.Name] = check.Err
+		}
+	}
+	return failed
+}
+
+// diagnoseKinds are every kind Diagnose may check the CRD of
+var diagnoseKinds = append([]schema.GroupVersionKind{
+	{Group: "networking.istio.io", Version: "v1beta1", Kind: "DestinationRule"},
+	{Group: "networking.istio.io", Version: "v1beta1", Kind: "ServiceEntry"},
+	v1alpha1.GroupVersion.WithKind("VirtualServicePolicy"),
+}, istioKinds...)
+
+func TestDiagnosePasses(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		opts   DiagnoseOptions
+		// The 37 checks of every deployment are 34 verbs, the ConfigMap and 2 CRDs
+		want        int
+		wantChecks  []string
+		wantSkipped []string
+	}{
+		{
+			name:   "required features",
+			config: "defaultNamespace: default\ndeveloperNamespaces: [alice]\n",
+			want:   37,
+			wantChecks: []string{
+				"patch services in all namespaces",
+				"list namespaces in all namespaces",
+				"watch pods in all namespaces",
+				"create events in all namespaces",
+				"watch endpointslices.discovery.k8s.io in all namespaces",
+				"delete sidecars.networking.istio.io in all namespaces",
+				"CRD for Sidecar.networking.istio.io installed",
+			},
+			wantSkipped: []string{
+				"create endpoints in all namespaces",
+				"create sidecars.networking.istio.io in all namespaces",
+				"get destinationrules.networking.istio.io in all namespaces",
+				"CRD for DestinationRule.networking.istio.io installed",
+				"get serviceentries.networking.istio.io in all namespaces",
+				"get virtualservicepolicies.virtualservice-operator.io in all namespaces",
+				"get leases.coordination.k8s.io in all namespaces",
+			},
+		},
+		{
+			name:       "placeholders",
+			config:     handlerTestConfig,
+			want:       43,
+			wantChecks: []string{"create endpoints in all namespaces"},
+		},
+		{
+			name:   "every feature",
+			config: handlerTestConfig + "generateSidecars: true\ngenerateDestinationRules: true\n",
+			opts:   DiagnoseOptions{ServiceEntries: true, Policies: true, LeaderElection: true},
+			// 6 endpoints, 3 sidecars and 7 destinationrules verbs, 3 serviceentries, 3 virtualservicepolicies
+			// and 7 leases verbs, and 3 more CRDs
+			want: 69,
+			wantChecks: []string{
+				"create sidecars.networking.istio.io in all namespaces",
+				"patch destinationrules.networking.istio.io in all namespaces",
+				"watch serviceentries.networking.istio.io in all namespaces",
+				"list virtualservicepolicies.virtualservice-operator.io in all namespaces",
+				"update leases.coordination.k8s.io in all namespaces",
+				"CRD for DestinationRule.networking.istio.io installed",
+				"CRD for ServiceEntry.networking.istio.io installed",
+				"CRD for VirtualServicePolicy.virtualservice-operator.io installed",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := diagnoseReconciler(t, tt.config, nil, diagnoseKinds...)
+
+			checks := r.Diagnose(context.Background(), tt.opts)
+			if failed := failedChecks(checks); len(failed) != 0 {
+				t.Errorf("failed checks with full access: %v", failed)
+			}
+			if len(checks) != tt.want {
+				t.Errorf("got %d checks, want %d", len(checks), tt.want)
+			}
+			names := map[string]bool{}
+			for _, check := range checks {
+				names[check.Name] = true
+			}
+			for _, name := range tt.wantChecks {
+				if !names[name] {
+					t.Errorf("no check %q", name)
+				}
+			}
+			for _, name := range tt.wantSkipped {
+				if names[name] {
+					t.Errorf("check %q of a disabled feature ran", name)
+				}
+			}
+		})
+	}
+}
+
+func TestDiagnoseReportsEveryFailure(t *testing.T) {
+	denied := map[string]bool{
+		"patch services":         true,
+		"list namespaces":        true,
+		"create virtualservices": true,
+		"update virtualservices": true,
+		"delete virtualservices": true,
+	}
+	r := diagnoseReconciler(t, "developerNamespaces: [alice", denied, istioKinds[:1]...)
+
+	failed := failedChecks(r.Diagnose(context.Background(), DiagnoseOptions{}))
+
+	want := map[string]string{
+		"patch services in all namespaces":                             "denied: RBAC: forbidden",
+		"list namespaces in all namespaces":                            "denied: RBAC: forbidden",
+		"create virtualservices.networking.istio.io in all namespaces": "denied: RBAC: forbidden",
+		"update virtualservices.networking.istio.io in all namespaces": "denied: RBAC: forbidden",
+		"delete virtualservices.networking.istio.io in all namespaces": "denied: RBAC: forbidden",
+		"ConfigMap " + testConfigMapKey.String() + " parses":           "invalid config",
+		"CRD for Sidecar.networking.istio.io installed":                "not installed",
+	}
+	for name, message := range want {
+		err, ok := failed[name]
+		if !ok {
+			t.Errorf("check %q passed, want it to fail", name)
+			continue
+		}
+		if !strings.Contains(err.Error(), message) {
+			t.Errorf("check %q failed with %q, want %q", name, err, message)
+		}
+	}
+	for name, err := range failed {
+		if _, ok := want[name]; !ok {
+			t.Errorf("check %q failed unexpectedly: %v", name, err)
+		}
+	}
+}
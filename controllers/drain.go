@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/utils"
+)
+
+// Drain deletes every VirtualService, DestinationRule, placeholder service and Sidecar the operator manages
+// in the watched namespaces, leaving the source services untouched. With dryRun set nothing is deleted.
+// It returns a description of every object that was (or would be) deleted.
+func (r *ServiceReconciler) Drain(ctx context.Context, dryRun bool) ([]string, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	config, err := r.ConfigManager.GetConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operator config: %w", err)
+	}
+
+	watchedNamespaces, err := r.ConfigManager.GetWatchedNamespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watched namespaces: %w", err)
+	}
+
+	var drained []string
+	for _, ns := range watchedNamespaces {
+		vsList := &istionetworkingv1beta1.VirtualServiceList{}
+		if err := r.List(ctx, vsList, client.InNamespace(ns), client.MatchingLabels{utils.ManagedByLabel: utils.OperatorName}); err != nil {
+			return drained, fmt.Errorf("failed to list VirtualServices in namespace %s: %w", ns, err)
+		}
+
+		for _, vs := range vsList.Items {
+			if !utils.IsManagedByOperator(vs) {
+				continue
+			}
+			if err := r.drainObject(ctx, vs, dryRun); err != nil {
+				return drained, err
+			}
+			drained = append(drained, fmt.Sprintf("VirtualService %s/%s", vs.Namespace, vs.Name))
+		}
+
+		// Placeholders, Sidecars and DestinationRules only ever live in developer namespaces
+		if ns == config.DefaultNamespace {
+			continue
+		}
+
+		sidecar := &istionetworkingv1beta1.Sidecar{}
+		err := r.Get(ctx, types.NamespacedName{Name: utils.SidecarName, Namespace: ns}, sidecar)
+		if err != nil && !errors.IsNotFound(err) {
+			return drained, fmt.Errorf("failed to get Sidecar in namespace %s: %w", ns, err)
+		}
+		if err == nil && utils.IsManagedByOperator(sidecar) {
+			if err := r.drainObject(ctx, sidecar, dryRun); err != nil {
+				return drained, err
+			}
+			drained = append(drained, fmt.Sprintf("Sidecar %s/%s", sidecar.Namespace, sidecar.Name))
+		}
+
+		drList := &istionetworkingv1beta1.DestinationRuleList{}
+		if err := r.List(ctx, drList, client.InNamespace(ns), client.MatchingLabels{utils.ManagedByLabel: utils.OperatorName}); err != nil {
+			return drained, fmt.Errorf("failed to list DestinationRules in namespace %s: %w", ns, err)
+		}
+
+		for _, dr := range drList.Items {
+			if !utils.IsManagedByOperator(dr) {
+				continue
+			}
+			if err := r.drainObject(ctx, dr, dryRun); err != nil {
+				return drained, err
+			}
+			drained = append(drained, fmt.Sprintf("DestinationRule %s/%s", dr.Namespace, dr.Name))
+		}
+
+		serviceList := &corev1.ServiceList{}
+		if err := r.List(ctx, serviceList, client.InNamespace(ns)); err != nil {
+			return drained, fmt.Errorf("failed to list services in namespace %s: %w", ns, err)
+		}
+
+		for i := range serviceList.Items {
+			service := &serviceList.Items[i]
+			if !r.isPlaceholderService(ctx, service, config) {
+				continue
+			}
+			if err := r.drainObject(ctx, service, dryRun); err != nil {
+				return drained, err
+			}
+			drained = append(drained, fmt.Sprintf("Service %s/%s", service.Namespace, service.Name))
+		}
+	}
+
+	log.Info("Drained operator-managed objects", "count", len(drained), "dryRun", dryRun)
+	return drained, nil
+}
+
+// drainObject deletes a single managed object unless running in dry-run mode
+func (r *ServiceReconciler) drainObject(ctx context.Context, obj client.Object, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+	if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+	}
+	return nil
+}
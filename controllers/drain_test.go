@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/utils"
+)
+
+// drainTestEnv has a managed VirtualService, placeholders in alice and bob, a managed DestinationRule
+// in alice and a hand-written DestinationRule in bob
+func drainTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	managed := utils.GenerateDestinationRule(newService("alice", "app", nil), utils.DefaultClusterDomain, []string{"v2"}, "version", nil)
+	unmanaged := utils.GenerateDestinationRule(newService("bob", "app", nil), utils.DefaultClusterDomain, []string{"v2"}, "version", nil)
+	unmanaged.Labels = nil
+
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{newService("default", "app", nil), managed, unmanaged})
+	env.reconcile("default", "app")
+	env.takeWrites()
+	return env
+}
+
+var wantDrained = []string{
+	"DestinationRule alice/" + utils.DestinationRuleName("app"),
+	"Service alice/app",
+	"Service bob/app",
+	"VirtualService default/app-virtual-service",
+}
+
+func TestDrainDryRun(t *testing.T) {
+	env := drainTestEnv(t)
+
+	drained, err := env.reconciler.Drain(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(drained)
+	if !reflect.DeepEqual(drained, wantDrained) {
+		t.Errorf("drained = %q, want %q", drained, wantDrained)
+	}
+	if writes := env.takeWrites(); len(writes) != 0 {
+		t.Errorf("dry-run drain made %d writes", len(writes))
+	}
+	if env.destinationRule("alice", utils.DestinationRuleName("app")) == nil {
+		t.Error("dry-run drain deleted the DestinationRule")
+	}
+}
+
+func TestDrainDeletesManagedObjects(t *testing.T) {
+	env := drainTestEnv(t)
+
+	drained, err := env.reconciler.Drain(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(drained)
+	if !reflect.DeepEqual(drained, wantDrained) {
+		t.Errorf("drained = %q, want %q", drained, wantDrained)
+	}
+
+	if env.virtualService("default", "app-virtual-service") != nil {
+		t.Error("managed VirtualService was not deleted")
+	}
+	if env.destinationRule("alice", utils.DestinationRuleName("app")) != nil {
+		t.Error("managed DestinationRule was not deleted")
+	}
+	for _, ns := range []string{"alice", "bob"} {
+		if env.service(ns, "app") != nil {
+			t.Errorf("placeholder in %s was not deleted", ns)
+		}
+	}
+	if env.destinationRule("bob", utils.DestinationRuleName("app")) == nil {
+		t.Error("unmanaged DestinationRule was deleted")
+	}
+	if env.service("default", "app") == nil {
+		t.Error("source service was deleted")
+	}
+}
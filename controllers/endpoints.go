@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"virtualservice-operator/internal/config"
+)
+
+// hasReadyEndpoints checks if any EndpointSlice of the service has a ready endpoint.
+// ExternalName services have no endpoints and are always considered ready.
+func (r *ServiceReconciler) hasReadyEndpoints(ctx context.Context, service *corev1.Service) (bool, error) {
+	if service.Spec.Type == corev1.ServiceTypeExternalName {
+		return true, nil
+	}
+
+	sliceList := &discoveryv1.EndpointSliceList{}
+	if err := r.List(ctx, sliceList, client.InNamespace(service.Namespace), client.MatchingLabels{discoveryv1.LabelServiceName: service.Name}); err != nil {
+		return false, fmt.Errorf("failed to list EndpointSlices for service %s/%s: %w", service.Namespace, service.Name, err)
+	}
+
+	for i := range sliceList.Items {
+		if sliceHasReadyEndpoint(&sliceList.Items[i]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// sliceHasReadyEndpoint checks if an EndpointSlice has a ready endpoint
+func sliceHasReadyEndpoint(slice *discoveryv1.EndpointSlice) bool {
+	for _, endpoint := range slice.Endpoints {
+		// A nil ready condition means the endpoint is ready
+		if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+			return true
+		}
+	}
+	return false
+}
+
+// endpointReadinessPredicate passes EndpointSlice events that can change whether a service has ready
+// endpoints: slices appearing or disappearing, and updates flipping the slice between ready and not ready.
+// Endpoint churn that leaves the readiness unchanged, e.g. a rolling restart, is dropped.
+var endpointReadinessPredicate = predicate.Funcs{
+	CreateFunc: func(event.CreateEvent) bool { return true },
+	DeleteFunc: func(event.DeleteEvent) bool { return true },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldSlice, okOld := e.ObjectOld.(*discoveryv1.EndpointSlice)
+		newSlice, okNew := e.ObjectNew.(*discoveryv1.EndpointSlice)
+		if !okOld || !okNew {
+			return true
+		}
+		return sliceHasReadyEndpoint(oldSlice) != sliceHasReadyEndpoint(newSlice)
+	},
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
+
+// endpointSliceToRequests reconciles the developer service an EndpointSlice belongs to, so its route is added
+// or withdrawn as soon as the service gains or loses its ready endpoints instead of on the next recheck
+func (r *ServiceReconciler) endpointSliceToRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	serviceName := obj.GetLabels()[discoveryv1.LabelServiceName]
+	if serviceName == "" {
+		return nil
+	}
+
+	operatorConfig, err := r.ConfigManager.GetConfig(ctx)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to get operator config for EndpointSlice event", "endpointSlice", obj.GetName(), "namespace", obj.GetNamespace())
+		return nil
+	}
+	// Only developer routes are gated on readiness
+	if !operatorConfig.RequireReadyEndpoints || obj.GetNamespace() == operatorConfig.DefaultNamespace {
+		return nil
+	}
+
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: serviceName, Namespace: obj.GetNamespace()},
+	}}
+}
+
+// withholdsRoute checks if the route of a developer service must be withheld because RequireReadyEndpoints
+// is set and the service has no ready endpoints. Withheld routes are reported with an event and a metric.
+func (r *ServiceReconciler) withholdsRoute(ctx context.Context, devService *corev1.Service, config *config.OperatorConfig) (bool, error) {
+	if !config.RequireReadyEndpoints {
+		return false, nil
+	}
+
+	ready, err := r.hasReadyEndpoints(ctx, devService)
+	if err != nil || ready {
+		return false, err
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Withholding route for developer service without ready endpoints", "service", devService.Name, "namespace", devService.Namespace)
+	r.recorder().Event(devService, corev1.EventTypeWarning, "NoReadyEndpoints", "Route withheld until the service has ready endpoints")
+	routesWithheldTotal.WithLabelValues(devService.Namespace).Inc()
+	return true, nil
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// newEndpointSlice builds the EndpointSlice of a service with the given number of endpoints, all ready or not
+func newEndpointSlice(namespace, service string, endpoints int, ready bool) *discoveryv1.EndpointSlice {
+	slice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      service + "-abcde",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: service},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+	}
+	for i := 0; i < endpoints; i++ {
+		slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{
+			Addresses:  []string{"10.1.0.1"},
+			Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+		})
+	}
+	return slice
+}
+
+// TestEndpointSliceTogglesDeveloperRoute scales a developer deployment to zero and back by rewriting the
+// EndpointSlice, and checks every change reaches the developer service's reconcile and toggles its route
+func TestEndpointSliceTogglesDeveloperRoute(t *testing.T) {
+	slice := newEndpointSlice("alice", "app", 2, true)
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"requireReadyEndpoints: true\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+		slice,
+	})
+	env.reconcile("default", "app")
+	env.reconcile("alice", "app")
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, []string{"alice"}) {
+		t.Fatalf("routed namespaces = %v, want [alice]", got)
+	}
+
+	// setEndpoints writes the slice like the EndpointSlice controller and reconciles what the watch enqueues
+	setEndpoints := func(endpoints int, ready bool) {
+		t.Helper()
+		current := &discoveryv1.EndpointSlice{}
+		if err := env.client.Get(context.Background(), client.ObjectKeyFromObject(slice), current); err != nil {
+			t.Fatal(err)
+		}
+		updated := current.DeepCopy()
+		updated.Endpoints = newEndpointSlice("alice", "app", endpoints, ready).Endpoints
+		if err := env.client.Update(context.Background(), updated); err != nil {
+			t.Fatal(err)
+		}
+
+		if !endpointReadinessPredicate.Update(event.UpdateEvent{ObjectOld: current, ObjectNew: updated}) {
+			t.Fatal("readiness change filtered out by the EndpointSlice predicate")
+		}
+		requests := env.reconciler.endpointSliceToRequests(context.Background(), updated)
+		want := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "alice", Name: "app"}}}
+		if !reflect.DeepEqual(requests, want) {
+			t.Fatalf("EndpointSlice mapped to %v, want %v", requests, want)
+		}
+		env.reconcile("alice", "app")
+	}
+
+	setEndpoints(0, false)
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); len(got) != 0 {
+		t.Errorf("route kept after scaling to zero: %v", got)
+	}
+
+	setEndpoints(1, true)
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, []string{"alice"}) {
+		t.Errorf("routed namespaces after scaling back up = %v, want [alice]", got)
+	}
+}
+
+func TestEndpointReadinessPredicate(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new *discoveryv1.EndpointSlice
+		want     bool
+	}{
+		{name: "scaled to zero", old: newEndpointSlice("alice", "app", 2, true), new: newEndpointSlice("alice", "app", 0, false), want: true},
+		{name: "became not ready", old: newEndpointSlice("alice", "app", 1, true), new: newEndpointSlice("alice", "app", 1, false), want: true},
+		{name: "became ready", old: newEndpointSlice("alice", "app", 1, false), new: newEndpointSlice("alice", "app", 1, true), want: true},
+		{name: "scaled while ready", old: newEndpointSlice("alice", "app", 1, true), new: newEndpointSlice("alice", "app", 3, true)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := endpointReadinessPredicate.Update(event.UpdateEvent{ObjectOld: tt.old, ObjectNew: tt.new}); got != tt.want {
+				t.Errorf("Update() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndpointSliceToRequestsSkipsDefaultNamespace(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"requireReadyEndpoints: true\n"), nil)
+	if requests := env.reconciler.endpointSliceToRequests(context.Background(), newEndpointSlice("default", "app", 1, true)); len(requests) != 0 {
+		t.Errorf("default namespace EndpointSlice mapped to %v", requests)
+	}
+
+	env = newTestEnv(t, testConfig(t, handlerTestConfig), nil)
+	if requests := env.reconciler.endpointSliceToRequests(context.Background(), newEndpointSlice("alice", "app", 1, true)); len(requests) != 0 {
+		t.Errorf("EndpointSlice mapped to %v without requireReadyEndpoints", requests)
+	}
+}
+
+func TestRequireReadyEndpoints(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"requireReadyEndpoints: true\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+		newService("bob", "app", nil),
+		newEndpointSlice("alice", "app", 1, true),
+		newEndpointSlice("bob", "app", 2, false),
+	})
+	withheld := testutil.ToFloat64(routesWithheldTotal.WithLabelValues("bob"))
+
+	// The default namespace service only routes to alice, which has a ready endpoint
+	env.reconcile("default", "app")
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, []string{"alice"}) {
+		t.Errorf("routed namespaces = %v, want [alice]", got)
+	}
+
+	// Bob is rechecked until it has ready endpoints
+	if result := env.reconcile("bob", "app"); result.RequeueAfter != endpointsRecheckInterval {
+		t.Errorf("bob requeued after %v, want %v", result.RequeueAfter, endpointsRecheckInterval)
+	}
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, []string{"alice"}) {
+		t.Errorf("routed namespaces after reconciling bob = %v, want [alice]", got)
+	}
+	if !recordedEvent(env.recorder, "NoReadyEndpoints") {
+		t.Error("no NoReadyEndpoints event for the withheld route")
+	}
+	if got := testutil.ToFloat64(routesWithheldTotal.WithLabelValues("bob")) - withheld; got != 2 {
+		t.Errorf("withheld routes metric increased by %v, want 2", got)
+	}
+}
+
+func TestHasReadyEndpoints(t *testing.T) {
+	unknown := newEndpointSlice("alice", "app", 1, true)
+	unknown.Endpoints[0].Conditions.Ready = nil
+	externalName := newService("alice", "external", nil)
+	externalName.Spec = corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "example.com"}
+
+	tests := []struct {
+		name    string
+		service *corev1.Service
+		slices  []client.Object
+		want    bool
+	}{
+		{name: "ready", service: newService("alice", "app", nil), slices: []client.Object{newEndpointSlice("alice", "app", 1, true)}, want: true},
+		{name: "unknown readiness counts as ready", service: newService("alice", "app", nil), slices: []client.Object{unknown}, want: true},
+		{name: "not ready", service: newService("alice", "app", nil), slices: []client.Object{newEndpointSlice("alice", "app", 3, false)}},
+		{name: "scaled to zero", service: newService("alice", "app", nil), slices: []client.Object{newEndpointSlice("alice", "app", 0, false)}},
+		{name: "no EndpointSlice", service: newService("alice", "app", nil)},
+		{name: "slice of another service", service: newService("alice", "app", nil), slices: []client.Object{newEndpointSlice("alice", "other", 1, true)}},
+		{name: "ExternalName", service: externalName, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, testConfig(t, handlerTestConfig), tt.slices)
+			got, err := env.reconciler.hasReadyEndpoints(context.Background(), tt.service)
+			if err != nil || got != tt.want {
+				t.Errorf("hasReadyEndpoints() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// headerRouteOrder lists the routes of a VirtualService as the header and value they match, "default" for
+// the route without a match
+func headerRouteOrder(vs *istionetworkingv1beta1.VirtualService) []string {
+	var order []string
+	for _, route := range vs.Spec.Http {
+		if len(route.Match) == 0 {
+			order = append(order, "default")
+			continue
+		}
+		for header, match := range route.Match[0].Headers {
+			order = append(order, header+"="+match.GetExact())
+		}
+	}
+	return order
+}
+
+func TestFallbackHeaderRouteOrdering(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"fallbackHeader: x-fallback\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+		newService("bob", "app", nil),
+	})
+	env.reconcile("default", "app")
+
+	// Every x-developer route comes before the fallback routes, so a request with x-developer: alice and
+	// x-fallback: bob reaches alice, and reaches bob only once alice's service is gone
+	want := []string{"x-developer=alice", "x-developer=bob", "x-fallback=alice", "x-fallback=bob", "default"}
+	if got := headerRouteOrder(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, want) {
+		t.Fatalf("routes = %v, want %v", got, want)
+	}
+
+	// Reconciling the developer services again leaves the order alone
+	env.takeWrites()
+	env.reconcile("alice", "app")
+	env.reconcile("bob", "app")
+	for _, w := range env.takeWrites() {
+		t.Errorf("unexpected %s of %s %s", w.verb, w.object.GetNamespace(), w.object.GetName())
+	}
+
+	env.deleteObject(newService("alice", "app", nil))
+	env.reconcile("alice", "app")
+	want = []string{"x-developer=bob", "x-fallback=bob", "default"}
+	if got := headerRouteOrder(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, want) {
+		t.Errorf("routes after deleting alice's service = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// serviceNameIndex indexes the cached services by name, so the same-named services of all
+// namespaces can be looked up without listing every service in the cluster
+const serviceNameIndex = "serviceName"
+
+// indexServiceName is the index function of serviceNameIndex
+func indexServiceName(obj client.Object) []string {
+	return []string{obj.GetName()}
+}
+
+// sameNameServiceRequests reconciles the same-named services in the other watched namespaces when a service
+// appears or disappears. The default namespace service and its developer services share one VirtualService
+// and one set of placeholders, so a service moved between namespaces, i.e. deleted in one and created in
+// another, must converge as a whole rather than through two independently handled events. The default
+// namespace is always included, its reconcile cleans up after a default service that no longer exists.
+func (r *ServiceReconciler) sameNameServiceRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := ctrl.LoggerFrom(ctx)
+
+	operatorConfig, err := r.ConfigManager.GetConfig(ctx)
+	if err != nil {
+		log.Error(err, "Failed to get operator config for same-name service fan-out", "service", obj.GetName(), "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	serviceList := &corev1.ServiceList{}
+	if err := r.List(ctx, serviceList, client.MatchingFields{serviceNameIndex: obj.GetName()}); err != nil {
+		log.Error(err, "Failed to list same-name services", "service", obj.GetName())
+		return nil
+	}
+
+	seen := map[string]bool{obj.GetNamespace(): true}
+	var requests []reconcile.Request
+	enqueue := func(namespace string) {
+		if seen[namespace] {
+			return
+		}
+		seen[namespace] = true
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: obj.GetName(), Namespace: namespace},
+		})
+	}
+
+	enqueue(operatorConfig.DefaultNamespace)
+	for _, service := range serviceList.Items {
+		if r.ConfigManager.IsWatchedNamespace(ctx, service.Namespace) {
+			enqueue(service.Namespace)
+		}
+	}
+	return requests
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const fanoutTestConfig = `
+defaultNamespace: default
+developerNamespaces: [alice, bob]
+`
+
+// serviceEvent reconciles a created or deleted service like the controller does: the service itself,
+// then the same-named services its lifecycle event fans out to
+func (e *testEnv) serviceEvent(service *corev1.Service) {
+	e.t.Helper()
+	e.reconcile(service.Namespace, service.Name)
+	for _, request := range e.reconciler.sameNameServiceRequests(context.Background(), service) {
+		e.reconcile(request.Namespace, request.Name)
+	}
+}
+
+func TestSameNameServiceRequests(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, fanoutTestConfig), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+		newService("bob", "app", nil),
+		newService("carol", "app", nil),
+		newService("bob", "other", nil),
+	})
+
+	tests := []struct {
+		name    string
+		service *corev1.Service
+		want    []types.NamespacedName
+	}{
+		{
+			name:    "developer service",
+			service: newService("alice", "app", nil),
+			want:    []types.NamespacedName{{Namespace: "default", Name: "app"}, {Namespace: "bob", Name: "app"}},
+		},
+		{
+			name:    "default service",
+			service: newService("default", "app", nil),
+			want:    []types.NamespacedName{{Namespace: "alice", Name: "app"}, {Namespace: "bob", Name: "app"}},
+		},
+		{
+			name:    "no same-named services",
+			service: newService("bob", "gone", nil),
+			want:    []types.NamespacedName{{Namespace: "default", Name: "gone"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []types.NamespacedName
+			for _, request := range env.reconciler.sameNameServiceRequests(context.Background(), tt.service) {
+				got = append(got, request.NamespacedName)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requests = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceMoveConverges(t *testing.T) {
+	tests := []struct {
+		name       string
+		objects    []client.Object
+		from, to   *corev1.Service
+		wantVS     bool
+		wantRoutes []string
+	}{
+		{
+			name:       "between developer namespaces",
+			objects:    []client.Object{newService("default", "app", nil), newService("alice", "app", nil)},
+			from:       newService("alice", "app", nil),
+			to:         newService("bob", "app", nil),
+			wantVS:     true,
+			wantRoutes: []string{"bob"},
+		},
+		{
+			name:    "from the default namespace to a developer namespace",
+			objects: []client.Object{newService("default", "app", nil), newService("alice", "app", nil)},
+			from:    newService("default", "app", nil),
+			to:      newService("bob", "app", nil),
+		},
+		{
+			name:    "from a developer namespace to the default namespace",
+			objects: []client.Object{newService("alice", "app", nil)},
+			from:    newService("alice", "app", nil),
+			to:      newService("default", "app", nil),
+			wantVS:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, testConfig(t, fanoutTestConfig), tt.objects)
+			for _, obj := range tt.objects {
+				env.reconcile(obj.GetNamespace(), obj.GetName())
+			}
+
+			// The service is deleted in one namespace and created in another
+			env.deleteObject(env.service(tt.from.Namespace, tt.from.Name))
+			env.serviceEvent(tt.from)
+			if err := env.client.Create(context.Background(), tt.to); err != nil {
+				t.Fatal(err)
+			}
+			env.serviceEvent(tt.to)
+
+			vs := env.virtualService("default", "app-virtual-service")
+			if (vs != nil) != tt.wantVS {
+				t.Fatalf("VirtualService exists = %v, want %v", vs != nil, tt.wantVS)
+			}
+			if vs == nil {
+				return
+			}
+			if got := routedDeveloperNamespaces(vs); !reflect.DeepEqual(got, tt.wantRoutes) {
+				t.Errorf("developer routes = %v, want %v", got, tt.wantRoutes)
+			}
+
+			// Converged: reconciling everything again changes nothing
+			env.takeWrites()
+			for _, namespace := range []string{"default", "alice", "bob"} {
+				env.reconcile(namespace, "app")
+			}
+			if writes := env.takeWrites(); len(writes) != 0 {
+				t.Errorf("%d writes after the move converged", len(writes))
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"virtualservice-operator/internal/config"
+)
+
+// gatewaysAnnotation overrides the gateways the VirtualService of a default namespace service is attached to,
+// e.g. "mesh,istio-system/external-gw"
+const gatewaysAnnotation = "virtualservice-operator/gateways"
+
+// parseGateways parses a comma-separated list of gateways in the "mesh" or "namespace/name" form
+func parseGateways(value string) ([]string, error) {
+	var gateways []string
+	for _, entry := range strings.Split(value, ",") {
+		gateway := strings.TrimSpace(entry)
+		if gateway == "" {
+			continue
+		}
+		if msgs := config.ValidateGateway(gateway); len(msgs) > 0 {
+			return nil, fmt.Errorf("invalid gateway %q: %s", gateway, strings.Join(msgs, "; "))
+		}
+		gateways = append(gateways, gateway)
+	}
+	if len(gateways) == 0 {
+		return nil, fmt.Errorf("no gateways in %q", value)
+	}
+	return gateways, nil
+}
+
+// serviceGateways returns the gateways for the VirtualService of a service: the gateways annotation
+// if present, otherwise the configured gateways. An invalid annotation falls back to the configured gateways.
+func serviceGateways(service *corev1.Service, operatorConfig *config.OperatorConfig) ([]string, error) {
+	if !hasAnnotation(service, gatewaysAnnotation) {
+		return operatorConfig.Gateways, nil
+	}
+	gateways, err := parseGateways(getAnnotation(service, gatewaysAnnotation))
+	if err != nil {
+		return operatorConfig.Gateways, err
+	}
+	return gateways, nil
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestParseGateways(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr string
+	}{
+		{value: "mesh", want: []string{"mesh"}},
+		{value: " mesh , istio-system/external-gw ,", want: []string{"mesh", "istio-system/external-gw"}},
+		{value: "", wantErr: "no gateways"},
+		{value: " , ", wantErr: "no gateways"},
+		{value: "mesh,a/b/c", wantErr: `invalid gateway "a/b/c"`},
+		{value: "istio-system/", wantErr: `invalid gateway "istio-system/"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseGateways(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseGateways() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGatewaysAnnotation(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        []string
+		wantWarned  bool
+	}{
+		{name: "configured gateways", want: []string{"istio-system/internal"}},
+		{name: "override", annotations: map[string]string{gatewaysAnnotation: "mesh,istio-system/external-gw"}, want: []string{"mesh", "istio-system/external-gw"}},
+		{name: "invalid override", annotations: map[string]string{gatewaysAnnotation: "a/b/c"}, want: []string{"istio-system/internal"}, wantWarned: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, testConfig(t, handlerTestConfig+"gateways: [istio-system/internal]\n"), []client.Object{
+				newService("default", "app", tt.annotations),
+			})
+			env.reconcile("default", "app")
+
+			if got := env.virtualService("default", "app-virtual-service").Spec.Gateways; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("gateways = %v, want %v", got, tt.want)
+			}
+			if warned := recordedEvent(env.recorder, "InvalidAnnotation", "gateways"); warned != tt.wantWarned {
+				t.Errorf("warned = %v, want %v", warned, tt.wantWarned)
+			}
+		})
+	}
+}
+
+func TestGatewaysAnnotationChange(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", map[string]string{gatewaysAnnotation: "istio-system/external-gw"}),
+	})
+	env.reconcile("default", "app")
+
+	env.updateService("default", "app", func(service *corev1.Service) {
+		delete(service.Annotations, gatewaysAnnotation)
+	})
+	env.reconcile("default", "app")
+
+	if got := env.virtualService("default", "app-virtual-service").Spec.Gateways; len(got) != 0 {
+		t.Errorf("gateways after removing the override = %v, want the configured none", got)
+	}
+}
@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/config"
+	"virtualservice-operator/internal/utils"
+)
+
+const (
+	// groupAnnotation on a default namespace service aggregates it with the other services of the same
+	// group into one VirtualService named "<group>-group-virtual-service", routed by path
+	groupAnnotation = "virtualservice-operator/group"
+	// groupPathAnnotation sets the path prefix routed to a group member, defaults to "/<service name>"
+	groupPathAnnotation = "virtualservice-operator/group-path"
+	// groupHostsAnnotation adds comma-separated hosts to the group VirtualService, defaults to the group name
+	groupHostsAnnotation = "virtualservice-operator/group-hosts"
+)
+
+// serviceGroup returns the group a default namespace service belongs to, or "" if it isn't grouped
+func serviceGroup(service *corev1.Service) string {
+	return strings.TrimSpace(getAnnotation(service, groupAnnotation))
+}
+
+// groupPath returns the path prefix routed to a group member
+func groupPath(service *corev1.Service) string {
+	if path := strings.TrimSpace(getAnnotation(service, groupPathAnnotation)); path != "" {
+		return path
+	}
+	return "/" + service.Name
+}
+
+// desiredGroupVirtualService computes the VirtualService of a service group from its current members without
+// writing anything. It returns nil when the group has no members.
+func (r *ServiceReconciler) desiredGroupVirtualService(ctx context.Context, group string, config *config.OperatorConfig) (*istionetworkingv1beta1.VirtualService, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	if msgs := validation.IsDNS1123Label(group); len(msgs) > 0 {
+		return nil, fmt.Errorf("invalid service group %q: %s", group, strings.Join(msgs, "; "))
+	}
+
+	serviceList := &corev1.ServiceList{}
+	if err := r.List(ctx, serviceList, client.InNamespace(config.DefaultNamespace)); err != nil {
+		return nil, fmt.Errorf("failed to list members of service group %s: %w", group, err)
+	}
+
+	var members []utils.GroupMember
+	hosts := map[string]bool{}
+	gateways := map[string]bool{}
+	for i := range serviceList.Items {
+		service := &serviceList.Items[i]
+		if serviceGroup(service) != group || !service.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if r.isSystemService(service.Name) || !config.SelectsService(service) {
+			continue
+		}
+		service, err := r.applyServicePolicy(ctx, service, config)
+		if err != nil {
+			return nil, err
+		}
+
+		namespaces, routeOptions, err := r.collectDeveloperRoutes(ctx, service, config)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, utils.GroupMember{
+			ServiceName:         service.Name,
+			PathPrefix:          groupPath(service),
+			DeveloperNamespaces: namespaces,
+			RouteOptions:        routeOptions,
+			Timeout:             r.routeTimeout(ctx, service, config),
+		})
+
+		for _, host := range strings.Split(getAnnotation(service, groupHostsAnnotation), ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				hosts[host] = true
+			}
+		}
+		memberGateways, err := serviceGateways(service, config)
+		if err != nil {
+			log.Error(err, "Ignoring invalid gateways annotation", "service", service.Name, "namespace", service.Namespace)
+		}
+		for _, gateway := range memberGateways {
+			gateways[gateway] = true
+		}
+	}
+
+	if len(members) == 0 {
+		return nil, nil
+	}
+	if len(hosts) == 0 {
+		hosts[group] = true
+	}
+	return utils.GenerateGroupVirtualService(group, sortedKeys(hosts), config.DefaultNamespace, members, utils.RouteOptions{
+		Gateways:           sortedKeys(gateways),
+		Annotations:        config.VirtualServiceAnnotations,
+		LocalClusterDomain: config.ClusterDomain,
+	}), nil
+}
+
+// reconcileGroup regenerates the VirtualService of a service group from its current members, so adding or
+// removing a member, or one of their developer services, always converges to the same VirtualService.
+// The VirtualService is deleted once the group has no members left.
+func (r *ServiceReconciler) reconcileGroup(ctx context.Context, group string, config *config.OperatorConfig) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	vs, err := r.desiredGroupVirtualService(ctx, group, config)
+	if err != nil {
+		return err
+	}
+
+	vsName := utils.GroupVirtualServiceName(group)
+	existingVS := &istionetworkingv1beta1.VirtualService{}
+	err = r.Get(ctx, types.NamespacedName{Name: vsName, Namespace: config.DefaultNamespace}, existingVS)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+	if exists && !utils.IsManagedByOperator(existingVS) {
+		log.Info("Group VirtualService exists but isn't managed by the operator, leaving it alone", "group", group, "virtualService", vsName)
+		return nil
+	}
+
+	if vs == nil {
+		if !exists {
+			return nil
+		}
+		log.Info("Deleting VirtualService of empty service group", "group", group, "virtualService", vsName)
+		if err := r.Delete(ctx, existingVS); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	if !exists {
+		log.Info("Creating VirtualService for service group", "group", group, "virtualService", vsName, "members", utils.GroupMembers(vs))
+		utils.StampDeveloperRoutes(vs, r.clock().Now(), 0)
+		if err := r.dryRunVirtualService(ctx, vs, true); err != nil {
+			return err
+		}
+		return r.Create(ctx, vs)
+	}
+
+	return r.retryVirtualServiceUpdate(ctx, existingVS, func(latest *istionetworkingv1beta1.VirtualService) error {
+		latest.Spec.Hosts = vs.Spec.Hosts
+		latest.Spec.Gateways = vs.Spec.Gateways
+		latest.Spec.Http = vs.Spec.Http
+		utils.SyncManagedAnnotations(latest, vs)
+		setAnnotation(latest, utils.GroupMembersAnnotation, vs.Annotations[utils.GroupMembersAnnotation])
+
+		// The desired routes cover every live developer service, reaped routes included,
+		// so a timestamp without a desired route belongs to a developer service that is gone
+		desired := map[string]bool{}
+		for _, route := range vs.Spec.Http {
+			if devNamespace, ok := utils.DeveloperRouteNamespace(route); ok {
+				desired[devNamespace] = true
+			}
+		}
+		for devNamespace := range utils.RouteTimestamps(latest) {
+			if !desired[devNamespace] {
+				utils.ForgetRouteTimestamp(latest, devNamespace)
+			}
+		}
+		return nil
+	})
+}
+
+// reconcileFormerGroups reconciles the groups whose VirtualService still lists a service as member
+// although it now belongs to another group, or to none, or was deleted
+func (r *ServiceReconciler) reconcileFormerGroups(ctx context.Context, serviceName, currentGroup string, config *config.OperatorConfig) error {
+	vsList := &istionetworkingv1beta1.VirtualServiceList{}
+	if err := r.List(ctx, vsList, client.InNamespace(config.DefaultNamespace), client.HasLabels{utils.GroupLabel}); err != nil {
+		return fmt.Errorf("failed to list group VirtualServices: %w", err)
+	}
+
+	for _, vs := range vsList.Items {
+		group := vs.Labels[utils.GroupLabel]
+		if group == currentGroup || !utils.IsManagedByOperator(vs) {
+			continue
+		}
+		for _, member := range utils.GroupMembers(vs) {
+			if member == serviceName {
+				if err := r.reconcileGroup(ctx, group, config); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a set in sorted order
+func sortedKeys(set map[string]bool) []string {
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/utils"
+)
+
+const groupTestConfig = `
+defaultNamespace: default
+developerNamespaces: [alice, bob]
+`
+
+// groupRoutes describes the routes of a group VirtualService as "<path prefix> <destination host>"
+func groupRoutes(vs *istionetworkingv1beta1.VirtualService) []string {
+	var routes []string
+	for _, route := range vs.Spec.Http {
+		routes = append(routes, route.Match[0].Uri.GetPrefix()+" "+route.Route[0].Destination.Host)
+	}
+	return routes
+}
+
+func TestServiceGroup(t *testing.T) {
+	group := map[string]string{groupAnnotation: "api"}
+	env := newTestEnv(t, testConfig(t, groupTestConfig), []client.Object{
+		newService("default", "users", group),
+		newService("default", "orders", map[string]string{groupAnnotation: "api", groupPathAnnotation: "/v1/orders"}),
+		newService("alice", "users", nil),
+	})
+	env.reconcile("default", "users")
+	env.reconcile("default", "orders")
+
+	vs := env.virtualService("default", "api-group-virtual-service")
+	if vs == nil {
+		t.Fatal("group VirtualService not created")
+	}
+	if vs.Labels[utils.GroupLabel] != "api" {
+		t.Errorf("group label = %q, want api", vs.Labels[utils.GroupLabel])
+	}
+	if want := []string{"api"}; !reflect.DeepEqual(vs.Spec.Hosts, want) {
+		t.Errorf("hosts = %v, want %v", vs.Spec.Hosts, want)
+	}
+	if got, want := utils.GroupMembers(vs), []string{"orders", "users"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("members = %v, want %v", got, want)
+	}
+
+	// The longer prefix comes first, alice's route precedes the default route of its member
+	wantRoutes := []string{
+		"/v1/orders orders.default.svc.cluster.local",
+		"/users users.alice.svc.cluster.local",
+		"/users users.default.svc.cluster.local",
+	}
+	if got := groupRoutes(vs); !reflect.DeepEqual(got, wantRoutes) {
+		t.Errorf("routes = %q, want %q", got, wantRoutes)
+	}
+	if namespace, ok := utils.DeveloperRouteNamespace(vs.Spec.Http[1]); !ok || namespace != "alice" {
+		t.Errorf("users route 0 is not alice's developer route")
+	}
+
+	for _, name := range []string{"users", "orders"} {
+		if env.virtualService("default", name+"-virtual-service") != nil {
+			t.Errorf("grouped service %s got its own VirtualService", name)
+		}
+	}
+}
+
+func TestServiceGroupMemberRemoval(t *testing.T) {
+	group := map[string]string{groupAnnotation: "api"}
+	env := newTestEnv(t, testConfig(t, groupTestConfig), []client.Object{
+		newService("default", "users", group),
+		newService("default", "orders", group),
+	})
+	env.reconcile("default", "users")
+	env.reconcile("default", "orders")
+
+	// A member leaving the group is dropped from the group and gets its own VirtualService
+	env.updateService("default", "orders", func(service *corev1.Service) {
+		delete(service.Annotations, groupAnnotation)
+	})
+	env.reconcile("default", "orders")
+
+	vs := env.virtualService("default", "api-group-virtual-service")
+	if vs == nil {
+		t.Fatal("group VirtualService deleted with a member left")
+	}
+	if got, want := utils.GroupMembers(vs), []string{"users"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("members = %v, want %v", got, want)
+	}
+	if want := []string{"/users users.default.svc.cluster.local"}; !reflect.DeepEqual(groupRoutes(vs), want) {
+		t.Errorf("routes = %q, want %q", groupRoutes(vs), want)
+	}
+	if env.virtualService("default", "orders-virtual-service") == nil {
+		t.Error("former member has no VirtualService of its own")
+	}
+
+	// Deleting the last member deletes the group VirtualService
+	env.deleteObject(env.service("default", "users"))
+	env.reconcile("default", "users")
+	if env.virtualService("default", "api-group-virtual-service") != nil {
+		t.Error("group VirtualService of an empty group still exists")
+	}
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// requestHeadersAnnotation manipulates request headers on the routes of a service,
+	// e.g. "set:x-debug=true,remove:x-internal"
+	requestHeadersAnnotation = "virtualservice-operator/request-headers"
+	// responseHeadersAnnotation manipulates response headers on the routes of a service,
+	// e.g. "set:x-served-by=dev-alice"
+	responseHeadersAnnotation = "virtualservice-operator/response-headers"
+)
+
+// parseHeaderOperations parses a comma-separated list of "set:key=value" and "remove:key" entries
+func parseHeaderOperations(value string) (*istiov1beta1.Headers_HeaderOperations, error) {
+	ops := &istiov1beta1.Headers_HeaderOperations{}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		action, operand, found := strings.Cut(entry, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid header operation %q, expected set:key=value or remove:key", entry)
+		}
+
+		switch action {
+		case "set":
+			key, val, found := strings.Cut(operand, "=")
+			if !found {
+				return nil, fmt.Errorf("invalid header operation %q, expected set:key=value", entry)
+			}
+			if err := validateHeaderName(key); err != nil {
+				return nil, err
+			}
+			if ops.Set == nil {
+				ops.Set = map[string]string{}
+			}
+			ops.Set[key] = val
+		case "remove":
+			if err := validateHeaderName(operand); err != nil {
+				return nil, err
+			}
+			ops.Remove = append(ops.Remove, operand)
+		default:
+			return nil, fmt.Errorf("unknown header operation %q, expected set or remove", action)
+		}
+	}
+
+	if ops.Set == nil && ops.Remove == nil {
+		return nil, nil
+	}
+	return ops, nil
+}
+
+// validateHeaderName checks that a header name is a valid HTTP token
+func validateHeaderName(name string) error {
+	if msgs := validation.IsHTTPHeaderName(name); len(msgs) > 0 {
+		return fmt.Errorf("invalid header name %q: %s", name, strings.Join(msgs, ", "))
+	}
+	return nil
+}
+
+// headersFromAnnotations builds the header manipulation of a route from the annotations of a service.
+// It returns nil if the service doesn't request any header manipulation.
+func headersFromAnnotations(service *corev1.Service) (*istiov1beta1.Headers, error) {
+	request, err := parseHeaderOperations(getAnnotation(service, requestHeadersAnnotation))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", requestHeadersAnnotation, err)
+	}
+	response, err := parseHeaderOperations(getAnnotation(service, responseHeadersAnnotation))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", responseHeadersAnnotation, err)
+	}
+
+	if request == nil && response == nil {
+		return nil, nil
+	}
+	return &istiov1beta1.Headers{Request: request, Response: response}, nil
+}
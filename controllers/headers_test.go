@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestParseHeaderOperations(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    *istiov1beta1.Headers_HeaderOperations
+		wantErr string
+	}{
+		{name: "empty"},
+		{name: "only separators", value: " , ,"},
+		{
+			name:  "set and remove",
+			value: "set:x-debug=true, remove:x-internal,set:x-empty=",
+			want: &istiov1beta1.Headers_HeaderOperations{
+				Set:    map[string]string{"x-debug": "true", "x-empty": ""},
+				Remove: []string{"x-internal"},
+			},
+		},
+		{name: "value with separator", value: "set:x-query=a=b", want: &istiov1beta1.Headers_HeaderOperations{Set: map[string]string{"x-query": "a=b"}}},
+		{name: "no action", value: "x-debug=true", wantErr: "expected set:key=value or remove:key"},
+		{name: "set without value", value: "set:x-debug", wantErr: "expected set:key=value"},
+		{name: "unknown action", value: "add:x-debug=true", wantErr: "unknown header operation"},
+		{name: "invalid header name", value: "remove:x debug", wantErr: "invalid header name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHeaderOperations(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(got, tt.want) {
+				t.Errorf("parseHeaderOperations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaderAnnotationsPopulateRoutes(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", map[string]string{requestHeadersAnnotation: "set:x-env=shared"}),
+		newService("alice", "app", map[string]string{
+			requestHeadersAnnotation:  "set:x-debug=true,remove:x-internal",
+			responseHeadersAnnotation: "set:x-served-by=dev-alice",
+		}),
+		newService("bob", "app", map[string]string{requestHeadersAnnotation: "set:x-debug"}),
+	})
+	env.reconcile("default", "app")
+	env.reconcile("alice", "app")
+	env.reconcile("bob", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	routes := map[string]*istiov1beta1.HTTPRoute{}
+	for _, route := range vs.Spec.Http {
+		if len(route.Match) == 0 {
+			routes["default"] = route
+		} else {
+			routes[route.Match[0].Headers["x-developer"].GetExact()] = route
+		}
+	}
+
+	want := map[string]*istiov1beta1.Headers{
+		"default": {Request: &istiov1beta1.Headers_HeaderOperations{Set: map[string]string{"x-env": "shared"}}},
+		"alice": {
+			Request:  &istiov1beta1.Headers_HeaderOperations{Set: map[string]string{"x-debug": "true"}, Remove: []string{"x-internal"}},
+			Response: &istiov1beta1.Headers_HeaderOperations{Set: map[string]string{"x-served-by": "dev-alice"}},
+		},
+		// An invalid annotation is ignored and reported, the route is still added
+		"bob": nil,
+	}
+	for name, headers := range want {
+		route, ok := routes[name]
+		if !ok {
+			t.Errorf("no %s route", name)
+			continue
+		}
+		if !proto.Equal(route.Headers, headers) {
+			t.Errorf("%s route headers = %v, want %v", name, route.Headers, headers)
+		}
+	}
+	if !recordedEvent(env.recorder, "InvalidAnnotation", "header manipulation") {
+		t.Error("no InvalidAnnotation event for the invalid header annotation")
+	}
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestInvalidVirtualServiceHosts(t *testing.T) {
+	longDomain := strings.Repeat("a", 60) + "." + strings.Repeat("b", 60) + ".example"
+	longName := strings.Repeat("s", 63)
+
+	tests := []struct {
+		name         string
+		hosts        []string
+		destinations []string
+		wantProblems int
+	}{
+		{name: "short name", hosts: []string{"app"}, destinations: []string{"app.default.svc.cluster.local"}},
+		{name: "fqdn", hosts: []string{"app.default.svc.cluster.local"}, destinations: []string{"app.alice.svc.cluster.local"}},
+		{name: "longest service name", hosts: []string{longName}, destinations: []string{longName + ".default.svc.cluster.local"}},
+		{name: "fqdn too long", hosts: []string{longName + "." + longName + ".svc." + longDomain}, wantProblems: 1},
+		{name: "destination too long", hosts: []string{"app"}, destinations: []string{longName + "." + longName + ".svc." + longDomain}, wantProblems: 1},
+		{name: "invalid domain label", hosts: []string{"app.default.svc.my_cluster"}, wantProblems: 1},
+		{name: "upper case", hosts: []string{"App"}, wantProblems: 1},
+		{name: "name starting with a digit", hosts: []string{"1app.default.svc.cluster.local"}, wantProblems: 1},
+		{name: "reported once", hosts: []string{"App"}, destinations: []string{"App"}, wantProblems: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vs := &istionetworkingv1beta1.VirtualService{}
+			vs.Spec.Hosts = tt.hosts
+			route := &istiov1beta1.HTTPRoute{}
+			for _, host := range tt.destinations {
+				route.Route = append(route.Route, &istiov1beta1.HTTPRouteDestination{Destination: &istiov1beta1.Destination{Host: host}})
+			}
+			vs.Spec.Http = []*istiov1beta1.HTTPRoute{route}
+
+			if problems := invalidVirtualServiceHosts(vs); len(problems) != tt.wantProblems {
+				t.Errorf("invalidVirtualServiceHosts() = %q, want %d problems", problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func TestIsValidIstioHost(t *testing.T) {
+	longName := strings.Repeat("s", 63)
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{host: "app", want: true},
+		{host: "my-app", want: true},
+		{host: "app.default.svc.cluster.local", want: true},
+		{host: "app.1team.svc.cluster.local", want: true},
+		{host: longName, want: true},
+		{host: longName + "s"},
+		{host: "1app"},
+		{host: "1app.default.svc.cluster.local"},
+		{host: "-app"},
+		{host: "app-"},
+		{host: "App"},
+		{host: "my_app"},
+		{host: "app.default.svc.my_cluster"},
+		{host: strings.Repeat(longName+".", 4) + "local"},
+		{host: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := isValidIstioHost(tt.host); got != tt.want {
+				t.Errorf("isValidIstioHost(%q) = %v, want %v", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleDefaultNamespaceServiceSkipsInvalidHosts(t *testing.T) {
+	// Every part is valid, only the host built from them is too long
+	longName := strings.Repeat("s", 63)
+	operatorConfig := testConfig(t, `
+useFQDNHosts: true
+developerNamespaces: [alice]
+clusterDomain: `+strings.Repeat("a", 60)+"."+strings.Repeat("b", 60)+"."+strings.Repeat("c", 60)+".example")
+	env := newTestEnv(t, operatorConfig, []client.Object{newService("default", longName, nil)})
+
+	env.reconcile("default", longName)
+
+	if vs := env.virtualService("default", longName+"-virtual-service"); vs != nil {
+		t.Errorf("VirtualService created with invalid hosts %v", vs.Spec.Hosts)
+	}
+	select {
+	case event := <-env.recorder.Events:
+		if !strings.Contains(event, "InvalidHost") {
+			t.Errorf("event = %q, want an InvalidHost warning", event)
+		}
+	default:
+		t.Error("no event recorded for the invalid hosts")
+	}
+}
+
+func TestFQDNHosts(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantHosts []string
+		domain    string
+	}{
+		{
+			name:      "short",
+			config:    "developerNamespaces: [alice]\n",
+			wantHosts: []string{"app"},
+			domain:    "cluster.local",
+		},
+		{
+			name:      "fqdn",
+			config:    "developerNamespaces: [alice]\nuseFQDNHosts: true\n",
+			wantHosts: []string{"app.default.svc.cluster.local"},
+			domain:    "cluster.local",
+		},
+		{
+			name:      "fqdn in cluster domain",
+			config:    "developerNamespaces: [alice]\nuseFQDNHosts: true\nclusterDomain: corp.example\n",
+			wantHosts: []string{"app.default.svc.corp.example"},
+			domain:    "corp.example",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, testConfig(t, tt.config), []client.Object{
+				newService("default", "app", nil),
+				newService("alice", "app", nil),
+			})
+			env.reconcile("default", "app")
+
+			vs := env.virtualService("default", "app-virtual-service")
+			if !reflect.DeepEqual(vs.Spec.Hosts, tt.wantHosts) {
+				t.Errorf("hosts = %v, want %v", vs.Spec.Hosts, tt.wantHosts)
+			}
+			assertAppDestinations(t, vs, tt.domain)
+		})
+	}
+}
+
+func TestFQDNHostsSwitchUpdatesVirtualService(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, "developerNamespaces: [alice]\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+	})
+	env.reconcile("default", "app")
+
+	env.config.set(testConfig(t, "developerNamespaces: [alice]\nuseFQDNHosts: true\n"))
+	env.reconcile("default", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if want := []string{"app.default.svc.cluster.local"}; !reflect.DeepEqual(vs.Spec.Hosts, want) {
+		t.Errorf("hosts = %v, want %v", vs.Spec.Hosts, want)
+	}
+	assertAppDestinations(t, vs, "cluster.local")
+}
+
+// assertAppDestinations checks the developer route of alice and the default route of app point at the FQDNs
+func assertAppDestinations(t *testing.T, vs *istionetworkingv1beta1.VirtualService, domain string) {
+	t.Helper()
+	if got, want := developerDestinations(vs)["alice"].GetHost(), "app.alice.svc."+domain; got != want {
+		t.Errorf("alice destination = %q, want %q", got, want)
+	}
+	defaultRoute := vs.Spec.Http[len(vs.Spec.Http)-1]
+	if got, want := defaultRoute.Route[0].Destination.Host, "app.default.svc."+domain; got != want {
+		t.Errorf("default destination = %q, want %q", got, want)
+	}
+}
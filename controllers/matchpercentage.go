@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// devMatchPercentageAnnotation on a developer service sends only this percentage of the traffic matched by its
+// developer route to the developer namespace, the rest of the matched traffic goes to the default namespace.
+// Unlike default-weight it applies to header-matched traffic only. A rollout annotation takes precedence.
+const devMatchPercentageAnnotation = "virtualservice-operator/dev-match-percentage"
+
+// parseMatchPercentage parses the value of the dev-match-percentage annotation
+func parseMatchPercentage(value string) (int32, error) {
+	percentage, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || percentage < 0 || percentage > 100 {
+		return 0, fmt.Errorf("invalid dev match percentage %q, expected an integer between 0 and 100", value)
+	}
+	return int32(percentage), nil
+}
+
+// matchPercentage returns the weight of the developer destination requested by the dev-match-percentage
+// annotation, nil if the service doesn't set one or it's invalid
+func (r *ServiceReconciler) matchPercentage(ctx context.Context, service *corev1.Service) *int32 {
+	if !hasAnnotation(service, devMatchPercentageAnnotation) {
+		return nil
+	}
+
+	percentage, err := parseMatchPercentage(getAnnotation(service, devMatchPercentageAnnotation))
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Ignoring invalid dev match percentage annotation", "service", service.Name, "namespace", service.Namespace)
+		r.recorder().Eventf(service, corev1.EventTypeWarning, "InvalidAnnotation", "Ignoring dev match percentage: %v", err)
+		return nil
+	}
+	return &percentage
+}
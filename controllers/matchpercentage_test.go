@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/utils"
+)
+
+func TestParseMatchPercentage(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int32
+		wantErr bool
+	}{
+		{value: "30", want: 30},
+		{value: "0", want: 0},
+		{value: "100", want: 100},
+		{value: "101", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "30%", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseMatchPercentage(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMatchPercentage(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseMatchPercentage(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// destinationWeights returns the weight of each destination host of a route
+func destinationWeights(route *istiov1beta1.HTTPRoute) map[string]int32 {
+	weights := map[string]int32{}
+	for _, destination := range route.Route {
+		weights[destination.Destination.Host] = destination.Weight
+	}
+	return weights
+}
+
+func TestDevMatchPercentage(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        map[string]int32
+		wantWarning bool
+	}{
+		{
+			name:        "weighted",
+			annotations: map[string]string{devMatchPercentageAnnotation: "30"},
+			want:        map[string]int32{"app.alice.svc.cluster.local": 30, "app.default.svc.cluster.local": 70},
+		},
+		{
+			name:        "all matched traffic",
+			annotations: map[string]string{devMatchPercentageAnnotation: "100"},
+			want:        map[string]int32{"app.alice.svc.cluster.local": 0},
+		},
+		{
+			name:        "invalid",
+			annotations: map[string]string{devMatchPercentageAnnotation: "thirty"},
+			want:        map[string]int32{"app.alice.svc.cluster.local": 0},
+			wantWarning: true,
+		},
+		{
+			name: "rollout takes precedence",
+			annotations: map[string]string{
+				devMatchPercentageAnnotation: "30",
+				rolloutAnnotation:            "start=10,step=10,interval=5m,target=100",
+				rolloutWeightAnnotation:      "20",
+			},
+			want: map[string]int32{"app.alice.svc.cluster.local": 20, "app.default.svc.cluster.local": 80},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+				newService("default", "app", nil),
+				newService("alice", "app", tt.annotations),
+			})
+			env.reconcile("default", "app")
+			env.reconcile("alice", "app")
+
+			vs := env.virtualService("default", "app-virtual-service")
+			if len(vs.Spec.Http) != 2 {
+				t.Fatalf("got %d routes, want the alice and default routes", len(vs.Spec.Http))
+			}
+			route := vs.Spec.Http[0]
+			if namespace, ok := utils.DeveloperRouteNamespace(route); !ok || namespace != "alice" {
+				t.Fatalf("route 0 is not alice's developer route")
+			}
+			// Still gated by the header
+			if got := route.Match[0].Headers["x-developer"].GetExact(); got != "alice" {
+				t.Errorf("route matches x-developer %q, want alice", got)
+			}
+			if got := destinationWeights(route); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("developer route weights = %v, want %v", got, tt.want)
+			}
+
+			// Unlike default-weight, traffic without the header is untouched
+			if got := destinationWeights(vs.Spec.Http[1]); !reflect.DeepEqual(got, map[string]int32{"app.default.svc.cluster.local": 0}) {
+				t.Errorf("default route weights = %v, want only the default service", got)
+			}
+
+			if warned := recordedEvent(env.recorder, "InvalidAnnotation", "dev match percentage"); warned != tt.wantWarning {
+				t.Errorf("InvalidAnnotation warning = %v, want %v", warned, tt.wantWarning)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getAnnotation returns the value of an annotation, or "" if the object has no such annotation
+func getAnnotation(obj metav1.Object, key string) string {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		return ""
+	}
+	return annotations[key]
+}
+
+// hasAnnotation checks if an object carries an annotation, regardless of its value
+func hasAnnotation(obj metav1.Object, key string) bool {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		return false
+	}
+	_, exists := annotations[key]
+	return exists
+}
+
+// setAnnotation sets an annotation, initializing the annotation map if needed
+func setAnnotation(obj metav1.Object, key, value string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = value
+	obj.SetAnnotations(annotations)
+}
+
+// removeAnnotation removes an annotation if present
+func removeAnnotation(obj metav1.Object, key string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		return
+	}
+	delete(annotations, key)
+	obj.SetAnnotations(annotations)
+}
+
+// hasLabel checks if an object carries a label with the given value
+func hasLabel(obj metav1.Object, key, value string) bool {
+	labels := obj.GetLabels()
+	if labels == nil {
+		return false
+	}
+	v, exists := labels[key]
+	return exists && v == value
+}
+
+// setLabel sets a label, initializing the label map if needed
+func setLabel(obj metav1.Object, key, value string) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[key] = value
+	obj.SetLabels(labels)
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// bareService is a service without annotation and label maps
+func bareService() *corev1.Service {
+	return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "alice", Name: "app"}}
+}
+
+func TestMetadataHelpersWithNilMaps(t *testing.T) {
+	if got := getAnnotation(bareService(), subsetAnnotation); got != "" {
+		t.Errorf("getAnnotation() = %q, want empty", got)
+	}
+	if hasAnnotation(bareService(), subsetAnnotation) {
+		t.Error("hasAnnotation() = true without annotations")
+	}
+	if hasLabel(bareService(), placeholderLabel, "true") {
+		t.Error("hasLabel() = true without labels")
+	}
+
+	service := bareService()
+	removeAnnotation(service, subsetAnnotation)
+	if service.Annotations != nil {
+		t.Errorf("removeAnnotation() created annotations %v", service.Annotations)
+	}
+
+	setAnnotation(service, subsetAnnotation, "v2")
+	if getAnnotation(service, subsetAnnotation) != "v2" || !hasAnnotation(service, subsetAnnotation) {
+		t.Errorf("annotations after setAnnotation() = %v", service.Annotations)
+	}
+	removeAnnotation(service, subsetAnnotation)
+	if hasAnnotation(service, subsetAnnotation) {
+		t.Errorf("annotations after removeAnnotation() = %v", service.Annotations)
+	}
+
+	setLabel(service, placeholderLabel, "true")
+	if !hasLabel(service, placeholderLabel, "true") || hasLabel(service, placeholderLabel, "false") {
+		t.Errorf("labels after setLabel() = %v", service.Labels)
+	}
+}
+
+// TestServiceHelpersWithNilMaps passes a service without annotations and labels through the readers of
+// operator annotations and labels, none of which may panic
+func TestServiceHelpersWithNilMaps(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), nil)
+	r := env.reconciler
+	operatorConfig := testConfig(t, handlerTestConfig)
+	ctx := context.Background()
+
+	if r.isPlaceholderService(ctx, bareService(), operatorConfig) {
+		t.Error("isPlaceholderService() = true")
+	}
+	if step, err := nextRolloutStep(bareService(), time.Now()); step != nil || err != nil {
+		t.Errorf("nextRolloutStep() = %v, %v, want no rollout", step, err)
+	}
+	if weight := currentRolloutWeight(bareService()); weight != nil {
+		t.Errorf("currentRolloutWeight() = %d, want nil", *weight)
+	}
+	if subset := r.developerSubset(ctx, bareService(), operatorConfig); subset != "" {
+		t.Errorf("developerSubset() = %q, want empty", subset)
+	}
+	if devRoutesDisabled(bareService()) {
+		t.Error("devRoutesDisabled() = true")
+	}
+	r.developerRouteOptions(ctx, bareService(), operatorConfig)
+	r.defaultRouteOptions(ctx, bareService(), operatorConfig)
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// routesWithheldTotal counts developer routes withheld or removed because the developer service has no ready endpoints
+	routesWithheldTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "virtualservice_operator_routes_withheld_total",
+			Help: "Number of times a developer route was withheld because the developer service had no ready endpoints",
+		},
+		[]string{"namespace"},
+	)
+
+	// throttledReconcilesTotal counts reconciles postponed because a service exceeded the minimum reconcile interval
+	throttledReconcilesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "virtualservice_operator_throttled_reconciles_total",
+			Help: "Number of reconciles postponed because the service was reconciled more often than the minimum interval allows",
+		},
+		[]string{"namespace"},
+	)
+
+	// pausedGauge is 1 while the paused config flag stops the operator from making changes
+	pausedGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "virtualservice_operator_paused",
+			Help: "Whether the operator is paused by its configuration (1) or making changes (0)",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(routesWithheldTotal, throttledReconcilesTotal, pausedGauge)
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// legacyPlaceholderLabel identified placeholders created by old operator versions
+	legacyPlaceholderLabel = "placeholder-service"
+	// placeholderAnnotation is the primary placeholder marker
+	placeholderAnnotation = "virtualservice-operator/placeholder-service"
+)
+
+// MigratePlaceholders adds the placeholder annotation and the canonical placeholder label to the services in
+// the watched developer namespaces that are only identified as placeholders by the legacy label, so their
+// detection no longer depends on it. Services already carrying both markers are left untouched, which makes
+// the migration safe to run repeatedly. With dryRun set nothing is changed. It returns a description of every
+// service that was (or would be) migrated.
+func (r *ServiceReconciler) MigratePlaceholders(ctx context.Context, dryRun bool) ([]string, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	config, err := r.ConfigManager.GetConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operator config: %w", err)
+	}
+
+	watchedNamespaces, err := r.ConfigManager.GetWatchedNamespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watched namespaces: %w", err)
+	}
+
+	var migrated []string
+	for _, ns := range watchedNamespaces {
+		// Placeholders only ever live in developer namespaces
+		if ns == config.DefaultNamespace {
+			continue
+		}
+
+		serviceList := &corev1.ServiceList{}
+		if err := r.List(ctx, serviceList, client.InNamespace(ns), client.MatchingLabels{legacyPlaceholderLabel: "true"}); err != nil {
+			return migrated, fmt.Errorf("failed to list legacy placeholder services in namespace %s: %w", ns, err)
+		}
+
+		for i := range serviceList.Items {
+			service := &serviceList.Items[i]
+			if getAnnotation(service, placeholderAnnotation) == "true" && hasLabel(service, placeholderLabel, "true") {
+				continue
+			}
+
+			if !dryRun {
+				original := service.DeepCopy()
+				setAnnotation(service, placeholderAnnotation, "true")
+				if service.Labels == nil {
+					service.Labels = map[string]string{}
+				}
+				service.Labels[placeholderLabel] = "true"
+				if err := r.Patch(ctx, service, client.MergeFrom(original)); err != nil {
+					return migrated, fmt.Errorf("failed to migrate placeholder service %s/%s: %w", service.Namespace, service.Name, err)
+				}
+			}
+			migrated = append(migrated, fmt.Sprintf("Service %s/%s", service.Namespace, service.Name))
+		}
+	}
+
+	log.Info("Migrated legacy placeholder services", "count", len(migrated), "dryRun", dryRun)
+	return migrated, nil
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// legacyPlaceholder builds a placeholder service identified by the legacy label only
+func legacyPlaceholder(namespace, name string) *corev1.Service {
+	service := newService(namespace, name, nil)
+	service.Labels = map[string]string{legacyPlaceholderLabel: "true"}
+	service.Spec = corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: name + ".default.svc.cluster.local"}
+	return service
+}
+
+// migrateTestEnv has legacy placeholders in alice and bob, a half-migrated one in alice, a migrated one in bob,
+// and legacy-labeled services outside the developer namespaces
+func migrateTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	halfMigrated := legacyPlaceholder("alice", "api")
+	halfMigrated.Annotations = map[string]string{placeholderAnnotation: "true"}
+	migrated := legacyPlaceholder("bob", "api")
+	migrated.Annotations = map[string]string{placeholderAnnotation: "true"}
+	migrated.Labels[placeholderLabel] = "true"
+
+	return newTestEnv(t, testConfig(t, "defaultNamespace: default\ndeveloperNamespaces: [alice, bob]\n"), []client.Object{
+		legacyPlaceholder("alice", "app"),
+		legacyPlaceholder("bob", "app"),
+		halfMigrated,
+		migrated,
+		newService("alice", "real", nil),
+		legacyPlaceholder("default", "app"),
+		legacyPlaceholder("carol", "app"),
+	})
+}
+
+var wantMigrated = []string{"Service alice/api", "Service alice/app", "Service bob/app"}
+
+func TestMigratePlaceholdersDryRun(t *testing.T) {
+	env := migrateTestEnv(t)
+
+	migrated, err := env.reconciler.MigratePlaceholders(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(migrated, wantMigrated) {
+		t.Errorf("MigratePlaceholders() = %v, want %v", migrated, wantMigrated)
+	}
+	if writes := env.takeWrites(); len(writes) != 0 {
+		t.Errorf("dry run made %d writes", len(writes))
+	}
+}
+
+func TestMigratePlaceholders(t *testing.T) {
+	env := migrateTestEnv(t)
+
+	migrated, err := env.reconciler.MigratePlaceholders(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(migrated, wantMigrated) {
+		t.Errorf("MigratePlaceholders() = %v, want %v", migrated, wantMigrated)
+	}
+	if writes := env.takeWrites(); len(writes) != len(wantMigrated) {
+		t.Errorf("%d writes, want one per migrated service", len(writes))
+	}
+
+	for _, key := range []client.ObjectKey{{Namespace: "alice", Name: "app"}, {Namespace: "alice", Name: "api"}, {Namespace: "bob", Name: "app"}} {
+		service := env.service(key.Namespace, key.Name)
+		if getAnnotation(service, placeholderAnnotation) != "true" || service.Labels[placeholderLabel] != "true" {
+			t.Errorf("%s not migrated: annotations %v, labels %v", key, service.Annotations, service.Labels)
+		}
+	}
+	for _, key := range []client.ObjectKey{{Namespace: "alice", Name: "real"}, {Namespace: "default", Name: "app"}, {Namespace: "carol", Name: "app"}} {
+		if service := env.service(key.Namespace, key.Name); hasAnnotation(service, placeholderAnnotation) {
+			t.Errorf("%s outside the migration was migrated", key)
+		}
+	}
+
+	// Running it again finds nothing left to migrate
+	migrated, err = env.reconciler.MigratePlaceholders(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrated) != 0 {
+		t.Errorf("second migration = %v, want nothing", migrated)
+	}
+	if writes := env.takeWrites(); len(writes) != 0 {
+		t.Errorf("second migration made %d writes", len(writes))
+	}
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/config"
+	"virtualservice-operator/internal/utils"
+)
+
+// cleanupFormerDeveloperNamespace removes what the operator generated in a namespace that stopped being a
+// developer namespace, e.g. because it no longer matches the developer namespace selector or lost the watch
+// annotation: its placeholder services, its egress Sidecar and the DestinationRules of its services.
+// Reconciles of the default namespace services only visit the current developer namespaces, and the
+// namespace predicate drops the events of the namespace from now on, so nothing else would remove them.
+func (r *ServiceReconciler) cleanupFormerDeveloperNamespace(ctx context.Context, namespace string, config *config.OperatorConfig) error {
+	var errs []error
+	if config.EnablePlaceholderServices {
+		errs = append(errs, r.deleteNamespacePlaceholders(ctx, namespace, config))
+	}
+	errs = append(errs, r.deleteNamespaceSidecar(ctx, namespace))
+	if config.GenerateDestinationRules {
+		errs = append(errs, r.deleteNamespaceDestinationRules(ctx, namespace))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// deleteNamespacePlaceholders deletes every placeholder service in a namespace
+func (r *ServiceReconciler) deleteNamespacePlaceholders(ctx context.Context, namespace string, config *config.OperatorConfig) error {
+	log := ctrl.LoggerFrom(ctx)
+	serviceList := &corev1.ServiceList{}
+	if err := r.List(ctx, serviceList, client.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("failed to list services in namespace %s: %w", namespace, err)
+	}
+
+	var errs []error
+	for i := range serviceList.Items {
+		service := &serviceList.Items[i]
+		if !r.isPlaceholderService(ctx, service, config) {
+			continue
+		}
+		if err := r.Delete(ctx, service); err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete placeholder service %s in namespace %s: %w", service.Name, namespace, err))
+			continue
+		}
+		log.Info("Deleted placeholder service of former developer namespace", "service", service.Name, "namespace", namespace)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// deleteNamespaceSidecar deletes the egress Sidecar of a namespace if the operator manages it
+func (r *ServiceReconciler) deleteNamespaceSidecar(ctx context.Context, namespace string) error {
+	sidecar := &istionetworkingv1beta1.Sidecar{}
+	if err := r.Get(ctx, types.NamespacedName{Name: utils.SidecarName, Namespace: namespace}, sidecar); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get Sidecar in namespace %s: %w", namespace, err)
+	}
+	if !utils.IsManagedByOperator(sidecar) {
+		return nil
+	}
+	if err := r.Delete(ctx, sidecar); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete Sidecar %s/%s: %w", namespace, sidecar.Name, err)
+	}
+	ctrl.LoggerFrom(ctx).Info("Deleted Sidecar of former developer namespace", "sidecar", sidecar.Name, "namespace", namespace)
+	return nil
+}
+
+// deleteNamespaceDestinationRules deletes the DestinationRules the operator manages in a namespace
+func (r *ServiceReconciler) deleteNamespaceDestinationRules(ctx context.Context, namespace string) error {
+	log := ctrl.LoggerFrom(ctx)
+	drList := &istionetworkingv1beta1.DestinationRuleList{}
+	if err := r.List(ctx, drList, client.InNamespace(namespace), client.MatchingLabels{utils.ManagedByLabel: utils.OperatorName}); err != nil {
+		return fmt.Errorf("failed to list DestinationRules in namespace %s: %w", namespace, err)
+	}
+
+	var errs []error
+	for _, dr := range drList.Items {
+		if err := r.Delete(ctx, dr); err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete DestinationRule %s/%s: %w", namespace, dr.Name, err))
+			continue
+		}
+		log.Info("Deleted DestinationRule of former developer namespace", "destinationRule", dr.Name, "namespace", namespace)
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/config"
+)
+
+const namespaceSelectorTestConfig = `defaultNamespace: default
+enablePlaceholderServices: true
+developerNamespaceSelector:
+  matchLabels:
+    env: dev
+`
+
+func TestNamespaceLeavingSelectorLosesPlaceholders(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testConfigMapKey.Namespace, Name: testConfigMapKey.Name},
+		Data:       map[string]string{"config.yaml": namespaceSelectorTestConfig},
+	}
+	bob := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "bob", Labels: map[string]string{"env": "dev"}}}
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		configMap,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "alice", Labels: map[string]string{"env": "dev"}}},
+		bob,
+		newService("default", "app", nil),
+		newService("default", "other", nil),
+		newService("bob", "own", nil),
+	})
+	env.reconciler.ConfigManager = config.NewConfigManager(env.client, testConfigMapKey.Namespace, testConfigMapKey.Name)
+	ctx := context.Background()
+
+	env.reconcile("default", "app")
+	env.reconcile("default", "other")
+	for _, name := range []string{"app", "other"} {
+		if env.service("bob", name) == nil {
+			t.Fatalf("no placeholder for %s in the selected namespace bob", name)
+		}
+	}
+
+	// bob no longer matches the selector, its placeholders go while alice's and bob's own service stay
+	if err := env.client.Get(ctx, client.ObjectKeyFromObject(bob), bob); err != nil {
+		t.Fatal(err)
+	}
+	bob.Labels = map[string]string{"env": "prod"}
+	if err := env.client.Update(ctx, bob); err != nil {
+		t.Fatal(err)
+	}
+	env.reconciler.namespaceToRequests(ctx, bob)
+
+	for _, name := range []string{"app", "other"} {
+		if env.service("bob", name) != nil {
+			t.Errorf("placeholder for %s left in bob after it stopped matching the selector", name)
+		}
+		if env.service("alice", name) == nil {
+			t.Errorf("placeholder for %s removed from alice, which still matches", name)
+		}
+	}
+	if env.service("bob", "own") == nil {
+		t.Error("bob's own service was deleted")
+	}
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestPausedReconcileWritesNothing(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"paused: true\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+	})
+
+	for _, req := range [][2]string{{"default", "app"}, {"alice", "app"}, {"bob", "app"}} {
+		if result := env.reconcile(req[0], req[1]); !result.IsZero() {
+			t.Errorf("paused reconcile of %s/%s requeued: %+v", req[0], req[1], result)
+		}
+	}
+	if writes := env.takeWrites(); len(writes) != 0 {
+		t.Fatalf("paused operator made %d writes, want none", len(writes))
+	}
+	if env.virtualService("default", "app-virtual-service") != nil {
+		t.Fatal("VirtualService created while paused")
+	}
+	if paused := testutil.ToFloat64(pausedGauge); paused != 1 {
+		t.Errorf("paused gauge = %v while paused, want 1", paused)
+	}
+
+	// Unpausing reconciles the watched services again
+	env.config.set(testConfig(t, handlerTestConfig))
+	env.reconcile("default", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if vs == nil {
+		t.Fatal("VirtualService not created after unpausing")
+	}
+	if got := routedDeveloperNamespaces(vs); !reflect.DeepEqual(got, []string{"alice"}) {
+		t.Errorf("developer routes = %v, want [alice]", got)
+	}
+	if env.service("bob", "app") == nil {
+		t.Error("placeholder not created after unpausing")
+	}
+	if paused := testutil.ToFloat64(pausedGauge); paused != 0 {
+		t.Errorf("paused gauge = %v after unpausing, want 0", paused)
+	}
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// placeholderBackfill staggers namespace-wide placeholder backfills over a window, so that many
+// developer namespaces reconciled at once (e.g. after a config change) don't all list the default
+// namespace and create placeholders at the same moment. The zero value is ready to use.
+type placeholderBackfill struct {
+	mu sync.Mutex
+	// scheduled maps a namespace to the time its backfill is due
+	scheduled map[string]time.Time
+}
+
+// next returns how long to wait before backfilling a namespace, zero meaning it should run now.
+// The first request for a namespace schedules the backfill at a random point within the window,
+// later requests for the same namespace share that slot.
+func (b *placeholderBackfill) next(namespace string, window time.Duration, now time.Time) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.scheduled == nil {
+		b.scheduled = map[string]time.Time{}
+	}
+
+	due, exists := b.scheduled[namespace]
+	if !exists {
+		due = now.Add(time.Duration(rand.Int63n(int64(window))) + time.Millisecond)
+		b.scheduled[namespace] = due
+	}
+
+	if !now.Before(due) {
+		delete(b.scheduled, namespace)
+		return 0
+	}
+	return due.Sub(now)
+}
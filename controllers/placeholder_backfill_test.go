@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestPlaceholderBackfillJittersWithinWindow(t *testing.T) {
+	const window = 10 * time.Second
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var b placeholderBackfill
+
+	delays := map[time.Duration]bool{}
+	for i := 0; i < 50; i++ {
+		delay := b.next(fmt.Sprintf("dev-%d", i), window, now)
+		if delay <= 0 || delay > window+time.Millisecond {
+			t.Fatalf("delay %v outside the window of %v", delay, window)
+		}
+		delays[delay] = true
+	}
+	if len(delays) < 2 {
+		t.Errorf("50 namespaces share %d delay, want jittered delays", len(delays))
+	}
+}
+
+func TestPlaceholderBackfillSharesSlot(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var b placeholderBackfill
+
+	first := b.next("alice", time.Minute, now)
+	if again := b.next("alice", time.Minute, now.Add(time.Millisecond/2)); again != first-time.Millisecond/2 {
+		t.Errorf("second request waits %v, want the remaining %v of the first slot", again, first-time.Millisecond/2)
+	}
+
+	// Once due the backfill runs and the slot is released
+	if delay := b.next("alice", time.Minute, now.Add(first)); delay != 0 {
+		t.Errorf("delay at the due time = %v, want 0", delay)
+	}
+	if delay := b.next("alice", time.Minute, now.Add(first)); delay <= 0 {
+		t.Errorf("delay after the backfill ran = %v, want a new slot", delay)
+	}
+}
+
+func TestPlaceholderBackfillWithoutWindow(t *testing.T) {
+	var b placeholderBackfill
+	if delay := b.next("alice", 0, time.Now()); delay != 0 {
+		t.Errorf("delay without a window = %v, want 0", delay)
+	}
+}
+
+func TestDeveloperReconcileStaggersPlaceholderBackfill(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"placeholderBackfillWindow: 30s\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "other", nil),
+	})
+
+	result := env.reconcile("alice", "other")
+	if result.RequeueAfter <= 0 || result.RequeueAfter > 30*time.Second+time.Millisecond {
+		t.Fatalf("requeued after %v, want a delay within the 30s window", result.RequeueAfter)
+	}
+	if env.service("alice", "app") != nil {
+		t.Fatal("placeholder backfilled before its slot")
+	}
+
+	env.clock.advance(result.RequeueAfter)
+	env.reconcile("alice", "other")
+	if env.service("alice", "app") == nil {
+		t.Error("placeholder not backfilled once its slot was due")
+	}
+}
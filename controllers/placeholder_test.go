@@ -0,0 +1,322 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestHeadlessPlaceholder(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"placeholderServiceType: Headless\n"), []client.Object{
+		newService("default", "app", nil),
+	})
+
+	env.reconcile("default", "app")
+
+	placeholder := env.service("alice", "app")
+	if placeholder == nil {
+		t.Fatal("no placeholder in namespace alice")
+	}
+	if placeholder.Spec.ClusterIP != corev1.ClusterIPNone || len(placeholder.Spec.Selector) != 0 {
+		t.Errorf("placeholder is not a selectorless headless service: clusterIP %q, selector %v", placeholder.Spec.ClusterIP, placeholder.Spec.Selector)
+	}
+	if len(placeholder.Spec.Ports) != 1 || placeholder.Spec.Ports[0].Port != 80 {
+		t.Errorf("placeholder ports = %v, want the ports of the source service", placeholder.Spec.Ports)
+	}
+
+	endpoints := &corev1.Endpoints{}
+	if err := env.client.Get(context.Background(), types.NamespacedName{Namespace: "alice", Name: "app"}, endpoints); err != nil {
+		t.Fatalf("no Endpoints for the headless placeholder: %v", err)
+	}
+	if len(endpoints.Subsets) != 1 || len(endpoints.Subsets[0].Addresses) != 1 || endpoints.Subsets[0].Addresses[0].IP != "10.0.0.1" {
+		t.Errorf("Endpoints subsets = %v, want the cluster IP of the source service", endpoints.Subsets)
+	}
+	if owners := endpoints.GetOwnerReferences(); len(owners) != 1 || owners[0].Name != "app" || owners[0].Kind != "Service" {
+		t.Errorf("Endpoints owner references = %v, want the placeholder", owners)
+	}
+
+	// The headless placeholder is detected and gets no route
+	env.reconcile("alice", "app")
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); len(got) != 0 {
+		t.Errorf("headless placeholder got a route: %v", got)
+	}
+}
+
+func TestIsPlaceholderService(t *testing.T) {
+	externalName := func(target string) *corev1.Service {
+		service := newService("alice", "app", nil)
+		service.Spec = corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: target}
+		return service
+	}
+	headless := func(annotations map[string]string) *corev1.Service {
+		service := newService("alice", "app", annotations)
+		service.Spec.ClusterIP = corev1.ClusterIPNone
+		service.Spec.Selector = nil
+		return service
+	}
+	labeled := func(key string) *corev1.Service {
+		service := newService("alice", "app", nil)
+		service.Labels = map[string]string{key: "true"}
+		return service
+	}
+
+	tests := []struct {
+		name    string
+		service *corev1.Service
+		want    bool
+	}{
+		{name: "annotation", service: newService("alice", "app", map[string]string{placeholderAnnotation: "true"}), want: true},
+		{name: "label", service: labeled(placeholderLabel), want: true},
+		{name: "legacy label", service: labeled(legacyPlaceholderLabel), want: true},
+		{name: "ExternalName to the default namespace", service: externalName("app.default.svc.cluster.local"), want: true},
+		{name: "ExternalName to another namespace", service: externalName("app.bob.svc.cluster.local")},
+		{name: "ExternalName outside the cluster", service: externalName("app.example.com")},
+		{name: "headless with source service", service: headless(map[string]string{"virtualservice-operator/source-service": "app.default.svc.cluster.local"}), want: true},
+		{name: "headless without source service", service: headless(nil)},
+		{name: "real service", service: newService("alice", "app", nil)},
+	}
+
+	r := &ServiceReconciler{}
+	operatorConfig := testConfig(t, handlerTestConfig)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.isPlaceholderService(context.Background(), tt.service, operatorConfig); got != tt.want {
+				t.Errorf("isPlaceholderService() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlaceholderExternalNameMatchesVirtualServiceHost(t *testing.T) {
+	for _, domain := range []string{"cluster.local", "corp.example"} {
+		t.Run(domain, func(t *testing.T) {
+			env := newTestEnv(t, testConfig(t, handlerTestConfig+"clusterDomain: "+domain+"\n"), []client.Object{
+				newService("default", "app", nil),
+				newService("alice", "app", nil),
+			})
+
+			env.reconcile("default", "app")
+			env.reconcile("alice", "app")
+
+			placeholder := env.service("bob", "app")
+			if placeholder == nil {
+				t.Fatal("no placeholder in namespace bob")
+			}
+			vs := env.virtualService("default", "app-virtual-service")
+			if vs == nil || len(vs.Spec.Http) != 2 {
+				t.Fatalf("VirtualService = %v, want an alice and a default route", vs)
+			}
+
+			defaultHost := vs.Spec.Http[len(vs.Spec.Http)-1].Route[0].Destination.Host
+			if placeholder.Spec.ExternalName != defaultHost {
+				t.Errorf("placeholder ExternalName %q differs from the default route host %q", placeholder.Spec.ExternalName, defaultHost)
+			}
+			if source := placeholder.Annotations["virtualservice-operator/source-service"]; source != defaultHost {
+				t.Errorf("placeholder source-service %q differs from the default route host %q", source, defaultHost)
+			}
+			if want := "app.alice.svc." + domain; vs.Spec.Http[0].Route[0].Destination.Host != want {
+				t.Errorf("developer route host = %q, want %q", vs.Spec.Http[0].Route[0].Destination.Host, want)
+			}
+
+			// The placeholder is still recognized in the configured domain and gets no route
+			env.reconcile("bob", "app")
+			if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); len(got) != 1 || got[0] != "alice" {
+				t.Errorf("routed namespaces = %v, want [alice]", got)
+			}
+		})
+	}
+}
+
+func TestPlaceholderLabels(t *testing.T) {
+	for _, placeholderType := range []string{"ExternalName", "Headless"} {
+		t.Run(placeholderType, func(t *testing.T) {
+			env := newTestEnv(t, testConfig(t, handlerTestConfig+`placeholderServiceType: `+placeholderType+`
+placeholderLabels:
+  team: platform
+  virtualservice-operator/placeholder: "false"
+`), []client.Object{
+				newService("default", "app", nil),
+			})
+
+			env.reconcile("default", "app")
+
+			placeholder := env.service("alice", "app")
+			if placeholder == nil {
+				t.Fatal("no placeholder in namespace alice")
+			}
+			if got := placeholder.Labels["team"]; got != "platform" {
+				t.Errorf("team label = %q, want the configured label", got)
+			}
+			// A configured label can't override the canonical one placeholders are detected by
+			if got := placeholder.Labels[placeholderLabel]; got != "true" {
+				t.Errorf("%s label = %q, want true", placeholderLabel, got)
+			}
+
+			env.reconcile("alice", "app")
+			if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); len(got) != 0 {
+				t.Errorf("labeled placeholder got a route: %v", got)
+			}
+		})
+	}
+}
+
+func TestExternalNameNamespace(t *testing.T) {
+	tests := []struct {
+		externalName  string
+		clusterDomain string
+		wantNamespace string
+		wantOK        bool
+	}{
+		{externalName: "app.default.svc.cluster.local", clusterDomain: "cluster.local", wantNamespace: "default", wantOK: true},
+		{externalName: "app.default.svc.cluster.local.", clusterDomain: "cluster.local", wantNamespace: "default", wantOK: true},
+		{externalName: "APP.Default.SVC.Cluster.Local", clusterDomain: "cluster.local", wantNamespace: "default", wantOK: true},
+		{externalName: "app.default.svc", clusterDomain: "cluster.local", wantNamespace: "default", wantOK: true},
+		{externalName: "app.default", clusterDomain: "cluster.local", wantNamespace: "default", wantOK: true},
+		{externalName: "app.default.svc.corp.example", clusterDomain: "corp.example", wantNamespace: "default", wantOK: true},
+		{externalName: "app.default.svc.corp.example", clusterDomain: "corp.example.", wantNamespace: "default", wantOK: true},
+		// Another cluster domain doesn't resolve in this cluster
+		{externalName: "app.default.svc.cluster.local", clusterDomain: "corp.example"},
+		{externalName: "app", clusterDomain: "cluster.local"},
+		{externalName: "app.example.com", clusterDomain: "cluster.local"},
+		{externalName: ".default.svc.cluster.local", clusterDomain: "cluster.local"},
+		{externalName: "app..svc.cluster.local", clusterDomain: "cluster.local"},
+		{externalName: "", clusterDomain: "cluster.local"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.externalName, func(t *testing.T) {
+			namespace, ok := externalNameNamespace(tt.externalName, tt.clusterDomain)
+			if namespace != tt.wantNamespace || ok != tt.wantOK {
+				t.Errorf("externalNameNamespace(%q, %q) = %q, %v, want %q, %v", tt.externalName, tt.clusterDomain, namespace, ok, tt.wantNamespace, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestIsPlaceholderServiceExternalNameForms checks that an ExternalName to the default namespace in any
+// resolvable form is taken for a placeholder and gets no route
+func TestIsPlaceholderServiceExternalNameForms(t *testing.T) {
+	for _, target := range []string{"app.default.svc.cluster.local.", "App.Default.Svc.Cluster.Local", "app.default.svc", "app.default"} {
+		t.Run(target, func(t *testing.T) {
+			service := newService("alice", "app", nil)
+			service.Spec = corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: target}
+			env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{newService("default", "app", nil), service})
+
+			env.reconcile("default", "app")
+			env.reconcile("alice", "app")
+
+			if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); len(got) != 0 {
+				t.Errorf("ExternalName %q got a route: %v", target, got)
+			}
+		})
+	}
+}
+
+// serviceWrites returns the writes to services in a namespace
+func serviceWrites(writes []write, namespace string) []write {
+	var services []write
+	for _, w := range writes {
+		if _, ok := w.object.(*corev1.Service); ok && w.object.GetNamespace() == namespace {
+			services = append(services, w)
+		}
+	}
+	return services
+}
+
+func TestPlaceholderCreationNeverConvertsRealService(t *testing.T) {
+	externalName := newService("alice", "app", nil)
+	externalName.Spec = corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "app.example.com"}
+	headless := newService("alice", "app", nil)
+	headless.Spec.ClusterIP = corev1.ClusterIPNone
+
+	for name, real := range map[string]*corev1.Service{
+		"ClusterIP":              newService("alice", "app", nil),
+		"ExternalName elsewhere": externalName,
+		"headless with selector": headless,
+	} {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t, testConfig(t, handlerTestConfig+"placeholderServiceType: Headless\n"), []client.Object{
+				newService("default", "app", nil),
+				newService("alice", "other", nil),
+				real.DeepCopy(),
+			})
+			before := env.service("alice", "app")
+
+			// Every path that creates placeholders, run twice
+			for i := 0; i < 2; i++ {
+				env.reconcile("default", "app")
+				env.reconcile("alice", "other")
+				env.reconcile("alice", "app")
+			}
+
+			if writes := serviceWrites(env.takeWrites(), "alice"); len(writes) != 0 {
+				t.Errorf("wrote %d services in the namespace of the real service, first %s %s", len(writes), writes[0].verb, writes[0].object.GetName())
+			}
+			after := env.service("alice", "app")
+			if after.ResourceVersion != before.ResourceVersion || after.Spec.Type != real.Spec.Type {
+				t.Errorf("real service changed: %v", after)
+			}
+			if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); len(got) != 1 || got[0] != "alice" {
+				t.Errorf("routed namespaces = %v, want the real alice service", got)
+			}
+		})
+	}
+}
+
+func TestPlaceholderCreationIsIdempotent(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "other", nil),
+	})
+	env.reconcile("default", "app")
+	env.takeWrites()
+
+	env.reconcile("default", "app")
+	env.reconcile("alice", "other")
+	if writes := serviceWrites(env.takeWrites(), "alice"); len(writes) != 0 {
+		t.Errorf("re-running placeholder creation wrote %d services", len(writes))
+	}
+}
+
+func TestPlaceholderCreationFailureInOneNamespace(t *testing.T) {
+	var denyAlice atomic.Bool
+	denyAlice.Store(true)
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{newService("default", "app", nil)},
+		withInterceptor(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if _, ok := obj.(*corev1.Service); ok && obj.GetNamespace() == "alice" && denyAlice.Load() {
+					return apierrors.NewForbidden(schema.GroupResource{Resource: "services"}, obj.GetName(), errors.New("permission denied"))
+				}
+				return nil
+			},
+		}))
+
+	err := env.reconcileErr("default", "app")
+	if err == nil || !strings.Contains(err.Error(), "namespace alice") {
+		t.Fatalf("reconcile error = %v, want the alice placeholder failure", err)
+	}
+	if env.service("bob", "app") == nil {
+		t.Error("bob's placeholder wasn't created")
+	}
+	if env.virtualService("default", "app-virtual-service") == nil {
+		t.Error("VirtualService wasn't created")
+	}
+
+	// The retry only creates the missing placeholder
+	denyAlice.Store(false)
+	env.takeWrites()
+	env.reconcile("default", "app")
+	writes := env.takeWrites()
+	if len(writes) != 1 || writes[0].verb != "create" || writes[0].object.GetNamespace() != "alice" {
+		t.Errorf("retry wrote %v, want only alice's placeholder", writes)
+	}
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"virtualservice-operator/api/v1alpha1"
+	"virtualservice-operator/internal/config"
+)
+
+// policyTargetIndex indexes the cached VirtualServicePolicies by the service they target
+const policyTargetIndex = "spec.targetService"
+
+// indexPolicyTarget is the index function of policyTargetIndex
+func indexPolicyTarget(obj client.Object) []string {
+	policy, ok := obj.(*v1alpha1.VirtualServicePolicy)
+	if !ok || policy.Spec.TargetService == "" {
+		return nil
+	}
+	return []string{policy.Spec.TargetService}
+}
+
+// servicePolicy returns the VirtualServicePolicy in the default namespace targeting a service, nil if there
+// is none or policies are disabled. When several policies target the service the oldest one applies.
+func (r *ServiceReconciler) servicePolicy(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) (*v1alpha1.VirtualServicePolicy, error) {
+	if !r.EnablePolicies || service.Namespace != config.DefaultNamespace {
+		return nil, nil
+	}
+
+	// The subcommands read with a direct client, which can't select policies by target, so the listed
+	// policies are filtered by target either way
+	listOpts := []client.ListOption{client.InNamespace(config.DefaultNamespace)}
+	if r.policiesIndexed {
+		listOpts = append(listOpts, client.MatchingFields{policyTargetIndex: service.Name})
+	}
+	policyList := &v1alpha1.VirtualServicePolicyList{}
+	if err := r.List(ctx, policyList, listOpts...); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil // The CRD isn't installed, so there can't be any policy
+		}
+		return nil, fmt.Errorf("failed to list VirtualServicePolicies: %w", err)
+	}
+
+	var policies []*v1alpha1.VirtualServicePolicy
+	for i := range policyList.Items {
+		policy := &policyList.Items[i]
+		if policy.Spec.TargetService == service.Name && policy.DeletionTimestamp.IsZero() {
+			policies = append(policies, policy)
+		}
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		if !policies[i].CreationTimestamp.Equal(&policies[j].CreationTimestamp) {
+			return policies[i].CreationTimestamp.Before(&policies[j].CreationTimestamp)
+		}
+		return policies[i].Name < policies[j].Name
+	})
+	if len(policies) > 1 {
+		ctrl.LoggerFrom(ctx).Info("Several VirtualServicePolicies target the service, using the oldest",
+			"service", service.Name, "policy", policies[0].Name, "policies", len(policies))
+	}
+	return policies[0], nil
+}
+
+// applyServicePolicy returns the service as configured by its VirtualServicePolicy: a copy carrying the policy's
+// settings as annotations, which replace the service's own, or the service itself if no policy targets it.
+// Since annotations already take precedence over the global config, so does the policy. The copy must not
+// be written back wholesale, only patched relative to itself.
+func (r *ServiceReconciler) applyServicePolicy(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) (*corev1.Service, error) {
+	policy, err := r.servicePolicy(ctx, service, config)
+	if err != nil || policy == nil {
+		return service, err
+	}
+
+	merged := service.DeepCopy()
+	spec := policy.Spec
+	if spec.Timeout != nil {
+		setAnnotation(merged, timeoutAnnotation, spec.Timeout.Duration.String())
+	}
+	if len(spec.Gateways) > 0 {
+		setAnnotation(merged, gatewaysAnnotation, strings.Join(spec.Gateways, ","))
+	}
+	if spec.DefaultWeight != nil {
+		setAnnotation(merged, defaultWeightAnnotation, strconv.Itoa(int(*spec.DefaultWeight)))
+	}
+	if spec.DisableDeveloperRoutes != nil {
+		setAnnotation(merged, disableDevRoutesAnnotation, strconv.FormatBool(*spec.DisableDeveloperRoutes))
+	}
+	return merged, nil
+}
+
+// policyToRequests reconciles the service a VirtualServicePolicy targets. On an update both the old and the
+// new version are mapped, so a service the policy no longer targets loses its settings.
+func (r *ServiceReconciler) policyToRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+	for _, target := range indexPolicyTarget(obj) {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: target, Namespace: obj.GetNamespace()},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"virtualservice-operator/api/v1alpha1"
+)
+
+// withPolicyIndex indexes the policies of the fake client like SetupWithManager indexes the cache
+func withPolicyIndex() testEnvOption {
+	return withBuilder(func(b *fake.ClientBuilder) {
+		b.WithIndex(&v1alpha1.VirtualServicePolicy{}, policyTargetIndex, indexPolicyTarget)
+	})
+}
+
+// newPolicy builds a policy in the default namespace created at the given minute
+func newPolicy(name, target string, minute int, spec v1alpha1.VirtualServicePolicySpec) *v1alpha1.VirtualServicePolicy {
+	spec.TargetService = target
+	return &v1alpha1.VirtualServicePolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(time.Date(2024, 1, 1, 12, minute, 0, 0, time.UTC)),
+		},
+		Spec: spec,
+	}
+}
+
+func policyTestEnv(t *testing.T, configYAML string, objects ...client.Object) *testEnv {
+	t.Helper()
+	env := newTestEnv(t, testConfig(t, configYAML), objects, withPolicyIndex())
+	env.reconciler.EnablePolicies = true
+	env.reconciler.policiesIndexed = true
+	return env
+}
+
+func TestServicePolicy(t *testing.T) {
+	empty := v1alpha1.VirtualServicePolicySpec{}
+	env := policyTestEnv(t, handlerTestConfig,
+		newPolicy("newer", "app", 30, empty),
+		newPolicy("older", "app", 10, empty),
+		newPolicy("other", "web", 0, empty),
+	)
+	operatorConfig := testConfig(t, handlerTestConfig)
+
+	tests := []struct {
+		name     string
+		service  string
+		disabled bool
+		indexed  bool
+		want     string
+	}{
+		{name: "oldest of several", service: "default/app", indexed: true, want: "older"},
+		{name: "single", service: "default/web", indexed: true, want: "other"},
+		{name: "none", service: "default/api", indexed: true},
+		{name: "developer namespace", service: "alice/app", indexed: true},
+		{name: "disabled", service: "default/app", indexed: true, disabled: true},
+		{name: "without index", service: "default/app", want: "older"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env.reconciler.EnablePolicies = !tt.disabled
+			env.reconciler.policiesIndexed = tt.indexed
+			namespace, name, _ := strings.Cut(tt.service, "/")
+
+			policy, err := env.reconciler.servicePolicy(context.Background(), newService(namespace, name, nil), operatorConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if policy != nil {
+				got = policy.Name
+			}
+			if got != tt.want {
+				t.Errorf("servicePolicy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServicePolicyPrecedence(t *testing.T) {
+	const configYAML = handlerTestConfig + `
+routeTimeout: 3s
+gateways: [mesh]
+`
+	annotated := func(name string) *corev1.Service {
+		return newService("default", name, map[string]string{
+			timeoutAnnotation:  "5s",
+			gatewaysAnnotation: "istio-system/annotated",
+		})
+	}
+	env := policyTestEnv(t, configYAML,
+		annotated("app"),
+		annotated("web"),
+		newService("default", "api", nil),
+		newPolicy("app", "app", 0, v1alpha1.VirtualServicePolicySpec{
+			Timeout:  &metav1.Duration{Duration: 10 * time.Second},
+			Gateways: []string{"istio-system/policy"},
+		}),
+	)
+
+	tests := []struct {
+		service  string
+		timeout  time.Duration
+		gateways []string
+	}{
+		// The policy overrides the annotations, which override the global config
+		{service: "app", timeout: 10 * time.Second, gateways: []string{"istio-system/policy"}},
+		{service: "web", timeout: 5 * time.Second, gateways: []string{"istio-system/annotated"}},
+		{service: "api", timeout: 3 * time.Second, gateways: []string{"mesh"}},
+	}
+	for _, tt := range tests {
+		env.reconcile("default", tt.service)
+		vs := env.virtualService("default", tt.service+"-virtual-service")
+		if vs == nil {
+			t.Fatalf("%s: no VirtualService", tt.service)
+		}
+		if got := defaultRouteTimeout(vs); got != tt.timeout {
+			t.Errorf("%s: route timeout = %v, want %v", tt.service, got, tt.timeout)
+		}
+		if got := vs.Spec.Gateways; !reflect.DeepEqual(got, tt.gateways) {
+			t.Errorf("%s: gateways = %v, want %v", tt.service, got, tt.gateways)
+		}
+	}
+
+	// The policy settings are not written back to the service
+	if got := getAnnotation(env.service("default", "app"), timeoutAnnotation); got != "5s" {
+		t.Errorf("timeout annotation of the service = %q, want it unchanged", got)
+	}
+}
+
+func TestPolicyToRequests(t *testing.T) {
+	r := &ServiceReconciler{}
+	got := r.policyToRequests(context.Background(), newPolicy("p", "app", 0, v1alpha1.VirtualServicePolicySpec{}))
+	want := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("policyToRequests() = %v, want %v", got, want)
+	}
+	if got := r.policyToRequests(context.Background(), newPolicy("p", "", 0, v1alpha1.VirtualServicePolicySpec{})); len(got) != 0 {
+		t.Errorf("policy without target mapped to %v", got)
+	}
+}
+
+// defaultRouteTimeout returns the timeout of the last, default route
+func defaultRouteTimeout(vs *istionetworkingv1beta1.VirtualService) time.Duration {
+	route := vs.Spec.Http[len(vs.Spec.Http)-1]
+	if route.Timeout == nil {
+		return 0
+	}
+	return route.Timeout.AsDuration()
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"virtualservice-operator/internal/config"
+)
+
+const predicateTestConfig = `
+defaultNamespace: default
+developerNamespaces: [alice, "dev-*"]
+serviceSelector:
+  matchLabels:
+    team: web
+`
+
+// predicateTestReconciler returns a reconciler using the real ConfigManager and a counter of the
+// API calls made through it
+func predicateTestReconciler(t testing.TB) (*ServiceReconciler, *atomic.Int64) {
+	t.Helper()
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testConfigMapKey.Namespace, Name: testConfigMapKey.Name},
+		Data:       map[string]string{"config.yaml": predicateTestConfig},
+	}
+	calls := &atomic.Int64{}
+	c := interceptor.NewClient(fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+		configMap,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev-carol"}},
+	).Build(), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			calls.Add(1)
+			return c.Get(ctx, key, obj, opts...)
+		},
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			calls.Add(1)
+			return c.List(ctx, list, opts...)
+		},
+	})
+	return &ServiceReconciler{
+		Client:        c,
+		Scheme:        testScheme,
+		ConfigManager: config.NewConfigManager(c, testConfigMapKey.Namespace, testConfigMapKey.Name),
+	}, calls
+}
+
+func TestServicePredicatesUseCachedNamespaces(t *testing.T) {
+	r, calls := predicateTestReconciler(t)
+	namespacePredicate, selectorPredicate := r.namespacePredicate(), r.selectorPredicate()
+
+	// The first event loads the config
+	namespacePredicate.Create(event.CreateEvent{Object: newService("default", "warmup", nil)})
+	calls.Store(0)
+
+	web := newService("default", "web", nil)
+	web.Labels = map[string]string{"team": "web"}
+	tests := []struct {
+		service *corev1.Service
+		want    bool
+	}{
+		{service: web, want: true},
+		{service: newService("default", "batch", nil), want: false},
+		{service: newService("alice", "app", nil), want: true},
+		{service: newService("dev-new", "app", nil), want: true},
+		{service: newService("kube-system", "app", nil), want: false},
+	}
+	for _, tt := range tests {
+		e := event.CreateEvent{Object: tt.service}
+		if got := namespacePredicate.Create(e) && selectorPredicate.Create(e); got != tt.want {
+			t.Errorf("service %s/%s passes = %v, want %v", tt.service.Namespace, tt.service.Name, got, tt.want)
+		}
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("predicates made %d API calls, want none", n)
+	}
+}
+
+func BenchmarkServicePredicates(b *testing.B) {
+	r, calls := predicateTestReconciler(b)
+	namespacePredicate, selectorPredicate := r.namespacePredicate(), r.selectorPredicate()
+	namespacePredicate.Create(event.CreateEvent{Object: newService("default", "warmup", nil)})
+	calls.Store(0)
+
+	events := []event.UpdateEvent{
+		{ObjectOld: newService("default", "app", nil), ObjectNew: newService("default", "app", nil)},
+		{ObjectOld: newService("alice", "app", nil), ObjectNew: newService("alice", "app", nil)},
+		{ObjectOld: newService("dev-carol", "app", nil), ObjectNew: newService("dev-carol", "app", nil)},
+		{ObjectOld: newService("kube-system", "dns", nil), ObjectNew: newService("kube-system", "dns", nil)},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			e := events[i%len(events)]
+			_ = namespacePredicate.Update(e) && selectorPredicate.Update(e)
+		}
+	})
+	b.StopTimer()
+
+	if n := calls.Load(); n != 0 {
+		b.Fatalf("predicates made %d API calls, want none", n)
+	}
+}
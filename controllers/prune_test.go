@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"testing"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/utils"
+)
+
+func TestDefaultReconcilePrunesRouteWithoutBackingService(t *testing.T) {
+	developer := newService("alice", "app", nil)
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", nil),
+		developer,
+		newService("bob", "app", nil),
+	})
+	env.reconcile("default", "app")
+
+	// The deletion is missed, only the default namespace service is reconciled again
+	env.deleteObject(developer)
+	env.reconcile("default", "app")
+
+	var vsWrites []write
+	for _, w := range env.takeWrites() {
+		if _, ok := w.object.(*istionetworkingv1beta1.VirtualService); ok {
+			vsWrites = append(vsWrites, w)
+		}
+	}
+	if len(vsWrites) != 1 {
+		t.Fatalf("VirtualService was written %d times, want a single update", len(vsWrites))
+	}
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if got := routedDeveloperNamespaces(vs); len(got) != 1 || got[0] != "bob" {
+		t.Errorf("developer routes = %v, want [bob]", got)
+	}
+	if _, stamped := utils.RouteTimestamps(vs)["alice"]; stamped {
+		t.Error("timestamp of the pruned route was kept")
+	}
+
+	if !recordedEvent(env.recorder, "PrunedRoute", "alice") {
+		t.Error("no PrunedRoute event for alice")
+	}
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// reconcileThrottleBurst is how many reconciles of a service may run back to back before the minimum interval applies
+const reconcileThrottleBurst = 3
+
+// reconcileThrottle limits how often a single service is acted on, so a controller that keeps toggling
+// a service can't drive the operator into a tight update loop against the API server.
+// Each service gets its own token bucket refilled once per interval. The zero value is ready to use.
+type reconcileThrottle struct {
+	mu       sync.Mutex
+	limiters map[types.NamespacedName]*rate.Limiter
+}
+
+// delay takes a token for the service and returns zero if the reconcile may proceed, or how long to
+// wait until the next token is available. An interval of zero disables throttling.
+func (t *reconcileThrottle) delay(key types.NamespacedName, interval time.Duration, now time.Time) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.limiters == nil {
+		t.limiters = map[types.NamespacedName]*rate.Limiter{}
+	}
+
+	limit := rate.Every(interval)
+	limiter, exists := t.limiters[key]
+	if !exists || limiter.Limit() != limit {
+		limiter = rate.NewLimiter(limit, reconcileThrottleBurst)
+		t.limiters[key] = limiter
+	}
+
+	reservation := limiter.ReserveN(now, 1)
+	wait := reservation.DelayFrom(now)
+	if wait > 0 {
+		// Don't consume the token, the requeued reconcile will take it
+		reservation.CancelAt(now)
+	}
+	return wait
+}
+
+// forget drops the bucket of a deleted service
+func (t *reconcileThrottle) forget(key types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.limiters, key)
+}
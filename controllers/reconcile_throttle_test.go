@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconcileThrottle(t *testing.T) {
+	key := types.NamespacedName{Namespace: "alice", Name: "app"}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var throttle reconcileThrottle
+
+	for i := 0; i < reconcileThrottleBurst; i++ {
+		if delay := throttle.delay(key, 10*time.Second, now); delay != 0 {
+			t.Fatalf("reconcile %d of the burst delayed by %v", i+1, delay)
+		}
+	}
+	delay := throttle.delay(key, 10*time.Second, now)
+	if delay != 10*time.Second {
+		t.Fatalf("reconcile after the burst delayed by %v, want 10s", delay)
+	}
+	// A throttled reconcile doesn't take the token of the requeued one
+	if again := throttle.delay(key, 10*time.Second, now); again != delay {
+		t.Errorf("second throttled reconcile delayed by %v, want %v", again, delay)
+	}
+	if delay := throttle.delay(key, 10*time.Second, now.Add(delay)); delay != 0 {
+		t.Errorf("requeued reconcile delayed by %v, want it to proceed", delay)
+	}
+
+	// Other services have their own bucket
+	if delay := throttle.delay(types.NamespacedName{Namespace: "bob", Name: "app"}, 10*time.Second, now); delay != 0 {
+		t.Errorf("reconcile of another service delayed by %v", delay)
+	}
+
+	throttle.forget(key)
+	if delay := throttle.delay(key, 10*time.Second, now.Add(delay)); delay != 0 {
+		t.Errorf("reconcile of a forgotten service delayed by %v", delay)
+	}
+
+	if delay := throttle.delay(key, 0, now); delay != 0 {
+		t.Errorf("reconcile delayed by %v without an interval", delay)
+	}
+}
+
+func TestRapidReconcilesAreThrottled(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"minReconcileInterval: 30s\n"), []client.Object{
+		newService("default", "app", nil),
+	})
+	throttled := testutil.ToFloat64(throttledReconcilesTotal.WithLabelValues("default"))
+
+	for i := 0; i < reconcileThrottleBurst; i++ {
+		if result := env.reconcile("default", "app"); result.RequeueAfter != 0 {
+			t.Fatalf("reconcile %d requeued after %v", i+1, result.RequeueAfter)
+		}
+	}
+	env.takeWrites()
+
+	result := env.reconcile("default", "app")
+	if result.RequeueAfter != 30*time.Second {
+		t.Errorf("rapid reconcile requeued after %v, want 30s", result.RequeueAfter)
+	}
+	if writes := env.takeWrites(); len(writes) != 0 {
+		t.Errorf("throttled reconcile wrote %d objects", len(writes))
+	}
+	if got := testutil.ToFloat64(throttledReconcilesTotal.WithLabelValues("default")) - throttled; got != 1 {
+		t.Errorf("throttled reconciles metric increased by %v, want 1", got)
+	}
+
+	env.clock.advance(30 * time.Second)
+	if result := env.reconcile("default", "app"); result.RequeueAfter != 0 {
+		t.Errorf("reconcile after the interval requeued after %v", result.RequeueAfter)
+	}
+}
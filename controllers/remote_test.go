@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"virtualservice-operator/internal/utils"
+)
+
+// remoteDeveloperHosts returns the destination host of each developer route by namespace
+func remoteDeveloperHosts(env *testEnv) map[string]string {
+	vs := env.virtualService("default", "app-virtual-service")
+	hosts := map[string]string{}
+	for _, route := range vs.Spec.Http {
+		if ns, ok := utils.DeveloperRouteNamespace(route); ok {
+			hosts[ns] = route.Route[0].Destination.Host
+		}
+	}
+	return hosts
+}
+
+func TestDefaultReconcileAddsRemoteDeveloperRoutes(t *testing.T) {
+	remote := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+		newService("carol", "app", nil),
+		newService("dave", "app", map[string]string{placeholderAnnotation: "true"}),
+		newService("alice", "app", nil),
+	).Build()
+
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"remoteDeveloperNamespaces: [alice, carol, dave]\nremoteClusterDomain: remote.local\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+	})
+	env.reconciler.RemoteClient = remote
+
+	env.reconcile("default", "app")
+
+	want := map[string]string{
+		// The local developer service wins over the remote one
+		"alice": "app.alice.svc.cluster.local",
+		"carol": "app.carol.svc.remote.local",
+	}
+	if got := remoteDeveloperHosts(env); !reflect.DeepEqual(got, want) {
+		t.Errorf("developer route hosts = %v, want %v", got, want)
+	}
+
+	// A remote developer service that goes away loses its route on the next reconcile
+	if err := remote.Delete(context.Background(), newService("carol", "app", nil)); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile("default", "app")
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, []string{"alice"}) {
+		t.Errorf("routed namespaces after remote deletion = %v, want [alice]", got)
+	}
+}
+
+func TestHasLiveDeveloperServiceInRemoteCluster(t *testing.T) {
+	remote := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+		newService("carol", "app", nil),
+		newService("erin", "app", nil),
+	).Build()
+	operatorConfig := testConfig(t, handlerTestConfig+"remoteDeveloperNamespaces: [carol]\n")
+	env := newTestEnv(t, operatorConfig, nil)
+	env.reconciler.RemoteClient = remote
+
+	tests := []struct {
+		namespace string
+		want      bool
+	}{
+		{namespace: "carol", want: true},
+		// Only remote developer namespaces are looked up remotely
+		{namespace: "erin"},
+		{namespace: "bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			got, err := env.reconciler.hasLiveDeveloperService(context.Background(), "app", tt.namespace, operatorConfig)
+			if err != nil || got != tt.want {
+				t.Errorf("hasLiveDeveloperService() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
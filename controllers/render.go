@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/utils"
+)
+
+// Render computes the VirtualServices and placeholder services the operator would apply for the current
+// cluster state without applying them, e.g. to review and apply them through a GitOps pipeline.
+// The objects carry their TypeMeta so they can be serialized as kubectl-applyable manifests.
+func (r *ServiceReconciler) Render(ctx context.Context) ([]client.Object, error) {
+	config, err := r.ConfigManager.GetConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operator config: %w", err)
+	}
+
+	serviceList := &corev1.ServiceList{}
+	if err := r.List(ctx, serviceList, client.InNamespace(config.DefaultNamespace)); err != nil {
+		return nil, fmt.Errorf("failed to list services in default namespace: %w", err)
+	}
+
+	var objects []client.Object
+	groups := map[string]bool{}
+	for i := range serviceList.Items {
+		service := &serviceList.Items[i]
+		if r.isSystemService(service.Name) || !config.SelectsService(service) {
+			continue
+		}
+		service, err := r.applyServicePolicy(ctx, service, config)
+		if err != nil {
+			return nil, err
+		}
+
+		if config.EnablePlaceholderServices {
+			for _, devNamespace := range config.DeveloperNamespaces {
+				if devNamespace == config.DefaultNamespace {
+					continue
+				}
+
+				existing := &corev1.Service{}
+				err := r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: devNamespace}, existing)
+				if err != nil && !errors.IsNotFound(err) {
+					return nil, fmt.Errorf("failed to get service %s in namespace %s: %w", service.Name, devNamespace, err)
+				}
+				if err == nil && !r.isPlaceholderService(ctx, existing, config) {
+					continue // A real developer service, no placeholder needed
+				}
+
+				placeholder := newPlaceholderService(service, devNamespace, config)
+				placeholder.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Service"))
+				objects = append(objects, placeholder)
+			}
+		}
+
+		// Grouped services are rendered once per group
+		if group := serviceGroup(service); group != "" {
+			if groups[group] {
+				continue
+			}
+			groups[group] = true
+
+			vs, err := r.desiredGroupVirtualService(ctx, group, config)
+			if err != nil {
+				return nil, err
+			}
+			vs.SetGroupVersionKind(istionetworkingv1beta1.SchemeGroupVersion.WithKind("VirtualService"))
+			objects = append(objects, vs)
+			continue
+		}
+
+		// Leave out VirtualServices the operator wouldn't take over
+		existingVS := &istionetworkingv1beta1.VirtualService{}
+		err = r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-virtual-service", service.Name), Namespace: config.DefaultNamespace}, existingVS)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get VirtualService for service %s: %w", service.Name, err)
+		}
+		if err == nil && !utils.IsManagedByOperator(existingVS) && config.UnmanagedVirtualServicePolicy != "adopt" {
+			continue
+		}
+
+		vs, err := r.desiredVirtualService(ctx, service, config)
+		if err != nil {
+			return nil, err
+		}
+		if len(invalidVirtualServiceHosts(vs)) > 0 {
+			continue
+		}
+		vs.SetGroupVersionKind(istionetworkingv1beta1.SchemeGroupVersion.WithKind("VirtualService"))
+		objects = append(objects, vs)
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"context"
+	stderrors "errors"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// isRetryableError checks if a failed API request may succeed when simply retried: conflicts, throttling and
+// server-side timeouts or outages. Errors without an API status, e.g. a dropped connection, are retryable too.
+// Anything else, such as Forbidden from missing RBAC or an invalid object, fails the same way on every attempt.
+func isRetryableError(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case stderrors.Is(err, context.Canceled), stderrors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.IsConflict(err), errors.IsServerTimeout(err), errors.IsTimeout(err),
+		errors.IsTooManyRequests(err), errors.IsServiceUnavailable(err), errors.IsInternalError(err):
+		return true
+	}
+
+	var status errors.APIStatus
+	return !stderrors.As(err, &status)
+}
@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestRetryVirtualServiceUpdateStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Every real update conflicts, the second one is the last before shutdown
+	updates := 0
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{newService("default", "app", nil)},
+		withInterceptor(interceptor.Funcs{
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				updateOpts := &client.UpdateOptions{}
+				updateOpts.ApplyOptions(opts)
+				if _, ok := obj.(*istionetworkingv1beta1.VirtualService); !ok || len(updateOpts.DryRun) > 0 {
+					return nil
+				}
+				updates++
+				if updates == 2 {
+					cancel()
+				}
+				return apierrors.NewConflict(schema.GroupResource{Group: "networking.istio.io", Resource: "virtualservices"}, obj.GetName(), errors.New("the object has been modified"))
+			},
+		}))
+	env.reconcile("default", "app")
+	env.takeWrites()
+	vs := env.virtualService("default", "app-virtual-service")
+
+	start := time.Now()
+	err := env.reconciler.retryVirtualServiceUpdate(ctx, vs, func(latest *istionetworkingv1beta1.VirtualService) error {
+		latest.Spec.Hosts = []string{"app", "app.example.com"}
+		return nil
+	})
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("retryVirtualServiceUpdate() = %v, want the cancellation", err)
+	}
+	if updates != 2 {
+		t.Errorf("%d updates attempted, want none after the cancellation", updates)
+	}
+	// The remaining backoff steps alone would take over a second
+	if elapsed > 500*time.Millisecond {
+		t.Errorf("retryVirtualServiceUpdate() returned after %v, want promptly after the cancellation", elapsed)
+	}
+	if writes := env.takeWrites(); len(writes) != 0 {
+		t.Errorf("%d writes reached the API server", len(writes))
+	}
+	if got := env.virtualService("default", "app-virtual-service").Spec.Hosts; !reflect.DeepEqual(got, vs.Spec.Hosts) {
+		t.Errorf("hosts = %v after the cancelled update, want them unchanged", got)
+	}
+}
+
+func TestRetryVirtualServiceUpdateReturnsUnderlyingError(t *testing.T) {
+	resource := schema.GroupResource{Group: "networking.istio.io", Resource: "virtualservices"}
+	tests := []struct {
+		name         string
+		getErr       error
+		updateErr    error
+		is           func(error) bool
+		wantAttempts int32
+	}{
+		{
+			name:         "get keeps failing",
+			getErr:       apierrors.NewServiceUnavailable("etcd is unavailable"),
+			is:           apierrors.IsServiceUnavailable,
+			wantAttempts: 5,
+		},
+		{
+			name:         "update keeps conflicting",
+			updateErr:    apierrors.NewConflict(resource, "app-virtual-service", errors.New("the object has been modified")),
+			is:           apierrors.IsConflict,
+			wantAttempts: 5,
+		},
+		{
+			name:         "get forbidden",
+			getErr:       apierrors.NewForbidden(resource, "app-virtual-service", errors.New("RBAC denied")),
+			is:           apierrors.IsForbidden,
+			wantAttempts: 1,
+		},
+		{
+			name:         "update forbidden",
+			updateErr:    apierrors.NewForbidden(resource, "app-virtual-service", errors.New("RBAC denied")),
+			is:           apierrors.IsForbidden,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failing atomic.Bool
+			var attempts atomic.Int32
+			env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{newService("default", "app", nil)},
+				withInterceptor(interceptor.Funcs{
+					Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+						if _, ok := obj.(*istionetworkingv1beta1.VirtualService); ok && failing.Load() && tt.getErr != nil {
+							attempts.Add(1)
+							return tt.getErr
+						}
+						return c.Get(ctx, key, obj, opts...)
+					},
+					Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+						updateOpts := &client.UpdateOptions{}
+						updateOpts.ApplyOptions(opts)
+						if _, ok := obj.(*istionetworkingv1beta1.VirtualService); ok && failing.Load() && tt.updateErr != nil && len(updateOpts.DryRun) == 0 {
+							attempts.Add(1)
+							return tt.updateErr
+						}
+						return nil
+					},
+				}))
+			env.reconcile("default", "app")
+			vs := env.virtualService("default", "app-virtual-service")
+
+			failing.Store(true)
+			err := env.reconciler.retryVirtualServiceUpdate(context.Background(), vs, func(latest *istionetworkingv1beta1.VirtualService) error {
+				latest.Spec.Hosts = []string{"app", "app.example.com"}
+				return nil
+			})
+
+			if err == nil || !tt.is(err) {
+				t.Errorf("retryVirtualServiceUpdate() = %v, want the underlying API error", err)
+			}
+			if wait.Interrupted(err) {
+				t.Errorf("retryVirtualServiceUpdate() = %v, want the cause instead of the backoff timeout", err)
+			}
+			if n := attempts.Load(); n != tt.wantAttempts {
+				t.Errorf("%d attempts, want %d", n, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryVirtualServiceUpdateBacksOffOnClock(t *testing.T) {
+	conflict := apierrors.NewConflict(schema.GroupResource{Group: "networking.istio.io", Resource: "virtualservices"}, "app-virtual-service", errors.New("the object has been modified"))
+	var failing atomic.Bool
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{newService("default", "app", nil)},
+		withInterceptor(interceptor.Funcs{
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				if failing.Load() {
+					return conflict
+				}
+				return c.Update(ctx, obj, opts...)
+			},
+		}))
+	env.reconcile("default", "app")
+	vs := env.virtualService("default", "app-virtual-service")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := &tickingClock{fakeClock: env.clock, ticks: 10, cancel: cancel}
+	env.reconciler.Clock = clock
+	start := clock.Now()
+
+	failing.Store(true)
+	realStart := time.Now()
+	err := env.reconciler.retryVirtualServiceUpdate(ctx, vs, func(latest *istionetworkingv1beta1.VirtualService) error {
+		latest.Spec.Hosts = []string{"app", "app.example.com"}
+		return nil
+	})
+	if !apierrors.IsConflict(err) {
+		t.Fatalf("retryVirtualServiceUpdate() = %v, want the conflict", err)
+	}
+
+	// Five attempts wait four exponentially growing, jittered delays on the clock and none in real time
+	base := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond}
+	if len(clock.waits) != len(base) {
+		t.Fatalf("waits = %v, want %d", clock.waits, len(base))
+	}
+	var total time.Duration
+	for i, d := range clock.waits {
+		if d < base[i] || d > base[i]+base[i]/10 {
+			t.Errorf("wait %d = %v, want %v plus up to 10%% jitter", i, d, base[i])
+		}
+		total += d
+	}
+	if got := clock.Now().Sub(start); got != total {
+		t.Errorf("clock advanced by %v, want the %v of backoff", got, total)
+	}
+	if elapsed := time.Since(realStart); elapsed > 100*time.Millisecond {
+		t.Errorf("retryVirtualServiceUpdate() took %v of real time", elapsed)
+	}
+}
@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// rolloutAnnotation configures a progressive rollout of a developer route,
+	// e.g. "start=10,step=10,interval=5m,target=100"
+	rolloutAnnotation = "virtualservice-operator/rollout"
+	// rolloutWeightAnnotation records the weight the developer route currently carries
+	rolloutWeightAnnotation = "virtualservice-operator/rollout-weight"
+	// rolloutUpdatedAnnotation records when the rollout weight last changed (RFC3339)
+	rolloutUpdatedAnnotation = "virtualservice-operator/rollout-updated"
+	// rolloutSpecAnnotation records the rollout annotation the recorded progress belongs to
+	rolloutSpecAnnotation = "virtualservice-operator/rollout-spec"
+)
+
+// rolloutProgressAnnotations are the annotations recording the progress of a rollout
+var rolloutProgressAnnotations = []string{rolloutWeightAnnotation, rolloutUpdatedAnnotation, rolloutSpecAnnotation}
+
+// rolloutSpec describes how the weight of a developer route changes over time.
+// A target below the start weight rolls the route back instead of forward.
+type rolloutSpec struct {
+	Start    int32
+	Step     int32
+	Target   int32
+	Interval time.Duration
+}
+
+// rolloutStep is the weight a developer route should carry at a point in time
+type rolloutStep struct {
+	Weight int32
+	// Advanced is true when Weight differs from the progress recorded on the service
+	Advanced bool
+	// RequeueAfter is the delay until the next step, zero once the target is reached
+	RequeueAfter time.Duration
+}
+
+// parseRollout parses the value of the rollout annotation
+func parseRollout(value string) (*rolloutSpec, error) {
+	spec := &rolloutSpec{Start: -1, Step: -1, Target: -1}
+
+	for _, entry := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			return nil, fmt.Errorf("invalid rollout entry %q, expected key=value", entry)
+		}
+
+		switch key {
+		case "start", "step", "target":
+			n, err := strconv.ParseInt(val, 10, 32)
+			if err != nil || n < 0 || n > 100 {
+				return nil, fmt.Errorf("invalid rollout %s %q, expected an integer between 0 and 100", key, val)
+			}
+			switch key {
+			case "start":
+				spec.Start = int32(n)
+			case "step":
+				spec.Step = int32(n)
+			case "target":
+				spec.Target = int32(n)
+			}
+		case "interval":
+			d, err := time.ParseDuration(val)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid rollout interval %q, expected a positive duration", val)
+			}
+			spec.Interval = d
+		default:
+			return nil, fmt.Errorf("unknown rollout key %q", key)
+		}
+	}
+
+	if spec.Start < 0 || spec.Target < 0 || spec.Interval == 0 {
+		return nil, fmt.Errorf("rollout requires start, target and interval")
+	}
+	if spec.Step <= 0 {
+		return nil, fmt.Errorf("rollout requires a positive step")
+	}
+
+	return spec, nil
+}
+
+// next returns the weight one step closer to the target
+func (s *rolloutSpec) next(weight int32) int32 {
+	if weight < s.Target {
+		weight += s.Step
+		if weight > s.Target {
+			weight = s.Target
+		}
+	} else if weight > s.Target {
+		weight -= s.Step
+		if weight < s.Target {
+			weight = s.Target
+		}
+	}
+	return weight
+}
+
+// nextRolloutStep computes the rollout weight for a developer service at the given time.
+// It returns nil when the service has no rollout annotation.
+func nextRolloutStep(service *corev1.Service, now time.Time) (*rolloutStep, error) {
+	if !hasAnnotation(service, rolloutAnnotation) {
+		return nil, nil
+	}
+
+	spec, err := parseRollout(getAnnotation(service, rolloutAnnotation))
+	if err != nil {
+		return nil, err
+	}
+
+	weight, recorded := recordedRolloutWeight(service)
+	if !recorded {
+		// No recorded progress yet, the rollout starts now
+		step := &rolloutStep{Weight: spec.Start, Advanced: true}
+		if spec.Start != spec.Target {
+			step.RequeueAfter = spec.Interval
+		}
+		return step, nil
+	}
+
+	if weight == spec.Target {
+		return &rolloutStep{Weight: weight}, nil
+	}
+
+	updated, err := time.Parse(time.RFC3339, getAnnotation(service, rolloutUpdatedAnnotation))
+	if err != nil {
+		// Progress without a timestamp, restart the interval from now
+		return &rolloutStep{Weight: weight, Advanced: true, RequeueAfter: spec.Interval}, nil
+	}
+
+	if elapsed := now.Sub(updated); elapsed < spec.Interval {
+		return &rolloutStep{Weight: weight, RequeueAfter: spec.Interval - elapsed}, nil
+	}
+
+	step := &rolloutStep{Weight: spec.next(weight), Advanced: true}
+	if step.Weight != spec.Target {
+		step.RequeueAfter = spec.Interval
+	}
+	return step, nil
+}
+
+// currentRolloutWeight returns the recorded rollout weight of a developer service without advancing it
+func currentRolloutWeight(service *corev1.Service) *int32 {
+	if !hasAnnotation(service, rolloutAnnotation) {
+		return nil
+	}
+
+	weight, recorded := recordedRolloutWeight(service)
+	if !recorded {
+		return nil
+	}
+	return &weight
+}
+
+// recordedRolloutWeight returns the rollout weight recorded on a developer service. Progress recorded for
+// another rollout annotation doesn't count, so a changed rollout starts over instead of resuming from the
+// weight an earlier rollout reached. Progress recorded before the rollout was tracked counts as current.
+func recordedRolloutWeight(service *corev1.Service) (int32, bool) {
+	if hasAnnotation(service, rolloutSpecAnnotation) && getAnnotation(service, rolloutSpecAnnotation) != getAnnotation(service, rolloutAnnotation) {
+		return 0, false
+	}
+
+	current, err := strconv.ParseInt(getAnnotation(service, rolloutWeightAnnotation), 10, 32)
+	if err != nil || current < 0 || current > 100 {
+		return 0, false
+	}
+	return int32(current), true
+}
+
+// persistRolloutStep records the rollout progress on the developer service
+func (r *ServiceReconciler) persistRolloutStep(ctx context.Context, service *corev1.Service, step *rolloutStep, now time.Time) error {
+	original := service.DeepCopy()
+	setAnnotation(service, rolloutWeightAnnotation, strconv.Itoa(int(step.Weight)))
+	setAnnotation(service, rolloutUpdatedAnnotation, now.UTC().Format(time.RFC3339))
+	setAnnotation(service, rolloutSpecAnnotation, getAnnotation(service, rolloutAnnotation))
+
+	if err := r.Patch(ctx, service, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to record rollout progress for service %s/%s: %w", service.Namespace, service.Name, err)
+	}
+	return nil
+}
+
+// clearRolloutProgress removes the recorded progress from a developer service whose rollout annotation was
+// removed, so the weight it reached neither lingers nor seeds a later rollout
+func (r *ServiceReconciler) clearRolloutProgress(ctx context.Context, service *corev1.Service) error {
+	if hasAnnotation(service, rolloutAnnotation) {
+		return nil
+	}
+
+	original := service.DeepCopy()
+	for _, key := range rolloutProgressAnnotations {
+		removeAnnotation(service, key)
+	}
+	if equality.Semantic.DeepEqual(original.Annotations, service.Annotations) {
+		return nil
+	}
+
+	if err := r.Patch(ctx, service, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to clear rollout progress for service %s/%s: %w", service.Namespace, service.Name, err)
+	}
+	return nil
+}
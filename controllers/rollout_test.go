@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/utils"
+)
+
+func TestParseRollout(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    rolloutSpec
+		wantErr bool
+	}{
+		{name: "forward", value: "start=10,step=10,interval=5m,target=100", want: rolloutSpec{Start: 10, Step: 10, Target: 100, Interval: 5 * time.Minute}},
+		{name: "rollback", value: "start=100, step=25, interval=1h, target=0", want: rolloutSpec{Start: 100, Step: 25, Target: 0, Interval: time.Hour}},
+		{name: "missing target", value: "start=10,step=10,interval=5m", wantErr: true},
+		{name: "zero step", value: "start=10,step=0,interval=5m,target=100", wantErr: true},
+		{name: "weight above 100", value: "start=10,step=10,interval=5m,target=101", wantErr: true},
+		{name: "negative interval", value: "start=10,step=10,interval=-5m,target=100", wantErr: true},
+		{name: "unknown key", value: "start=10,step=10,interval=5m,target=100,jitter=1", wantErr: true},
+		{name: "no value", value: "start", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := parseRollout(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseRollout(%q) = %+v, want an error", tt.value, spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRollout(%q) failed: %v", tt.value, err)
+			}
+			if *spec != tt.want {
+				t.Errorf("parseRollout(%q) = %+v, want %+v", tt.value, *spec, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextRolloutStepAdvancesEveryInterval(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	service := newService("alice", "app", map[string]string{rolloutAnnotation: "start=10,step=40,interval=5m,target=100"})
+	persist := func(step *rolloutStep) {
+		setAnnotation(service, rolloutWeightAnnotation, fmtWeight(step.Weight))
+		setAnnotation(service, rolloutUpdatedAnnotation, clock.Now().Format(time.RFC3339))
+		setAnnotation(service, rolloutSpecAnnotation, getAnnotation(service, rolloutAnnotation))
+	}
+
+	steps := []struct {
+		after        time.Duration
+		weight       int32
+		advanced     bool
+		requeueAfter time.Duration
+	}{
+		{after: 0, weight: 10, advanced: true, requeueAfter: 5 * time.Minute},
+		{after: 2 * time.Minute, weight: 10, requeueAfter: 3 * time.Minute},
+		{after: 3 * time.Minute, weight: 50, advanced: true, requeueAfter: 5 * time.Minute},
+		{after: 5 * time.Minute, weight: 90, advanced: true, requeueAfter: 5 * time.Minute},
+		{after: 5 * time.Minute, weight: 100, advanced: true},
+		{after: time.Hour, weight: 100},
+	}
+	for i, want := range steps {
+		clock.advance(want.after)
+		step, err := nextRolloutStep(service, clock.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if step.Weight != want.weight || step.Advanced != want.advanced || step.RequeueAfter != want.requeueAfter {
+			t.Fatalf("step %d = %+v, want %+v", i, *step, want)
+		}
+		if step.Advanced {
+			persist(step)
+		}
+	}
+}
+
+func TestNextRolloutStepRollsBack(t *testing.T) {
+	service := newService("alice", "app", map[string]string{
+		rolloutAnnotation:        "start=100,step=30,interval=1m,target=0",
+		rolloutWeightAnnotation:  "10",
+		rolloutUpdatedAnnotation: "2024-01-01T12:00:00Z",
+	})
+
+	step, err := nextRolloutStep(service, time.Date(2024, 1, 1, 12, 1, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step.Weight != 0 || step.RequeueAfter != 0 {
+		t.Errorf("step = %+v, want weight 0 and no requeue", *step)
+	}
+}
+
+func TestNextRolloutStepRestartsChangedRollout(t *testing.T) {
+	service := newService("alice", "app", map[string]string{
+		rolloutAnnotation:        "start=20,step=10,interval=5m,target=50",
+		rolloutWeightAnnotation:  "100",
+		rolloutUpdatedAnnotation: "2024-01-01T12:00:00Z",
+		rolloutSpecAnnotation:    "start=10,step=10,interval=5m,target=100",
+	})
+
+	step, err := nextRolloutStep(service, time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step.Weight != 20 || !step.Advanced {
+		t.Errorf("step = %+v, want the new rollout to start at 20", *step)
+	}
+	if weight := currentRolloutWeight(service); weight != nil {
+		t.Errorf("current weight = %d, want none for progress of another rollout", *weight)
+	}
+}
+
+func TestRolloutReconcileAdvancesRouteWeight(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", map[string]string{rolloutAnnotation: "start=10,step=40,interval=5m,target=100"}),
+	})
+	env.reconcile("default", "app")
+
+	for i, want := range []struct {
+		weight       int32
+		requeueAfter time.Duration
+	}{
+		{weight: 10, requeueAfter: 5 * time.Minute},
+		{weight: 50, requeueAfter: 5 * time.Minute},
+		{weight: 90, requeueAfter: 5 * time.Minute},
+		{weight: 100},
+		{weight: 100},
+	} {
+		if i > 0 {
+			env.clock.advance(5 * time.Minute)
+		}
+		result := env.reconcile("alice", "app")
+		if result.RequeueAfter != want.requeueAfter {
+			t.Errorf("interval %d: requeue after %v, want %v", i, result.RequeueAfter, want.requeueAfter)
+		}
+		if weight := developerRouteWeight(t, env.virtualService("default", "app-virtual-service"), "alice"); weight != want.weight {
+			t.Errorf("interval %d: route weight %d, want %d", i, weight, want.weight)
+		}
+		if recorded := getAnnotation(env.service("alice", "app"), rolloutWeightAnnotation); recorded != fmtWeight(want.weight) {
+			t.Errorf("interval %d: recorded weight %q, want %d", i, recorded, want.weight)
+		}
+	}
+
+	// The default namespace reconcile keeps the weight the rollout reached
+	env.reconcile("default", "app")
+	if weight := developerRouteWeight(t, env.virtualService("default", "app-virtual-service"), "alice"); weight != 100 {
+		t.Errorf("route weight after default reconcile %d, want 100", weight)
+	}
+}
+
+func TestRolloutProgressClearedWhenAnnotationRemoved(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", map[string]string{rolloutAnnotation: "start=10,step=40,interval=5m,target=100"}),
+	})
+	env.reconcile("default", "app")
+	env.reconcile("alice", "app")
+
+	developer := env.service("alice", "app")
+	removeAnnotation(developer, rolloutAnnotation)
+	if err := env.client.Update(context.Background(), developer); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile("alice", "app")
+
+	developer = env.service("alice", "app")
+	for _, key := range rolloutProgressAnnotations {
+		if hasAnnotation(developer, key) {
+			t.Errorf("annotation %s still set after the rollout was removed", key)
+		}
+	}
+	if weight := developerRouteWeight(t, env.virtualService("default", "app-virtual-service"), "alice"); weight != 100 {
+		t.Errorf("route weight %d after the rollout was removed, want 100", weight)
+	}
+
+	// A later rollout starts from its own start weight
+	setAnnotation(developer, rolloutAnnotation, "start=30,step=10,interval=5m,target=100")
+	if err := env.client.Update(context.Background(), developer); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile("alice", "app")
+	if weight := developerRouteWeight(t, env.virtualService("default", "app-virtual-service"), "alice"); weight != 30 {
+		t.Errorf("route weight %d for the new rollout, want 30", weight)
+	}
+}
+
+// developerRouteWeight returns the percentage of matched traffic the route of a developer namespace sends to it
+func developerRouteWeight(t *testing.T, vs *istionetworkingv1beta1.VirtualService, devNamespace string) int32 {
+	t.Helper()
+	for _, route := range vs.Spec.Http {
+		if ns, ok := utils.DeveloperRouteNamespace(route); !ok || ns != devNamespace {
+			continue
+		}
+		if len(route.Route) == 1 {
+			return 100
+		}
+		return route.Route[0].Weight
+	}
+	t.Fatalf("no route for developer namespace %s", devNamespace)
+	return 0
+}
+
+func fmtWeight(weight int32) string {
+	return strconv.Itoa(int(weight))
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/utils"
+)
+
+const routeAgeTestConfig = `
+defaultNamespace: default
+developerNamespaces: [alice, bob]
+maxRouteAge: 1h
+`
+
+func TestMaxRouteAge(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, routeAgeTestConfig), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+	})
+	start := env.clock.Now()
+	env.reconcile("default", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if got := utils.RouteTimestamps(vs); !reflect.DeepEqual(got, map[string]time.Time{"alice": start}) {
+		t.Fatalf("timestamps = %v, want alice stamped at %v", got, start)
+	}
+
+	// A route added later is stamped when it's added, the reconcile comes back when alice's route expires
+	env.clock.advance(30 * time.Minute)
+	if err := env.client.Create(context.Background(), newService("bob", "app", nil)); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile("bob", "app")
+	result := env.reconcile("default", "app")
+	if result.RequeueAfter != 30*time.Minute {
+		t.Errorf("RequeueAfter = %v, want 30m", result.RequeueAfter)
+	}
+	vs = env.virtualService("default", "app-virtual-service")
+	if got, want := utils.RouteTimestamps(vs), map[string]time.Time{"alice": start, "bob": start.Add(30 * time.Minute)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("timestamps = %v, want %v", got, want)
+	}
+
+	// Past maxAge alice's route is reaped, bob's stays
+	env.clock.advance(31 * time.Minute)
+	env.reconcile("default", "app")
+	vs = env.virtualService("default", "app-virtual-service")
+	if got := routedDeveloperNamespaces(vs); !reflect.DeepEqual(got, []string{"bob"}) {
+		t.Errorf("developer routes = %v, want [bob]", got)
+	}
+
+	// Its developer service still exists, reconciling it doesn't add the route back
+	env.reconcile("alice", "app")
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, []string{"bob"}) {
+		t.Errorf("developer routes after reconciling alice = %v, want [bob]", got)
+	}
+
+	// A developer service created again gets a fresh route
+	env.deleteObject(env.service("alice", "app"))
+	env.reconcile("alice", "app")
+	if err := env.client.Create(context.Background(), newService("alice", "app", nil)); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile("alice", "app")
+	vs = env.virtualService("default", "app-virtual-service")
+	if got := routedDeveloperNamespaces(vs); !reflect.DeepEqual(got, []string{"bob", "alice"}) {
+		t.Errorf("developer routes after recreating alice = %v, want [bob alice]", got)
+	}
+	if got := utils.RouteTimestamps(vs)["alice"]; !got.Equal(env.clock.Now()) {
+		t.Errorf("alice timestamp = %v, want %v", got, env.clock.Now())
+	}
+}
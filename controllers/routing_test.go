@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestAuthorityRoutingStrategy(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"routingStrategy: authority\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+	})
+	env.reconcile("default", "app")
+	env.reconcile("alice", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if len(vs.Spec.Http) != 2 {
+		t.Fatalf("got %d routes, want the alice and default routes", len(vs.Spec.Http))
+	}
+	route := vs.Spec.Http[0]
+	if got := route.Match[0].Headers["x-developer"].GetExact(); got != "alice" {
+		t.Errorf("route matches x-developer %q, want alice", got)
+	}
+	// The route stays on the default destination with the authority of the developer service
+	if len(route.Route) != 1 || route.Route[0].Destination.Host != "app.default.svc.cluster.local" {
+		t.Errorf("route destinations = %v, want the default service", route.Route)
+	}
+	if route.Rewrite == nil || route.Rewrite.Authority != "app.alice.svc.cluster.local" {
+		t.Errorf("route rewrite = %v, want the alice authority", route.Rewrite)
+	}
+
+	env.deleteObject(newService("alice", "app", nil))
+	env.reconcile("alice", "app")
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); len(got) != 0 {
+		t.Errorf("rewrite route kept after deleting the developer service: %v", got)
+	}
+}
+
+func TestSourceRoutingStrategy(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"routingStrategy: source\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+	})
+	env.reconcile("default", "app")
+	env.reconcile("alice", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if len(vs.Spec.Http) != 2 {
+		t.Fatalf("got %d routes, want the alice and default routes", len(vs.Spec.Http))
+	}
+	match := vs.Spec.Http[0].Match[0]
+	if match.SourceNamespace != "alice" || len(match.Headers) != 0 {
+		t.Errorf("route match = %v, want only sourceNamespace alice", match)
+	}
+	if got := developerDestinations(vs)["alice"].GetHost(); got != "app.alice.svc.cluster.local" {
+		t.Errorf("alice destination = %q, want the alice service", got)
+	}
+
+	env.deleteObject(newService("alice", "app", nil))
+	env.reconcile("alice", "app")
+	vs = env.virtualService("default", "app-virtual-service")
+	if got := routedDeveloperNamespaces(vs); len(got) != 0 {
+		t.Errorf("source route kept after deleting the developer service: %v", got)
+	}
+	if len(vs.Spec.Http) != 1 {
+		t.Errorf("got %d routes, want only the default route", len(vs.Spec.Http))
+	}
+}
+
+func TestSwitchingToSourceRoutingReplacesHeaderRoutes(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+	})
+	env.reconcile("default", "app")
+
+	env.config.set(testConfig(t, handlerTestConfig+"routingStrategy: source\n"))
+	env.reconcile("alice", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if len(vs.Spec.Http) != 2 {
+		t.Fatalf("got %d routes, want the alice and default routes", len(vs.Spec.Http))
+	}
+	if match := vs.Spec.Http[0].Match[0]; match.SourceNamespace != "alice" || len(match.Headers) != 0 {
+		t.Errorf("route match = %v, want the header route replaced by a sourceNamespace route", match)
+	}
+}
+
+func TestDevHeaderPrefixAndSuffix(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantValue string
+	}{
+		{name: "none", wantValue: "alice"},
+		{name: "prefix", config: "devHeaderPrefix: ns-\n", wantValue: "ns-alice"},
+		{name: "suffix", config: "devHeaderSuffix: .dev\n", wantValue: "alice.dev"},
+		{name: "both", config: "devHeaderPrefix: ns-\ndevHeaderSuffix: .dev\n", wantValue: "ns-alice.dev"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, testConfig(t, handlerTestConfig+tt.config), []client.Object{
+				newService("default", "app", nil),
+				newService("alice", "app", nil),
+			})
+			env.reconcile("default", "app")
+			env.reconcile("alice", "app")
+			env.reconcile("default", "app")
+
+			vs := env.virtualService("default", "app-virtual-service")
+			if len(vs.Spec.Http) != 2 {
+				t.Fatalf("got %d routes, want the alice and default routes", len(vs.Spec.Http))
+			}
+			if got := vs.Spec.Http[0].Match[0].Headers["x-developer"].GetExact(); got != tt.wantValue {
+				t.Errorf("route matches x-developer %q, want %q", got, tt.wantValue)
+			}
+			if got := routedDeveloperNamespaces(vs); len(got) != 1 || got[0] != "alice" {
+				t.Errorf("developer routes = %v, want [alice]", got)
+			}
+
+			env.deleteObject(newService("alice", "app", nil))
+			env.reconcile("alice", "app")
+			vs = env.virtualService("default", "app-virtual-service")
+			if len(vs.Spec.Http) != 1 {
+				t.Errorf("got %d routes after deleting the developer service, want only the default route", len(vs.Spec.Http))
+			}
+		})
+	}
+}
+
+func TestDevHeaderPrefixChangeReplacesRoute(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"devHeaderPrefix: ns-\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+	})
+	env.reconcile("default", "app")
+
+	// The route written with the old prefix is recognized and replaced, then removed with the new one
+	env.config.set(testConfig(t, handlerTestConfig+"devHeaderPrefix: team-\n"))
+	env.reconcile("alice", "app")
+	vs := env.virtualService("default", "app-virtual-service")
+	if len(vs.Spec.Http) != 2 {
+		t.Fatalf("got %d routes, want the alice and default routes", len(vs.Spec.Http))
+	}
+	if got := vs.Spec.Http[0].Match[0].Headers["x-developer"].GetExact(); got != "team-alice" {
+		t.Errorf("route matches x-developer %q, want team-alice", got)
+	}
+
+	env.deleteObject(newService("alice", "app", nil))
+	env.reconcile("alice", "app")
+	if vs := env.virtualService("default", "app-virtual-service"); len(vs.Spec.Http) != 1 {
+		t.Errorf("got %d routes after deleting the developer service, want only the default route", len(vs.Spec.Http))
+	}
+}
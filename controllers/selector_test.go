@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const selectorTestConfig = handlerTestConfig + `serviceSelector:
+  matchLabels:
+    team: web
+`
+
+// labeledService builds a service carrying a team label
+func labeledService(namespace, name, team string) *corev1.Service {
+	service := newService(namespace, name, nil)
+	service.Labels = map[string]string{"team": team}
+	return service
+}
+
+func TestServiceSelector(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, selectorTestConfig), []client.Object{
+		labeledService("default", "web", "web"),
+		labeledService("default", "batch", "data"),
+	})
+	env.reconcile("default", "web")
+	env.reconcile("default", "batch")
+
+	if env.virtualService("default", "web-virtual-service") == nil || env.service("alice", "web") == nil {
+		t.Error("matching service got no VirtualService or placeholder")
+	}
+	if env.virtualService("default", "batch-virtual-service") != nil || env.service("alice", "batch") != nil {
+		t.Error("non-matching service got a VirtualService or placeholder")
+	}
+}
+
+func TestServiceStopsMatchingSelector(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, selectorTestConfig), []client.Object{
+		labeledService("default", "web", "web"),
+	})
+	env.reconcile("default", "web")
+	if env.virtualService("default", "web-virtual-service") == nil {
+		t.Fatal("matching service got no VirtualService")
+	}
+
+	old := env.service("default", "web")
+	env.updateService("default", "web", func(service *corev1.Service) {
+		service.Labels["team"] = "data"
+	})
+
+	// The relabel passes the predicate because the old version matched
+	if !env.reconciler.selectorPredicate().Update(event.UpdateEvent{ObjectOld: old, ObjectNew: env.service("default", "web")}) {
+		t.Fatal("relabel of a service that stopped matching was filtered out")
+	}
+	env.reconcile("default", "web")
+
+	if env.virtualService("default", "web-virtual-service") != nil {
+		t.Error("VirtualService kept after the service stopped matching")
+	}
+	for _, ns := range []string{"alice", "bob"} {
+		if env.service(ns, "web") != nil {
+			t.Errorf("placeholder in %s kept after the service stopped matching", ns)
+		}
+	}
+}
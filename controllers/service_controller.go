@@ -3,40 +3,103 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	istiov1beta1 "istio.io/api/networking/v1beta1"
 	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	"virtualservice-operator/api/v1alpha1"
 	"virtualservice-operator/internal/config"
 	"virtualservice-operator/internal/utils"
 )
 
+const (
+	// conflictAnnotation is set on a default namespace service whose VirtualService can't be managed by the operator
+	conflictAnnotation = "virtualservice-operator/conflict"
+	// placeholderLabel is set on every placeholder service so it can be selected distinctly
+	placeholderLabel = "virtualservice-operator/placeholder"
+	// defaultRouteNamespaceAnnotation on a default namespace service, or on the default namespace itself,
+	// sends traffic without an x-developer header to the given developer namespace
+	defaultRouteNamespaceAnnotation = "virtualservice-operator/default-route-namespace"
+)
+
+// endpointsRecheckInterval is how often a withheld developer route is checked for ready endpoints again
+const endpointsRecheckInterval = 30 * time.Second
+
 // ServiceReconciler reconciles a Service object
 type ServiceReconciler struct {
 	client.Client
 	Scheme        *runtime.Scheme
-	ConfigManager *config.ConfigManager
+	ConfigManager config.Provider
+	// Recorder emits events on services, events are discarded when nil
+	Recorder record.EventRecorder
+	// RemoteClient optionally reads developer services from a second cluster of the mesh
+	RemoteClient client.Reader
+	// CachedNamespaces are the namespaces the manager cache is limited to, all namespaces are cached when empty.
+	// They are fixed at startup, watched namespaces outside them are ignored until a restart.
+	CachedNamespaces []string
+	// EnablePolicies applies VirtualServicePolicies to the services they target
+	EnablePolicies bool
+	// Clock tells the time, the real clock is used when nil
+	Clock Clock
+
+	backfill placeholderBackfill
+	throttle reconcileThrottle
+	// reconciled holds the time each service was last reconciled, for the status document
+	reconciled sync.Map
+	// policiesIndexed is set once the cached policies are indexed by policyTargetIndex
+	policiesIndexed bool
 }
 
 // Reconcile handles Service events and manages VirtualServices
-func (r *ServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *ServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, retErr error) {
+	ctx, span := tracer.Start(ctx, "Reconcile", trace.WithAttributes(
+		attribute.String("namespace", req.Namespace),
+		attribute.String("service", req.Name),
+	))
+	defer func() { endSpan(span, retErr) }()
+	defer r.recordReconcile(req.NamespacedName, r.clock().Now())
+
 	// Get operator configuration
 	config, err := r.ConfigManager.GetConfig(ctx)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to get operator config: %w", err)
 	}
 
+	// While paused nothing is written. Unpausing changes the ConfigMap, which reconciles every
+	// watched service, so the events dropped meanwhile don't need to be requeued.
+	if config.Paused {
+		pausedGauge.Set(1)
+		ctrl.LoggerFrom(ctx).Info("Operator paused, skipping reconcile", "service", req.Name, "namespace", req.Namespace)
+		span.SetAttributes(attribute.String("action", "paused"))
+		return ctrl.Result{}, nil
+	}
+	pausedGauge.Set(0)
+
 	// Check if this namespace should be watched
 	watchedNamespaces, err := r.ConfigManager.GetWatchedNamespaces(ctx)
 	if err != nil {
@@ -60,17 +123,52 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	if err := r.Get(ctx, req.NamespacedName, &service); err != nil {
 		if errors.IsNotFound(err) {
 			// Service was deleted, handle cleanup
+			r.throttle.forget(req.NamespacedName)
+			span.SetAttributes(attribute.String("action", "delete"))
 			return r.handleServiceDeletion(ctx, req.Name, req.Namespace, config)
 		}
 		return ctrl.Result{}, err
 	}
 
+	// Back off from services that are reconciled in a tight loop
+	if delay := r.throttle.delay(req.NamespacedName, config.MinReconcileInterval.Duration, r.clock().Now()); delay > 0 {
+		ctrl.LoggerFrom(ctx).V(1).Info("Throttling reconcile of frequently changing service", "service", req.Name, "namespace", req.Namespace, "requeueAfter", delay)
+		throttledReconcilesTotal.WithLabelValues(req.Namespace).Inc()
+		span.SetAttributes(attribute.String("action", "throttle"))
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+
 	// Handle service creation/update
+	var result ctrl.Result
 	if req.Namespace == config.DefaultNamespace {
-		return r.handleDefaultNamespaceService(ctx, &service, config)
+		result, err = r.handleDefaultNamespaceService(ctx, &service, config)
 	} else {
-		return r.handleDeveloperNamespaceService(ctx, &service, config)
+		result, err = r.handleDeveloperNamespaceService(ctx, &service, config)
+	}
+	return withResync(result, err, config)
+}
+
+// withResync requeues a successfully reconciled service after the configured resync period
+// so drift is corrected even if a watch event is missed
+func withResync(result ctrl.Result, err error, config *config.OperatorConfig) (ctrl.Result, error) {
+	period := config.ResyncPeriod.Duration
+	if err != nil || period <= 0 || result.Requeue {
+		return result, err
+	}
+
+	// Keep an earlier requeue requested by the handler
+	if result.RequeueAfter == 0 || result.RequeueAfter > period {
+		result.RequeueAfter = period
+	}
+	return result, nil
+}
+
+// recorder returns the event recorder, falling back to one that discards events
+func (r *ServiceReconciler) recorder() record.EventRecorder {
+	if r.Recorder == nil {
+		return &record.FakeRecorder{}
 	}
+	return r.Recorder
 }
 
 // isSystemService checks if a service is a system service that should be excluded from VirtualService creation
@@ -89,38 +187,105 @@ func (r *ServiceReconciler) isSystemService(serviceName string) bool {
 	return false
 }
 
+// isValidIstioHost checks a VirtualService host against the DNS rules Istio applies, see istioHostProblems
+func isValidIstioHost(host string) bool {
+	return len(istioHostProblems(host)) == 0
+}
+
+// istioHostProblems returns why Istio would reject a host: it must be a DNS-1123 subdomain of at most 253
+// characters, and its first label, the service name, a DNS-1035 label
+func istioHostProblems(host string) []string {
+	if problems := validation.IsDNS1123Subdomain(host); len(problems) > 0 {
+		return problems
+	}
+	name, _, _ := strings.Cut(host, ".")
+	return validation.IsDNS1035Label(name)
+}
+
+// invalidVirtualServiceHosts returns the problems with the hosts of a generated VirtualService that Istio
+// would reject. The API server already holds service names to DNS-1035, but the hosts built from them, with
+// the namespace and cluster domain appended, can still exceed the 253 characters a DNS name allows.
+func invalidVirtualServiceHosts(vs *istionetworkingv1beta1.VirtualService) []string {
+	hosts := append([]string(nil), vs.Spec.Hosts...)
+	for _, route := range vs.Spec.Http {
+		for _, destination := range route.Route {
+			hosts = append(hosts, destination.GetDestination().GetHost())
+		}
+	}
+
+	var problems []string
+	seen := map[string]bool{}
+	for _, host := range hosts {
+		if seen[host] || isValidIstioHost(host) {
+			continue
+		}
+		seen[host] = true
+		for _, msg := range istioHostProblems(host) {
+			problems = append(problems, fmt.Sprintf("host %q: %s", host, msg))
+		}
+	}
+	return problems
+}
+
 // isPlaceholderService checks if a service is a placeholder service created by the operator
 // Uses annotations as primary detection method with fallback to service type and external name pattern
-func (r *ServiceReconciler) isPlaceholderService(service *corev1.Service) bool {
+func (r *ServiceReconciler) isPlaceholderService(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) bool {
 	// Primary detection: Check for placeholder annotation
-	if service.Annotations != nil {
-		if placeholderAnnotation, exists := service.Annotations["virtualservice-operator/placeholder-service"]; exists && placeholderAnnotation == "true" {
-			fmt.Printf("DEBUG: Service %s/%s identified as placeholder via annotation\n", service.Namespace, service.Name)
-			return true
-		}
+	if getAnnotation(service, placeholderAnnotation) == "true" {
+		ctrl.LoggerFrom(ctx).V(1).Info("Service identified as placeholder via annotation", "service", service.Name, "namespace", service.Namespace)
+		return true
+	}
+
+	// Canonical label set on every placeholder, also used by network policies and mesh selectors
+	if hasLabel(service, placeholderLabel, "true") {
+		ctrl.LoggerFrom(ctx).V(1).Info("Service identified as placeholder via label", "service", service.Name, "namespace", service.Namespace)
+		return true
 	}
 
-	// Fallback detection: Check if it's an ExternalName service pointing to default namespace
+	// Fallback detection: Check if it's an ExternalName service pointing to a default namespace service
 	if service.Spec.Type == corev1.ServiceTypeExternalName && service.Spec.ExternalName != "" {
-		// Check if external name matches pattern *.default.svc.cluster.local
-		if strings.HasSuffix(service.Spec.ExternalName, ".default.svc.cluster.local") {
-			fmt.Printf("DEBUG: Service %s/%s identified as placeholder via ExternalName pattern: %s\n", service.Namespace, service.Name, service.Spec.ExternalName)
+		if namespace, ok := externalNameNamespace(service.Spec.ExternalName, config.ClusterDomain); ok && namespace == config.DefaultNamespace {
+			ctrl.LoggerFrom(ctx).V(1).Info("Service identified as placeholder via ExternalName pattern", "service", service.Name, "namespace", service.Namespace, "externalName", service.Spec.ExternalName)
 			return true
 		}
 	}
 
-	// Legacy detection: Check for old label-based identification
-	if service.Labels != nil {
-		if placeholderLabel, exists := service.Labels["placeholder-service"]; exists && placeholderLabel == "true" {
-			fmt.Printf("DEBUG: Service %s/%s identified as placeholder via legacy label\n", service.Namespace, service.Name)
-			return true
-		}
+	// Fallback detection for headless placeholders: selectorless headless service pointing at a source service
+	if service.Spec.ClusterIP == corev1.ClusterIPNone && len(service.Spec.Selector) == 0 && hasAnnotation(service, "virtualservice-operator/source-service") {
+		ctrl.LoggerFrom(ctx).V(1).Info("Service identified as headless placeholder", "service", service.Name, "namespace", service.Namespace)
+		return true
+	}
+
+	// Legacy detection: Check for old label-based identification, see MigratePlaceholders
+	if hasLabel(service, legacyPlaceholderLabel, "true") {
+		ctrl.LoggerFrom(ctx).V(1).Info("Service identified as placeholder via legacy label", "service", service.Name, "namespace", service.Namespace)
+		return true
 	}
 
 	return false
 }
 
-// createPlaceholderServices creates placeholder ExternalName services in all developer namespaces
+// externalNameNamespace extracts the namespace from an ExternalName referring to an in-cluster service.
+// It accepts the forms a pod's DNS search path resolves: "svc.ns", "svc.ns.svc" and "svc.ns.svc.<clusterDomain>",
+// with or without a trailing dot and in any case. Other names, such as external hosts, are rejected.
+func externalNameNamespace(externalName, clusterDomain string) (string, bool) {
+	name := strings.ToLower(strings.TrimSuffix(externalName, "."))
+	suffix := ".svc." + strings.ToLower(strings.TrimSuffix(clusterDomain, "."))
+
+	switch {
+	case strings.HasSuffix(name, suffix):
+		name = strings.TrimSuffix(name, suffix)
+	case strings.HasSuffix(name, ".svc"):
+		name = strings.TrimSuffix(name, ".svc")
+	}
+
+	parts := strings.Split(name, ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
 // createSinglePlaceholderService creates a placeholder service in a specific namespace
 func (r *ServiceReconciler) createSinglePlaceholderService(ctx context.Context, sourceService *corev1.Service, targetNamespace string, config *config.OperatorConfig) error {
 	if !config.EnablePlaceholderServices {
@@ -132,46 +297,179 @@ func (r *ServiceReconciler) createSinglePlaceholderService(ctx context.Context,
 		return nil
 	}
 
-	fmt.Printf("DEBUG: Creating placeholder service %s in namespace %s\n", sourceService.Name, targetNamespace)
+	ctrl.LoggerFrom(ctx).V(1).Info("Ensuring placeholder service", "serviceName", sourceService.Name, "namespace", targetNamespace)
+
+	return r.ensurePlaceholderService(ctx, sourceService, targetNamespace, config)
+}
+
+// ensurePlaceholderService is the single place placeholders are written: it creates the placeholder when
+// no service with the name exists and brings an existing placeholder in line with the desired one.
+// A service that isn't a placeholder is never modified, so a real developer service can't be replaced.
+func (r *ServiceReconciler) ensurePlaceholderService(ctx context.Context, sourceService *corev1.Service, targetNamespace string, config *config.OperatorConfig) error {
+	log := ctrl.LoggerFrom(ctx)
 
-	// Check if service already exists in the target namespace
 	existingService := &corev1.Service{}
 	err := r.Get(ctx, types.NamespacedName{Name: sourceService.Name, Namespace: targetNamespace}, existingService)
-	if err == nil {
-		// Service already exists, skip creation
-		fmt.Printf("DEBUG: Service %s already exists in namespace %s, skipping placeholder creation\n", sourceService.Name, targetNamespace)
+	if errors.IsNotFound(err) {
+		log.Info("No existing service found, creating placeholder", "serviceName", sourceService.Name, "namespace", targetNamespace)
+		if err := r.createPlaceholder(ctx, sourceService, targetNamespace, config); err != nil {
+			return err
+		}
+		log.Info("Successfully created placeholder service", "serviceName", sourceService.Name, "namespace", targetNamespace)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check existing service %s in namespace %s: %w", sourceService.Name, targetNamespace, err)
+	}
+
+	if !r.isPlaceholderService(ctx, existingService, config) {
+		log.V(1).Info("Real service exists, not creating placeholder", "serviceName", sourceService.Name, "namespace", targetNamespace, "serviceType", existingService.Spec.Type)
+		return nil
+	}
+
+	return r.updatePlaceholder(ctx, existingService, newPlaceholderService(sourceService, targetNamespace, config))
+}
+
+// updatePlaceholder syncs the metadata and spec of an existing placeholder with the desired placeholder.
+// Only callers that verified the service is a placeholder may call it.
+func (r *ServiceReconciler) updatePlaceholder(ctx context.Context, existing, desired *corev1.Service) error {
+	if existing.Spec.Type != desired.Spec.Type {
+		// Switching between ExternalName and headless changes immutable fields
+		ctrl.LoggerFrom(ctx).Info("Placeholder has a different form than configured, delete it to recreate",
+			"serviceName", existing.Name, "namespace", existing.Namespace, "serviceType", existing.Spec.Type)
+		return nil
+	}
+
+	updated := existing.DeepCopy()
+	for key, value := range desired.Labels {
+		setLabel(updated, key, value)
+	}
+	for key, value := range desired.Annotations {
+		setAnnotation(updated, key, value)
+	}
+	updated.Spec.ExternalName = desired.Spec.ExternalName
+	if desired.Spec.ClusterIP == corev1.ClusterIPNone {
+		updated.Spec.Ports = desired.Spec.Ports
+	}
+
+	if equality.Semantic.DeepEqual(existing.ObjectMeta, updated.ObjectMeta) && equality.Semantic.DeepEqual(existing.Spec, updated.Spec) {
+		return nil
+	}
+
+	if err := r.Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update placeholder service %s in namespace %s: %w", existing.Name, existing.Namespace, err)
+	}
+	ctrl.LoggerFrom(ctx).Info("Updated placeholder service", "serviceName", existing.Name, "namespace", existing.Namespace)
+	return nil
+}
+
+// createPlaceholder creates the placeholder service and, for headless placeholders, the Endpoints resolving to the source service
+func (r *ServiceReconciler) createPlaceholder(ctx context.Context, sourceService *corev1.Service, targetNamespace string, config *config.OperatorConfig) error {
+	placeholderService := newPlaceholderService(sourceService, targetNamespace, config)
+
+	if err := r.Create(ctx, placeholderService); err != nil {
+		return fmt.Errorf("failed to create placeholder service %s in namespace %s: %w", sourceService.Name, targetNamespace, err)
+	}
+
+	if config.PlaceholderServiceType != "Headless" {
 		return nil
-	} else if !errors.IsNotFound(err) {
-		return fmt.Errorf("failed to check existing service: %w", err)
 	}
 
-	// Create placeholder service
-	placeholderService := &corev1.Service{
+	endpoints := newPlaceholderEndpoints(sourceService, placeholderService)
+	if endpoints == nil {
+		ctrl.LoggerFrom(ctx).Info("Source service has no cluster IP, headless placeholder won't resolve", "service", sourceService.Name, "namespace", targetNamespace)
+		return nil
+	}
+	// Garbage collected together with the placeholder service
+	if err := ctrl.SetControllerReference(placeholderService, endpoints, r.Scheme); err != nil {
+		return fmt.Errorf("failed to set owner reference: %w", err)
+	}
+	if err := r.Create(ctx, endpoints); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create placeholder endpoints %s in namespace %s: %w", sourceService.Name, targetNamespace, err)
+	}
+	return nil
+}
+
+// newPlaceholderService builds the placeholder for a default namespace service in a developer namespace.
+// It is an ExternalName service by default, or a selectorless headless service with PlaceholderServiceType "Headless".
+func newPlaceholderService(sourceService *corev1.Service, targetNamespace string, config *config.OperatorConfig) *corev1.Service {
+	labels := map[string]string{
+		"app.kubernetes.io/managed-by": "Helm",
+	}
+	for key, value := range config.PlaceholderLabels {
+		labels[key] = value
+	}
+	// Always set last so configured labels can't break placeholder detection
+	labels[placeholderLabel] = "true"
+
+	placeholder := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      sourceService.Name,
 			Namespace: targetNamespace,
-			Labels: map[string]string{
-				"app.kubernetes.io/managed-by": "Helm",
-			},
+			Labels:    labels,
 			Annotations: map[string]string{
 				"virtualservice-operator/placeholder-service": "true",
-				"virtualservice-operator/source-service":      fmt.Sprintf("%s.%s.svc.cluster.local", sourceService.Name, config.DefaultNamespace),
+				"virtualservice-operator/source-service":      utils.ServiceFQDN(sourceService.Name, config.DefaultNamespace, config.ClusterDomain),
 				"meta.helm.sh/release-name":                   sourceService.Name,
 				"meta.helm.sh/release-namespace":              targetNamespace,
 			},
 		},
 		Spec: corev1.ServiceSpec{
 			Type:         corev1.ServiceTypeExternalName,
-			ExternalName: fmt.Sprintf("%s.%s.svc.cluster.local", sourceService.Name, config.DefaultNamespace),
+			ExternalName: utils.ServiceFQDN(sourceService.Name, config.DefaultNamespace, config.ClusterDomain),
 		},
 	}
 
-	if err := r.Create(ctx, placeholderService); err != nil {
-		return fmt.Errorf("failed to create placeholder service %s in namespace %s: %w", sourceService.Name, targetNamespace, err)
+	if config.PlaceholderServiceType == "Headless" {
+		placeholder.Spec = corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: corev1.ClusterIPNone,
+		}
+		for _, port := range sourceService.Spec.Ports {
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = corev1.ProtocolTCP
+			}
+			// Spell out the API server defaults so an unchanged placeholder compares equal
+			placeholder.Spec.Ports = append(placeholder.Spec.Ports, corev1.ServicePort{
+				Name:       port.Name,
+				Protocol:   protocol,
+				Port:       port.Port,
+				TargetPort: intstr.FromInt(int(port.Port)),
+			})
+		}
 	}
 
-	fmt.Printf("DEBUG: Successfully created placeholder service %s in namespace %s\n", sourceService.Name, targetNamespace)
-	return nil
+	return placeholder
+}
+
+// newPlaceholderEndpoints builds the Endpoints of a headless placeholder, resolving to the cluster IP of the source service.
+// It returns nil if the source service has no cluster IP to resolve to.
+func newPlaceholderEndpoints(sourceService, placeholder *corev1.Service) *corev1.Endpoints {
+	clusterIP := sourceService.Spec.ClusterIP
+	if clusterIP == "" || clusterIP == corev1.ClusterIPNone {
+		return nil
+	}
+
+	subset := corev1.EndpointSubset{
+		Addresses: []corev1.EndpointAddress{{IP: clusterIP}},
+	}
+	for _, port := range sourceService.Spec.Ports {
+		subset.Ports = append(subset.Ports, corev1.EndpointPort{
+			Name:     port.Name,
+			Protocol: port.Protocol,
+			Port:     port.Port,
+		})
+	}
+
+	return &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      placeholder.Name,
+			Namespace: placeholder.Namespace,
+			Labels:    map[string]string{placeholderLabel: "true"},
+		},
+		Subsets: []corev1.EndpointSubset{subset},
+	}
 }
 
 // ensurePlaceholderServicesForNamespace ensures all necessary placeholder services exist in a specific namespace
@@ -187,21 +485,25 @@ func (r *ServiceReconciler) ensurePlaceholderServicesForNamespace(ctx context.Co
 		return fmt.Errorf("failed to list services in default namespace: %w", err)
 	}
 
-	// For each service in default namespace, ensure a placeholder exists in the target namespace
+	// For each service in default namespace, ensure a placeholder exists in the target namespace.
+	// A failure doesn't stop the others from being created.
+	var errs []error
 	for _, defaultService := range serviceList.Items {
-		if r.isSystemService(defaultService.Name) {
+		if r.isSystemService(defaultService.Name) || !config.SelectsService(&defaultService) {
 			continue
 		}
 
 		err := r.createSinglePlaceholderService(ctx, &defaultService, targetNamespace, config)
 		if err != nil {
-			return fmt.Errorf("failed to create placeholder service %s: %w", defaultService.Name, err)
+			errs = append(errs, fmt.Errorf("failed to create placeholder service %s: %w", defaultService.Name, err))
 		}
 	}
 
-	return nil
+	return utilerrors.NewAggregate(errs)
 }
 
+// createPlaceholderServices ensures the placeholders of a default namespace service in every developer namespace.
+// A namespace that fails doesn't stop the others; the failures are returned as one aggregated error.
 func (r *ServiceReconciler) createPlaceholderServices(ctx context.Context, sourceService *corev1.Service, config *config.OperatorConfig) error {
 	log := ctrl.LoggerFrom(ctx)
 
@@ -212,6 +514,7 @@ func (r *ServiceReconciler) createPlaceholderServices(ctx context.Context, sourc
 
 	log.Info("Creating placeholder services", "sourceService", sourceService.Name, "sourceNamespace", sourceService.Namespace, "developerNamespaces", config.DeveloperNamespaces)
 
+	var errs []error
 	for _, devNamespace := range config.DeveloperNamespaces {
 		if devNamespace == config.DefaultNamespace {
 			log.V(1).Info("Skipping placeholder creation in same namespace as source", "namespace", devNamespace)
@@ -220,52 +523,14 @@ func (r *ServiceReconciler) createPlaceholderServices(ctx context.Context, sourc
 
 		log.Info("Checking for existing service", "serviceName", sourceService.Name, "namespace", devNamespace)
 
-		// Check if placeholder service already exists
-		existingService := &corev1.Service{}
-		err := r.Get(ctx, types.NamespacedName{Name: sourceService.Name, Namespace: devNamespace}, existingService)
-		if err == nil {
-			log.Info("Service already exists, skipping placeholder creation", "serviceName", sourceService.Name, "namespace", devNamespace, "serviceType", existingService.Spec.Type)
-			// Service already exists, don't modify it
-			continue
+		if err := r.ensurePlaceholderService(ctx, sourceService, devNamespace, config); err != nil {
+			log.Error(err, "Failed to ensure placeholder service", "serviceName", sourceService.Name, "namespace", devNamespace)
+			errs = append(errs, fmt.Errorf("namespace %s: %w", devNamespace, err))
 		}
-		if !errors.IsNotFound(err) {
-			log.Error(err, "Failed to check existing service", "serviceName", sourceService.Name, "namespace", devNamespace)
-			return fmt.Errorf("failed to check existing service %s in namespace %s: %w", sourceService.Name, devNamespace, err)
-		}
-
-		log.Info("No existing service found, creating placeholder", "serviceName", sourceService.Name, "namespace", devNamespace)
-
-		// Create placeholder service
-		placeholderService := &corev1.Service{
-			ObjectMeta: ctrl.ObjectMeta{
-				Name:      sourceService.Name,
-				Namespace: devNamespace,
-				Labels: map[string]string{
-					"app.kubernetes.io/managed-by": "Helm",
-				},
-				Annotations: map[string]string{
-					"virtualservice-operator/placeholder-service": "true",
-					"virtualservice-operator/source-service":      fmt.Sprintf("%s.%s.svc.cluster.local", sourceService.Name, config.DefaultNamespace),
-					"meta.helm.sh/release-name":                   sourceService.Name,
-					"meta.helm.sh/release-namespace":              devNamespace,
-				},
-			},
-			Spec: corev1.ServiceSpec{
-				Type:         corev1.ServiceTypeExternalName,
-				ExternalName: fmt.Sprintf("%s.%s.svc.cluster.local", sourceService.Name, config.DefaultNamespace),
-			},
-		}
-
-		if err := r.Create(ctx, placeholderService); err != nil {
-			log.Error(err, "Failed to create placeholder service", "serviceName", sourceService.Name, "namespace", devNamespace)
-			return fmt.Errorf("failed to create placeholder service %s in namespace %s: %w", sourceService.Name, devNamespace, err)
-		}
-
-		log.Info("Successfully created placeholder service", "serviceName", sourceService.Name, "namespace", devNamespace)
 	}
 
-	log.Info("Finished creating placeholder services", "sourceService", sourceService.Name)
-	return nil
+	log.Info("Finished creating placeholder services", "sourceService", sourceService.Name, "failed", len(errs))
+	return utilerrors.NewAggregate(errs)
 }
 
 // deletePlaceholderServices deletes placeholder services from all developer namespaces
@@ -290,7 +555,7 @@ func (r *ServiceReconciler) deletePlaceholderServices(ctx context.Context, servi
 		}
 
 		// Only delete if it's a placeholder service managed by us
-		if r.isPlaceholderService(service) {
+		if r.isPlaceholderService(ctx, service, config) {
 			if err := r.Delete(ctx, service); err != nil {
 				return fmt.Errorf("failed to delete placeholder service %s in namespace %s: %w", serviceName, devNamespace, err)
 			}
@@ -301,66 +566,353 @@ func (r *ServiceReconciler) deletePlaceholderServices(ctx context.Context, servi
 }
 
 // handleDefaultNamespaceService creates or updates VirtualService for services in the default namespace
-func (r *ServiceReconciler) handleDefaultNamespaceService(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) (ctrl.Result, error) {
+func (r *ServiceReconciler) handleDefaultNamespaceService(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) (_ ctrl.Result, retErr error) {
+	ctx, span := tracer.Start(ctx, "handleDefaultNamespaceService", trace.WithAttributes(
+		attribute.String("namespace", service.Namespace),
+		attribute.String("service", service.Name),
+	))
+	defer func() { endSpan(span, retErr) }()
+
 	// Skip system services
 	if r.isSystemService(service.Name) {
 		return ctrl.Result{}, nil
 	}
 
-	// Create placeholder services in developer namespaces if feature is enabled
+	service, err := r.applyServicePolicy(ctx, service, config)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The service may have stopped matching the selector, remove what was generated for it
+	if !config.SelectsService(service) {
+		ctrl.LoggerFrom(ctx).V(1).Info("Service not selected by serviceSelector", "service", service.Name, "namespace", service.Namespace)
+		return ctrl.Result{}, r.cleanupDefaultNamespaceService(ctx, service.Name, config)
+	}
+
+	// Create placeholder services in developer namespaces if feature is enabled. Failed placeholders
+	// don't hold up the VirtualService; the error is returned at the end so the service is requeued,
+	// and the retry leaves the placeholders and VirtualService that were already written untouched.
 	if err := r.createPlaceholderServices(ctx, service, config); err != nil {
-		return ctrl.Result{}, fmt.Errorf("failed to create placeholder services: %w", err)
+		span.SetAttributes(attribute.Bool("placeholderFailures", true))
+		defer func() {
+			retErr = utilerrors.NewAggregate([]error{retErr, fmt.Errorf("failed to create placeholder services: %w", err)})
+		}()
 	}
 
-	// Generate VirtualService with only default route initially
-	vs := utils.GenerateVirtualService(service, config.DefaultNamespace, config.DeveloperNamespaces)
+	// Let developer namespaces reach the service through their egress Sidecars
+	if err := r.reconcileSidecars(ctx, config); err != nil {
+		return ctrl.Result{}, err
+	}
 
-	// Set owner reference
-	if err := ctrl.SetControllerReference(service, vs, r.Scheme); err != nil {
-		return ctrl.Result{}, fmt.Errorf("failed to set owner reference: %w", err)
+	// Drop the service from a group it left
+	group := serviceGroup(service)
+	if err := r.reconcileFormerGroups(ctx, service.Name, group, config); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// A grouped service is routed by the group VirtualService instead of its own
+	if group != "" {
+		span.SetAttributes(attribute.String("action", "group"))
+		if err := r.deleteManagedVirtualService(ctx, fmt.Sprintf("%s-virtual-service", service.Name), config); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.reconcileGroup(ctx, group, config)
+	}
+
+	// Generate the complete VirtualService, default route and developer routes, so it is always
+	// written in a single update and never transiently loses developer routes
+	vs, err := r.desiredVirtualService(ctx, service, config)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Istio would reject the VirtualService on every reconcile, so warn once and skip instead
+	if problems := invalidVirtualServiceHosts(vs); len(problems) > 0 {
+		ctrl.LoggerFrom(ctx).Info("Skipping VirtualService with invalid hosts", "service", service.Name, "namespace", service.Namespace, "problems", problems)
+		r.recorder().Eventf(service, corev1.EventTypeWarning, "InvalidHost",
+			"VirtualService for the service would have invalid hosts: %s", strings.Join(problems, "; "))
+		return ctrl.Result{}, nil
 	}
 
 	// Check if VirtualService already exists
 	existingVS := &istionetworkingv1beta1.VirtualService{}
-	err := r.Get(ctx, types.NamespacedName{Name: vs.Name, Namespace: vs.Namespace}, existingVS)
+	err = r.Get(ctx, types.NamespacedName{Name: vs.Name, Namespace: vs.Namespace}, existingVS)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			// Create new VirtualService with default route only
+			span.SetAttributes(attribute.String("action", "create"))
+			utils.StampDeveloperRoutes(vs, r.clock().Now(), 0)
+			if err := r.dryRunVirtualService(ctx, vs, true); err != nil {
+				return ctrl.Result{}, err
+			}
 			if err := r.Create(ctx, vs); err != nil {
 				return ctrl.Result{}, err
 			}
-			// Now check for existing services in developer namespaces and add routes
-			return r.addExistingDeveloperRoutes(ctx, service, vs, config)
+			return ctrl.Result{}, r.setConflictAnnotation(ctx, service, "")
 		}
 		return ctrl.Result{}, err
 	}
 
+	// A VirtualService we created that lost its label, e.g. in a partial write, is still ours
+	if !utils.IsManagedByOperator(existingVS) && isOwnedByService(existingVS, service) {
+		if err := r.repairManagedByLabel(ctx, existingVS); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if !utils.IsManagedByOperator(existingVS) {
+		adopted, err := r.handleUnmanagedVirtualService(ctx, service, existingVS, config)
+		if err != nil || !adopted {
+			return ctrl.Result{}, err
+		}
+	}
+
 	// Update existing VirtualService if it's managed by us
 	if utils.IsManagedByOperator(existingVS) {
+		span.SetAttributes(attribute.String("action", "update"))
+		if err := r.setConflictAnnotation(ctx, service, ""); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// The desired VirtualService only routes to live developer services, so orphaned routes are
+		// dropped by the same update that writes everything else
+		orphaned, err := r.orphanedDeveloperRoutes(ctx, service, existingVS, config)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
 		// Use retry logic to update the VirtualService
-		err := r.retryVirtualServiceUpdate(ctx, existingVS, func(latest *istionetworkingv1beta1.VirtualService) error {
-			// Regenerate with only default route - copy fields individually to avoid mutex copy
+		err = r.retryVirtualServiceUpdate(ctx, existingVS, func(latest *istionetworkingv1beta1.VirtualService) error {
+			// Copy fields individually to avoid mutex copy
 			latest.Spec.Hosts = vs.Spec.Hosts
 			latest.Spec.Gateways = vs.Spec.Gateways
 			latest.Spec.Http = vs.Spec.Http
 			latest.Spec.Tls = vs.Spec.Tls
 			latest.Spec.Tcp = vs.Spec.Tcp
 			latest.Spec.ExportTo = vs.Spec.ExportTo
+			utils.SyncManagedAnnotations(latest, vs)
+			for _, devNamespace := range orphaned {
+				utils.ForgetRouteTimestamp(latest, devNamespace)
+			}
 			return nil
 		})
 		if err != nil {
 			return ctrl.Result{}, err
 		}
-		// Add routes for existing services in developer namespaces
-		return r.addExistingDeveloperRoutes(ctx, service, existingVS, config)
+		r.reportPrunedRoutes(ctx, service, existingVS, orphaned)
+		if config.MaxRouteAge.Duration <= 0 {
+			return ctrl.Result{}, nil
+		}
+
+		// Come back when the oldest developer route is due to be reaped
+		updated := &istionetworkingv1beta1.VirtualService{}
+		if err := r.Get(ctx, types.NamespacedName{Name: vs.Name, Namespace: vs.Namespace}, updated); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		return ctrl.Result{RequeueAfter: utils.NextRouteExpiry(updated, r.clock().Now(), config.MaxRouteAge.Duration)}, nil
 	}
 
 	return ctrl.Result{}, nil
 }
 
-// addExistingDeveloperRoutes checks each developer namespace for existing services and adds routes
-func (r *ServiceReconciler) addExistingDeveloperRoutes(ctx context.Context, service *corev1.Service, vs *istionetworkingv1beta1.VirtualService, config *config.OperatorConfig) (ctrl.Result, error) {
+// orphanedDeveloperRoutes returns the developer namespaces with a route or route timestamp in the
+// VirtualService whose backing service no longer exists or has been replaced by a placeholder.
+// Deletion events can be missed while the operator is down, so the routes in the VirtualService are
+// checked against the services that actually exist.
+func (r *ServiceReconciler) orphanedDeveloperRoutes(ctx context.Context, service *corev1.Service, vs *istionetworkingv1beta1.VirtualService, config *config.OperatorConfig) ([]string, error) {
+	// Routes removed for their age keep a timestamp until their developer service is gone
+	candidates := map[string]bool{}
+	for _, devNamespace := range routedDeveloperNamespaces(vs) {
+		candidates[devNamespace] = true
+	}
+	for devNamespace := range utils.RouteTimestamps(vs) {
+		candidates[devNamespace] = true
+	}
+
+	var orphaned []string
+	for _, devNamespace := range sortedKeys(candidates) {
+		live, err := r.hasLiveDeveloperService(ctx, service.Name, devNamespace, config)
+		if err != nil {
+			return nil, err
+		}
+		if !live {
+			orphaned = append(orphaned, devNamespace)
+		}
+	}
+	return orphaned, nil
+}
+
+// reportPrunedRoutes records the orphaned developer routes a VirtualService had before they were removed
+func (r *ServiceReconciler) reportPrunedRoutes(ctx context.Context, service *corev1.Service, previous *istionetworkingv1beta1.VirtualService, orphaned []string) {
+	routed := map[string]bool{}
+	for _, devNamespace := range routedDeveloperNamespaces(previous) {
+		routed[devNamespace] = true
+	}
+
+	for _, devNamespace := range orphaned {
+		if !routed[devNamespace] {
+			continue
+		}
+		ctrl.LoggerFrom(ctx).Info("Pruned developer route without a backing service", "service", service.Name, "developerNamespace", devNamespace)
+		r.recorder().Eventf(service, corev1.EventTypeNormal, "PrunedRoute",
+			"Removed route for developer namespace %s, the developer service no longer exists", devNamespace)
+	}
+}
+
+// routedDeveloperNamespaces returns the developer namespaces a VirtualService has routes for
+func routedDeveloperNamespaces(vs *istionetworkingv1beta1.VirtualService) []string {
+	var namespaces []string
+	for _, route := range vs.Spec.Http {
+		if devNamespace, ok := utils.DeveloperRouteNamespace(route); ok {
+			namespaces = append(namespaces, devNamespace)
+		}
+	}
+	return namespaces
+}
+
+// hasLiveDeveloperService checks if a real (non-placeholder) developer service exists for a route,
+// in the local cluster or, for remote developer namespaces, in the remote cluster
+func (r *ServiceReconciler) hasLiveDeveloperService(ctx context.Context, serviceName, devNamespace string, config *config.OperatorConfig) (bool, error) {
+	devService := &corev1.Service{}
+	err := r.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: devNamespace}, devService)
+	if err == nil {
+		return !r.isPlaceholderService(ctx, devService, config), nil
+	}
+	if !errors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to get developer service %s/%s: %w", devNamespace, serviceName, err)
+	}
+
+	if r.RemoteClient == nil {
+		return false, nil
+	}
+	for _, remoteNamespace := range config.RemoteDeveloperNamespaces {
+		if remoteNamespace != devNamespace {
+			continue
+		}
+		err := r.RemoteClient.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: devNamespace}, devService)
+		if err == nil {
+			return !r.isPlaceholderService(ctx, devService, config), nil
+		}
+		if !errors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to get service %s in remote namespace %s: %w", serviceName, devNamespace, err)
+		}
+	}
+	return false, nil
+}
+
+// handleUnmanagedVirtualService applies the configured policy to a VirtualService that has the name
+// the operator would use but isn't managed by it. It returns true if the VirtualService was adopted
+// and should be updated like any other managed VirtualService.
+func (r *ServiceReconciler) handleUnmanagedVirtualService(ctx context.Context, service *corev1.Service, existingVS *istionetworkingv1beta1.VirtualService, config *config.OperatorConfig) (bool, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	switch config.UnmanagedVirtualServicePolicy {
+	case "ignore":
+		log.V(1).Info("Ignoring VirtualService not managed by the operator", "virtualService", existingVS.Name, "namespace", existingVS.Namespace)
+		return false, nil
+
+	case "adopt":
+		err := r.retryVirtualServiceUpdate(ctx, existingVS, func(latest *istionetworkingv1beta1.VirtualService) error {
+			if latest.Labels == nil {
+				latest.Labels = map[string]string{}
+			}
+			latest.Labels[utils.ManagedByLabel] = utils.OperatorName
+			return ctrl.SetControllerReference(service, latest, r.Scheme)
+		})
+		if err != nil {
+			// Most likely owned by another controller, don't fight over it
+			r.recorder().Eventf(service, corev1.EventTypeWarning, "AdoptionFailed",
+				"Failed to adopt VirtualService %s: %v", existingVS.Name, err)
+			return false, r.setConflictAnnotation(ctx, service, fmt.Sprintf("failed to adopt VirtualService %s: %v", existingVS.Name, err))
+		}
+		log.Info("Adopted VirtualService not previously managed by the operator", "virtualService", existingVS.Name, "namespace", existingVS.Namespace)
+		r.recorder().Eventf(service, corev1.EventTypeNormal, "Adopted", "Adopted existing VirtualService %s", existingVS.Name)
+
+		// Mark our copy as managed so the caller proceeds with the regular update
+		if existingVS.Labels == nil {
+			existingVS.Labels = map[string]string{}
+		}
+		existingVS.Labels[utils.ManagedByLabel] = utils.OperatorName
+		return true, nil
+
+	default: // "warn"
+		message := fmt.Sprintf("VirtualService %s already exists and is not managed by the operator", existingVS.Name)
+		log.Info("Found VirtualService not managed by the operator", "virtualService", existingVS.Name, "namespace", existingVS.Namespace)
+		if getAnnotation(service, conflictAnnotation) != message {
+			r.recorder().Event(service, corev1.EventTypeWarning, "UnmanagedVirtualService", message)
+		}
+		return false, r.setConflictAnnotation(ctx, service, message)
+	}
+}
+
+// isOwnedByService checks if an object has an owner reference to the given service
+func isOwnedByService(obj metav1.Object, service *corev1.Service) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.APIVersion == "v1" && ref.Kind == "Service" && ref.Name == service.Name && ref.UID == service.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// repairManagedByLabel re-applies the managed-by label to a VirtualService the operator created,
+// so it is recognized as managed again instead of being treated as a conflicting VirtualService
+func (r *ServiceReconciler) repairManagedByLabel(ctx context.Context, vs *istionetworkingv1beta1.VirtualService) error {
+	err := r.retryVirtualServiceUpdate(ctx, vs, func(latest *istionetworkingv1beta1.VirtualService) error {
+		if latest.Labels == nil {
+			latest.Labels = map[string]string{}
+		}
+		latest.Labels[utils.ManagedByLabel] = utils.OperatorName
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to repair managed-by label on VirtualService %s/%s: %w", vs.Namespace, vs.Name, err)
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Repaired missing managed-by label on owned VirtualService", "virtualService", vs.Name, "namespace", vs.Namespace)
+	if vs.Labels == nil {
+		vs.Labels = map[string]string{}
+	}
+	vs.Labels[utils.ManagedByLabel] = utils.OperatorName
+	return nil
+}
+
+// setConflictAnnotation records why the operator couldn't manage the VirtualService of a service.
+// An empty message removes the annotation once the conflict is resolved.
+func (r *ServiceReconciler) setConflictAnnotation(ctx context.Context, service *corev1.Service, message string) error {
+	if getAnnotation(service, conflictAnnotation) == message {
+		return nil
+	}
+
+	original := service.DeepCopy()
+	if message == "" {
+		removeAnnotation(service, conflictAnnotation)
+	} else {
+		setAnnotation(service, conflictAnnotation, message)
+	}
+
+	if err := r.Patch(ctx, service, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to update conflict annotation on service %s/%s: %w", service.Namespace, service.Name, err)
+	}
+	return nil
+}
+
+// collectDeveloperRoutes finds the developer namespaces, local and remote, with a real developer service
+// for a default namespace service and returns them with the route options of each developer service
+func (r *ServiceReconciler) collectDeveloperRoutes(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) (_ []string, _ map[string]utils.RouteOptions, retErr error) {
+	ctx, span := tracer.Start(ctx, "collectDeveloperRoutes", trace.WithAttributes(
+		attribute.String("namespace", service.Namespace),
+		attribute.String("service", service.Name),
+	))
+	defer func() { endSpan(span, retErr) }()
+
 	var namespacesToAdd []string
+	routeOptions := map[string]utils.RouteOptions{}
+
+	if devRoutesDisabled(service) {
+		ctrl.LoggerFrom(ctx).Info("Developer routes disabled by annotation", "service", service.Name, "namespace", service.Namespace)
+		span.SetAttributes(attribute.Bool("devRoutesDisabled", true))
+		return nil, routeOptions, nil
+	}
 
 	for _, devNamespace := range config.DeveloperNamespaces {
 		if devNamespace == config.DefaultNamespace {
@@ -375,46 +927,121 @@ func (r *ServiceReconciler) addExistingDeveloperRoutes(ctx context.Context, serv
 				// Service doesn't exist in this developer namespace, skip
 				continue
 			}
-			return ctrl.Result{}, err
+			return nil, nil, err
 		}
 
 		// Skip placeholder services - they should not have VirtualService routes
-		if r.isPlaceholderService(devService) {
-			fmt.Printf("DEBUG: Skipping route addition for placeholder service %s/%s\n", devService.Namespace, devService.Name)
+		if r.isPlaceholderService(ctx, devService, config) {
+			ctrl.LoggerFrom(ctx).V(1).Info("Skipping route for placeholder service", "service", devService.Name, "namespace", devService.Namespace)
 			continue
 		}
 
-		fmt.Printf("DEBUG: Adding route for real service %s/%s\n", devService.Namespace, devService.Name)
+		withheld, err := r.withholdsRoute(ctx, devService, config)
+		if err != nil {
+			return nil, nil, err
+		}
+		if withheld {
+			continue
+		}
+
+		ctrl.LoggerFrom(ctx).V(1).Info("Adding route for developer service", "service", devService.Name, "namespace", devService.Namespace)
 
 		// Service exists and is not a placeholder, add to list of namespaces to add routes for
 		namespacesToAdd = append(namespacesToAdd, devNamespace)
+		routeOptions[devNamespace] = r.developerRouteOptions(ctx, devService, config)
 	}
 
-	// Update VirtualService if we have routes to add
-	if len(namespacesToAdd) > 0 {
-		err := r.retryVirtualServiceUpdate(ctx, vs, func(latest *istionetworkingv1beta1.VirtualService) error {
-			for _, devNamespace := range namespacesToAdd {
-				utils.UpdateVirtualServiceRoutes(latest, service.Name, devNamespace)
-			}
-			return nil
-		})
+	remoteNamespaces, err := r.discoverRemoteDeveloperServices(ctx, service, config, routeOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	namespacesToAdd = append(namespacesToAdd, remoteNamespaces...)
+
+	// The timeout of the default namespace service applies to its developer routes too
+	timeout := r.routeTimeout(ctx, service, config)
+	for namespace, opts := range routeOptions {
+		opts.Timeout = timeout
+		routeOptions[namespace] = opts
+	}
+	span.SetAttributes(attribute.StringSlice("developerNamespaces", namespacesToAdd))
+
+	return namespacesToAdd, routeOptions, nil
+}
+
+// desiredVirtualService computes the complete VirtualService for a default namespace service from the
+// current cluster state: the default route plus a route for every developer service, without writing anything
+func (r *ServiceReconciler) desiredVirtualService(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) (*istionetworkingv1beta1.VirtualService, error) {
+	vs := utils.GenerateVirtualService(service, config.DefaultNamespace, config.DeveloperNamespaces, r.defaultRouteOptions(ctx, service, config))
+	if err := ctrl.SetControllerReference(service, vs, r.Scheme); err != nil {
+		return nil, fmt.Errorf("failed to set owner reference: %w", err)
+	}
+
+	namespaces, routeOptions, err := r.collectDeveloperRoutes(ctx, service, config)
+	if err != nil {
+		return nil, err
+	}
+	for _, devNamespace := range namespaces {
+		utils.UpdateVirtualServiceRoutes(vs, service.Name, devNamespace, routeOptions[devNamespace])
+	}
+	r.defaultRouteDiversion(ctx, service, config)(vs)
+
+	return vs, nil
+}
+
+// discoverRemoteDeveloperServices looks up developer services in the remote cluster and adds their
+// route options to routeOptions. Namespaces that already have a local developer service are skipped.
+// The remote cluster isn't watched, so remote changes are picked up on the next reconcile of the default service.
+func (r *ServiceReconciler) discoverRemoteDeveloperServices(ctx context.Context, service *corev1.Service, config *config.OperatorConfig, routeOptions map[string]utils.RouteOptions) ([]string, error) {
+	if r.RemoteClient == nil {
+		return nil, nil
+	}
+
+	log := ctrl.LoggerFrom(ctx)
+	var namespaces []string
+
+	for _, devNamespace := range config.RemoteDeveloperNamespaces {
+		if devNamespace == config.DefaultNamespace {
+			continue
+		}
+		if _, local := routeOptions[devNamespace]; local {
+			continue // Local developer service takes precedence
+		}
+
+		devService := &corev1.Service{}
+		err := r.RemoteClient.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: devNamespace}, devService)
 		if err != nil {
-			return ctrl.Result{}, err
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get service %s in remote namespace %s: %w", service.Name, devNamespace, err)
 		}
+
+		if r.isPlaceholderService(ctx, devService, config) {
+			continue
+		}
+
+		log.Info("Adding route for remote developer service", "service", devService.Name, "namespace", devNamespace)
+
+		opts := r.developerRouteOptions(ctx, devService, config)
+		opts.ClusterDomain = config.RemoteClusterDomain
+		routeOptions[devNamespace] = opts
+		namespaces = append(namespaces, devNamespace)
 	}
 
-	return ctrl.Result{}, nil
+	return namespaces, nil
 }
 
 // handleDeveloperNamespaceService updates existing VirtualService for services in developer namespaces
 func (r *ServiceReconciler) handleDeveloperNamespaceService(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
 	// Skip placeholder services - they should not have VirtualService routes
-	if r.isPlaceholderService(service) {
-		fmt.Printf("DEBUG: Skipping VirtualService route creation for placeholder service %s/%s\n", service.Namespace, service.Name)
+	if r.isPlaceholderService(ctx, service, config) {
+		ctrl.LoggerFrom(ctx).V(1).Info("Skipping route for placeholder service", "service", service.Name, "namespace", service.Namespace)
 		return ctrl.Result{}, nil
 	}
 
-	fmt.Printf("DEBUG: Processing developer namespace service %s/%s for VirtualService routes\n", service.Namespace, service.Name)
+	ctrl.LoggerFrom(ctx).V(1).Info("Processing developer namespace service", "service", service.Name, "namespace", service.Namespace)
 
 	// First, check if a service with the same name exists in the default namespace
 	defaultService := &corev1.Service{}
@@ -425,6 +1052,10 @@ func (r *ServiceReconciler) handleDeveloperNamespaceService(ctx context.Context,
 			// But we should check if there are other services in other developer namespaces
 			// that might need placeholder services created for this namespace
 			if config.EnablePlaceholderServices {
+				// Spread namespace-wide backfills over the configured window to avoid bursts on the API server
+				if delay := r.backfill.next(service.Namespace, config.PlaceholderBackfillWindow.Duration, r.clock().Now()); delay > 0 {
+					return ctrl.Result{RequeueAfter: delay}, nil
+				}
 				err := r.ensurePlaceholderServicesForNamespace(ctx, service.Namespace, config)
 				if err != nil {
 					return ctrl.Result{}, fmt.Errorf("failed to ensure placeholder services: %w", err)
@@ -434,6 +1065,17 @@ func (r *ServiceReconciler) handleDeveloperNamespaceService(ctx context.Context,
 		}
 		return ctrl.Result{}, err
 	}
+	if defaultService, err = r.applyServicePolicy(ctx, defaultService, config); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Grouped services share the group VirtualService, which is regenerated as a whole
+	if group := serviceGroup(defaultService); group != "" {
+		if err := r.reconcileDestinationRule(ctx, service, config); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.reconcileGroup(ctx, group, config)
+	}
 
 	// Find the corresponding VirtualService in the default namespace
 	// VirtualService name follows the pattern: serviceName + "-virtual-service"
@@ -449,45 +1091,237 @@ func (r *ServiceReconciler) handleDeveloperNamespaceService(ctx context.Context,
 	}
 
 	// Update the VirtualService with new route for this developer namespace
-	if utils.IsManagedByOperator(existingVS) {
-		err := r.retryVirtualServiceUpdate(ctx, existingVS, func(latest *istionetworkingv1beta1.VirtualService) error {
-			utils.UpdateVirtualServiceRoutes(latest, service.Name, service.Namespace)
+	if utils.IsManagedByOperator(existingVS) && devRoutesDisabled(defaultService) {
+		log.Info("Developer routes disabled by annotation, not adding route", "service", service.Name, "namespace", service.Namespace)
+		return ctrl.Result{}, r.retryVirtualServiceUpdate(ctx, existingVS, func(latest *istionetworkingv1beta1.VirtualService) error {
+			utils.RemoveAllDeveloperRoutes(latest)
+			utils.SetDefaultRouteNamespace(latest, service.Name, config.DefaultNamespace, config.ClusterDomain)
 			return nil
 		})
-		return ctrl.Result{}, err
 	}
+	if utils.IsManagedByOperator(existingVS) {
+		divert := r.defaultRouteDiversion(ctx, defaultService, config)
 
-	return ctrl.Result{}, nil
-}
+		withheld, err := r.withholdsRoute(ctx, service, config)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if withheld {
+			// Remove a route that was added while the service still had endpoints
+			err := r.retryVirtualServiceUpdate(ctx, existingVS, func(latest *istionetworkingv1beta1.VirtualService) error {
+				utils.RemoveDeveloperRoutes(latest, service.Namespace)
+				if utils.DefaultRouteNamespace(latest, service.Name) == service.Namespace {
+					utils.SetDefaultRouteNamespace(latest, service.Name, config.DefaultNamespace, config.ClusterDomain)
+				}
+				divert(latest)
+				return nil
+			})
+			return ctrl.Result{RequeueAfter: endpointsRecheckInterval}, err
+		}
 
-// handleServiceDeletion handles cleanup when a service is deleted
-func (r *ServiceReconciler) handleServiceDeletion(ctx context.Context, serviceName, namespace string, config *config.OperatorConfig) (ctrl.Result, error) {
-	if namespace == config.DefaultNamespace {
-		// Delete the VirtualService when the main service is deleted
-		// VirtualService name follows the pattern: serviceName + "-virtual-service"
-		vsName := fmt.Sprintf("%s-virtual-service", serviceName)
-		vs := &istionetworkingv1beta1.VirtualService{}
-		err := r.Get(ctx, types.NamespacedName{Name: vsName, Namespace: namespace}, vs)
+		// Declare the pinned subset before the route starts referencing it
+		if err := r.reconcileDestinationRule(ctx, service, config); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		opts := r.developerRouteOptions(ctx, service, config)
+		opts.Timeout = r.routeTimeout(ctx, defaultService, config)
+
+		// Advance a progressive rollout if the developer service requests one
+		now := r.clock().Now()
+		step, err := nextRolloutStep(service, now)
 		if err != nil {
-			if errors.IsNotFound(err) {
-				return ctrl.Result{}, nil
+			log.Error(err, "Ignoring invalid rollout annotation", "service", service.Name, "namespace", service.Namespace)
+		}
+		if step != nil {
+			opts.Weight = &step.Weight
+		}
+
+		// Switch the default route over once the designated namespace has a live service.
+		// Designation problems are reported by the default namespace service reconcile.
+		defaultRouteNamespace, _ := r.designatedDefaultRouteNamespace(ctx, defaultService, config)
+
+		err = r.retryVirtualServiceUpdate(ctx, existingVS, func(latest *istionetworkingv1beta1.VirtualService) error {
+			utils.UpdateVirtualServiceRoutes(latest, service.Name, service.Namespace, opts)
+			if defaultRouteNamespace == service.Namespace {
+				utils.SetDefaultRouteNamespace(latest, service.Name, service.Namespace, config.ClusterDomain)
 			}
+			divert(latest)
+			return nil
+		})
+		if err != nil {
 			return ctrl.Result{}, err
 		}
+		if step == nil {
+			return ctrl.Result{}, r.clearRolloutProgress(ctx, service)
+		}
 
-		if utils.IsManagedByOperator(vs) {
-			if err := r.Delete(ctx, vs); err != nil {
+		// Record progress only once the route carries the new weight
+		if step.Advanced {
+			if err := r.persistRolloutStep(ctx, service, step, now); err != nil {
 				return ctrl.Result{}, err
 			}
 		}
+		return ctrl.Result{RequeueAfter: step.RequeueAfter}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// developerRouteOptions derives the route options for a developer service from its annotations
+func (r *ServiceReconciler) developerRouteOptions(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) utils.RouteOptions {
+	weight := currentRolloutWeight(service)
+	if !hasAnnotation(service, rolloutAnnotation) {
+		weight = r.matchPercentage(ctx, service)
+	}
+	return utils.RouteOptions{
+		Weight:               weight,
+		ClusterDomain:        config.ClusterDomain,
+		LocalClusterDomain:   config.ClusterDomain,
+		Subset:               r.developerSubset(ctx, service, config),
+		Headers:              r.routeHeaders(ctx, service),
+		RewriteAuthority:     config.RoutingStrategy == "authority",
+		MatchSourceNamespace: config.RoutingStrategy == "source",
+		HeaderPrefix:         config.DevHeaderPrefix,
+		HeaderSuffix:         config.DevHeaderSuffix,
+		FallbackHeader:       config.FallbackHeader,
+	}
+}
+
+// defaultRouteOptions derives the options for the default route from the annotations of the default namespace service
+func (r *ServiceReconciler) defaultRouteOptions(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) utils.RouteOptions {
+	defaultRouteNamespace, err := r.designatedDefaultRouteNamespace(ctx, service, config)
+	if devRoutesDisabled(service) {
+		defaultRouteNamespace = "" // Everything goes to the default namespace
+	}
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Problem with default route namespace designation", "service", service.Name, "namespace", service.Namespace)
+		r.recorder().Eventf(service, corev1.EventTypeWarning, "InvalidDefaultRouteNamespace", "%v", err)
+	}
+
+	gateways, err := serviceGateways(service, config)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Ignoring invalid gateways annotation", "service", service.Name, "namespace", service.Namespace)
+		r.recorder().Eventf(service, corev1.EventTypeWarning, "InvalidAnnotation", "Ignoring gateways override: %v", err)
+	}
+
+	opts := utils.RouteOptions{
+		Headers:               r.routeHeaders(ctx, service),
+		Timeout:               r.routeTimeout(ctx, service, config),
+		Gateways:              gateways,
+		Annotations:           virtualServiceAnnotations(service, config),
+		DefaultRouteNamespace: defaultRouteNamespace,
+		LocalClusterDomain:    config.ClusterDomain,
+	}
+	if config.UseFQDNHosts {
+		opts.HostDomain = config.ClusterDomain
+	}
+	return opts
+}
+
+// virtualServiceAnnotations returns the annotations for the VirtualService of a service: the configured
+// static annotations plus the service's own annotations matching a passthrough prefix, which win on conflict
+func virtualServiceAnnotations(service *corev1.Service, config *config.OperatorConfig) map[string]string {
+	annotations := map[string]string{}
+	for key, value := range config.VirtualServiceAnnotations {
+		annotations[key] = value
+	}
+	for key, value := range service.Annotations {
+		for _, prefix := range config.AnnotationPassthroughPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				annotations[key] = value
+				break
+			}
+		}
+	}
+	return annotations
+}
+
+// designatedDefaultRouteNamespace returns the developer namespace the default route of a service should
+// point at, as designated by the default-route-namespace annotation on the service or, failing that, on
+// its namespace. The designation only takes effect while the namespace has a live developer service.
+// An error describes an invalid or conflicting designation; a usable namespace may still be returned.
+func (r *ServiceReconciler) designatedDefaultRouteNamespace(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) (string, error) {
+	designated := getAnnotation(service, defaultRouteNamespaceAnnotation)
+
+	var conflict error
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: service.Namespace}, namespace); err != nil {
+		if !errors.IsNotFound(err) {
+			return "", fmt.Errorf("failed to get namespace %s: %w", service.Namespace, err)
+		}
+	} else if namespaceDesignated := getAnnotation(namespace, defaultRouteNamespaceAnnotation); namespaceDesignated != "" {
+		if designated == "" {
+			designated = namespaceDesignated
+		} else if designated != namespaceDesignated {
+			conflict = fmt.Errorf("service designates default route namespace %q but namespace %s designates %q, using the service's",
+				designated, service.Namespace, namespaceDesignated)
+		}
+	}
+
+	if designated == "" {
+		return "", conflict
+	}
+
+	if strings.Contains(designated, ",") {
+		return "", fmt.Errorf("only one default route namespace may be designated, got %q", designated)
+	}
+	if !isDeveloperNamespace(designated, config) {
+		return "", fmt.Errorf("default route namespace %q is not a developer namespace", designated)
+	}
+
+	live, err := r.hasLiveDeveloperService(ctx, service.Name, designated, config)
+	if err != nil {
+		return "", err
+	}
+	if !live {
+		ctrl.LoggerFrom(ctx).V(1).Info("Designated default route namespace has no developer service, keeping the default namespace",
+			"service", service.Name, "defaultRouteNamespace", designated)
+		return "", conflict
+	}
+
+	return designated, conflict
+}
+
+// isDeveloperNamespace checks if a namespace is one of the configured local or remote developer namespaces
+func isDeveloperNamespace(namespace string, config *config.OperatorConfig) bool {
+	if namespace == config.DefaultNamespace {
+		return false
+	}
+	for _, ns := range config.DeveloperNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	for _, ns := range config.RemoteDeveloperNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
 
-		// Delete placeholder services in developer namespaces if feature is enabled
-		if err := r.deletePlaceholderServices(ctx, serviceName, config); err != nil {
-			return ctrl.Result{}, fmt.Errorf("failed to delete placeholder services: %w", err)
+// routeHeaders parses the header manipulation annotations of a service.
+// Invalid annotations are reported and ignored so they don't block routing.
+func (r *ServiceReconciler) routeHeaders(ctx context.Context, service *corev1.Service) *istiov1beta1.Headers {
+	headers, err := headersFromAnnotations(service)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Ignoring invalid header annotation", "service", service.Name, "namespace", service.Namespace)
+		r.recorder().Eventf(service, corev1.EventTypeWarning, "InvalidAnnotation", "Ignoring header manipulation: %v", err)
+		return nil
+	}
+	return headers
+}
+
+// handleServiceDeletion handles cleanup when a service is deleted
+func (r *ServiceReconciler) handleServiceDeletion(ctx context.Context, serviceName, namespace string, config *config.OperatorConfig) (ctrl.Result, error) {
+	if namespace == config.DefaultNamespace {
+		if err := r.cleanupDefaultNamespaceService(ctx, serviceName, config); err != nil {
+			return ctrl.Result{}, err
 		}
 	} else {
 		// Handle deletion in developer namespace
-		fmt.Printf("DEBUG: Real service %s deleted from developer namespace %s. Always removing route from VirtualService.\n", serviceName, namespace)
+		ctrl.LoggerFrom(ctx).V(1).Info("Developer service deleted, removing its route", "service", serviceName, "namespace", namespace)
 
 		// ALWAYS remove the route from VirtualService when a real service is deleted
 		// Placeholder services should NEVER have routes in VirtualService
@@ -496,30 +1330,48 @@ func (r *ServiceReconciler) handleServiceDeletion(ctx context.Context, serviceNa
 		err := r.Get(ctx, types.NamespacedName{Name: vsName, Namespace: config.DefaultNamespace}, vs)
 		if err != nil {
 			if errors.IsNotFound(err) {
-				fmt.Printf("DEBUG: VirtualService %s not found, nothing to update.\n", vsName)
+				ctrl.LoggerFrom(ctx).V(1).Info("VirtualService not found, nothing to update", "virtualService", vsName)
 			} else {
 				return ctrl.Result{}, err
 			}
 		} else {
 			if utils.IsManagedByOperator(vs) {
-				fmt.Printf("DEBUG: Removing route for namespace %s from VirtualService %s.\n", namespace, vsName)
-				// Use retry logic to remove routes for this developer namespace
+				ctrl.LoggerFrom(ctx).V(1).Info("Removing developer route", "virtualService", vsName, "developerNamespace", namespace)
+
+				// Re-spread diverted default traffic over the remaining developer routes
+				divert := func(*istionetworkingv1beta1.VirtualService) {}
+				owner := &corev1.Service{}
+				if err := r.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: config.DefaultNamespace}, owner); err == nil {
+					if owner, err = r.applyServicePolicy(ctx, owner, config); err != nil {
+						return ctrl.Result{}, err
+					}
+					divert = r.defaultRouteDiversion(ctx, owner, config)
+				}
+
+				// Use retry logic to remove routes for this developer namespace. Every attempt works on the freshly
+				// read VirtualService and only touches this namespace's routes, so routes that other reconciles add
+				// or remove concurrently, e.g. for a developer service deleted in another namespace at the same
+				// time, survive a conflict retry unchanged.
 				err := r.retryVirtualServiceUpdate(ctx, vs, func(latest *istionetworkingv1beta1.VirtualService) error {
-					var newRoutes []*istiov1beta1.HTTPRoute
-					routesRemoved := 0
-					for _, route := range latest.Spec.Http {
-						if len(route.Match) > 0 && route.Match[0].Headers != nil {
-							if headerMatch, exists := route.Match[0].Headers["x-developer"]; exists {
-								if exact := headerMatch.GetExact(); exact == namespace {
-									routesRemoved++
-									continue // Skip this route - REMOVE IT
-								}
-							}
-						}
-						newRoutes = append(newRoutes, route)
+					// The service may have been created again since the delete event, its route then stays
+					live, err := r.hasLiveDeveloperService(ctx, serviceName, namespace, config)
+					if err != nil {
+						return err
+					}
+					if live {
+						ctrl.LoggerFrom(ctx).V(1).Info("Developer service is back, keeping its route", "service", serviceName, "namespace", namespace)
+						return nil
+					}
+
+					routesRemoved := utils.RemoveDeveloperRoutes(latest, namespace)
+					utils.ForgetRouteTimestamp(latest, namespace)
+					ctrl.LoggerFrom(ctx).V(1).Info("Removed developer routes", "virtualService", vsName, "developerNamespace", namespace, "routes", routesRemoved)
+
+					// The namespace was the default route target, fall back to the default namespace
+					if utils.DefaultRouteNamespace(latest, serviceName) == namespace {
+						utils.SetDefaultRouteNamespace(latest, serviceName, config.DefaultNamespace, config.ClusterDomain)
 					}
-					latest.Spec.Http = newRoutes
-					fmt.Printf("DEBUG: Removed %d routes for namespace %s from VirtualService.\n", routesRemoved, namespace)
+					divert(latest)
 					return nil
 				})
 				if err != nil {
@@ -532,29 +1384,90 @@ func (r *ServiceReconciler) handleServiceDeletion(ctx context.Context, serviceNa
 		// This is independent of route management - placeholder services don't get routes
 		defaultService := &corev1.Service{}
 		err = r.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: config.DefaultNamespace}, defaultService)
-		if err == nil {
+		if err == nil && config.SelectsService(defaultService) {
 			// Service exists in default namespace, so we should recreate the placeholder service
 			// if placeholder services are enabled
 			if config.EnablePlaceholderServices {
-				fmt.Printf("DEBUG: Service %s exists in default namespace. Creating placeholder service in %s (no route will be added).\n", serviceName, namespace)
+				ctrl.LoggerFrom(ctx).V(1).Info("Service exists in default namespace, recreating its placeholder", "service", serviceName, "namespace", namespace)
 
 				// Create a single placeholder service for this namespace
 				err := r.createSinglePlaceholderService(ctx, defaultService, namespace, config)
 				if err != nil {
 					return ctrl.Result{}, fmt.Errorf("failed to recreate placeholder service: %w", err)
 				}
-				fmt.Printf("DEBUG: Placeholder service created for %s in namespace %s. Placeholder services do NOT get VirtualService routes.\n", serviceName, namespace)
+				ctrl.LoggerFrom(ctx).V(1).Info("Recreated placeholder service, it gets no route", "service", serviceName, "namespace", namespace)
 			}
 		} else if !errors.IsNotFound(err) {
 			return ctrl.Result{}, err
 		}
+
+		// Drop the developer route from the group VirtualService
+		if err == nil && serviceGroup(defaultService) != "" {
+			if err := r.reconcileGroup(ctx, serviceGroup(defaultService), config); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
 	}
 
 	return ctrl.Result{}, nil
 }
 
+// cleanupDefaultNamespaceService deletes the managed VirtualService and the placeholder services
+// generated for a default namespace service
+func (r *ServiceReconciler) cleanupDefaultNamespaceService(ctx context.Context, serviceName string, config *config.OperatorConfig) error {
+	// VirtualService name follows the pattern: serviceName + "-virtual-service"
+	if err := r.deleteManagedVirtualService(ctx, fmt.Sprintf("%s-virtual-service", serviceName), config); err != nil {
+		return err
+	}
+
+	// Drop the service from the group it belonged to
+	if err := r.reconcileFormerGroups(ctx, serviceName, "", config); err != nil {
+		return err
+	}
+
+	// Delete placeholder services in developer namespaces if feature is enabled
+	if err := r.deletePlaceholderServices(ctx, serviceName, config); err != nil {
+		return fmt.Errorf("failed to delete placeholder services: %w", err)
+	}
+
+	// Drop the service from the egress Sidecars
+	return r.reconcileSidecars(ctx, config)
+}
+
+// deleteManagedVirtualService deletes a VirtualService in the default namespace if the operator manages it
+func (r *ServiceReconciler) deleteManagedVirtualService(ctx context.Context, vsName string, config *config.OperatorConfig) error {
+	vs := &istionetworkingv1beta1.VirtualService{}
+	err := r.Get(ctx, types.NamespacedName{Name: vsName, Namespace: config.DefaultNamespace}, vs)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if utils.IsManagedByOperator(vs) {
+		if err := r.Delete(ctx, vs); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
 // retryVirtualServiceUpdate performs a VirtualService update with retry logic and conflict resolution
-func (r *ServiceReconciler) retryVirtualServiceUpdate(ctx context.Context, vs *istionetworkingv1beta1.VirtualService, updateFunc func(*istionetworkingv1beta1.VirtualService) error) error {
+func (r *ServiceReconciler) retryVirtualServiceUpdate(ctx context.Context, vs *istionetworkingv1beta1.VirtualService, updateFunc func(*istionetworkingv1beta1.VirtualService) error) (retErr error) {
+	ctx, span := tracer.Start(ctx, "retryVirtualServiceUpdate", trace.WithAttributes(
+		attribute.String("namespace", vs.Namespace),
+		attribute.String("virtualService", vs.Name),
+	))
+	attempts := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("retries", attempts-1))
+		endSpan(span, retErr)
+	}()
+
+	operatorConfig, err := r.ConfigManager.GetConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get operator config: %w", err)
+	}
+	maxRouteAge := operatorConfig.MaxRouteAge.Duration
+
 	backoff := wait.Backoff{
 		Steps:    5,
 		Duration: 100 * time.Millisecond,
@@ -562,54 +1475,250 @@ func (r *ServiceReconciler) retryVirtualServiceUpdate(ctx context.Context, vs *i
 		Jitter:   0.1,
 	}
 
-	return wait.ExponentialBackoff(backoff, func() (bool, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	// The error of the last failed attempt, returned in place of the backoff's timeout once the attempts
+	// are exhausted, so the real cause, e.g. a persistent conflict or an outage, is visible
+	var lastErr error
+
+	// attempt applies the update to the latest version once, it reports false to be retried
+	attempt := func(ctx context.Context) (bool, error) {
+
 		// Get the latest version of the VirtualService
 		latest := &istionetworkingv1beta1.VirtualService{}
 		err := r.Get(ctx, types.NamespacedName{Name: vs.Name, Namespace: vs.Namespace}, latest)
 		if err != nil {
-			if errors.IsNotFound(err) {
-				return false, err // Don't retry if resource is deleted
+			if !isRetryableError(err) {
+				return false, err // Don't retry if resource is deleted or can't be read at all
 			}
-			return false, nil // Retry on other errors
+			lastErr = fmt.Errorf("failed to get VirtualService: %w", err)
+			return false, nil
 		}
 
 		// Apply the update function to the latest version
+		before := latest.DeepCopy()
 		if err := updateFunc(latest); err != nil {
 			return false, err // Don't retry on update function errors
 		}
 
+		// Stamp new developer routes and reap the ones past maxRouteAge, whichever update added them
+		if reaped := utils.StampDeveloperRoutes(latest, r.clock().Now(), maxRouteAge); len(reaped) > 0 {
+			log.Info("Removing developer routes older than maxRouteAge", "virtualService", latest.Name, "namespace", latest.Namespace,
+				"developerNamespaces", reaped, "maxRouteAge", maxRouteAge)
+		}
+
+		// Skip the API call when the update function didn't change anything
+		changes := utils.DiffVirtualService(latest, before)
+		if len(changes) == 0 {
+			log.V(1).Info("VirtualService already up to date, skipping update", "virtualService", latest.Name, "namespace", latest.Namespace)
+			span.SetAttributes(attribute.String("action", "skip"))
+			return true, nil
+		}
+		span.SetAttributes(attribute.String("action", "update"))
+		log.Info("Updating VirtualService", "virtualService", latest.Name, "namespace", latest.Namespace, "changes", changes)
+
+		// Validate the change server-side first, a rejected spec fails the update for good
+		if err := r.dryRunVirtualService(ctx, latest, false); err != nil {
+			if !isRetryableError(err) {
+				return false, err
+			}
+			lastErr = fmt.Errorf("failed to validate VirtualService update: %w", err)
+			return false, nil
+		}
+
 		// Try to update
-		err = r.Update(ctx, latest)
-		if err != nil {
-			if errors.IsConflict(err) {
-				return false, nil // Retry on conflict
+		if err := r.Update(ctx, latest); err != nil {
+			if !isRetryableError(err) {
+				return false, err
 			}
-			return false, err // Don't retry on other errors
+			lastErr = fmt.Errorf("failed to update VirtualService: %w", err)
+			return false, nil
 		}
 
 		return true, nil // Success
+	}
+
+	// Stop retrying as soon as the context is cancelled, e.g. when the manager shuts down.
+	// Every attempt is a single Update, so a cancelled loop never leaves a partial change behind.
+	// The backoff waits on the reconciler's clock, so a fake clock runs through it without sleeping.
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		attempts++
+		done, err := attempt(ctx)
+		if err != nil || done {
+			return err
+		}
+		if backoff.Steps <= 1 {
+			return fmt.Errorf("giving up on VirtualService %s/%s after %d attempts: %w", vs.Namespace, vs.Name, attempts, lastErr)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.clock().After(backoff.Step()):
+		}
+	}
+}
+
+// configMapToRequests refreshes the watched namespaces when the operator ConfigMap changes and
+// enqueues every service in the watched namespaces so the new configuration takes effect
+func (r *ServiceReconciler) configMapToRequests(ctx context.Context, _ client.Object) []reconcile.Request {
+	log := ctrl.LoggerFrom(ctx)
+
+	watchedNamespaces, err := r.ConfigManager.GetWatchedNamespaces(ctx)
+	if err != nil {
+		log.Error(err, "Failed to refresh watched namespaces after config change")
+		return nil
+	}
+	r.checkCacheScope(ctx, watchedNamespaces)
+
+	var requests []reconcile.Request
+	for _, ns := range watchedNamespaces {
+		serviceList := &corev1.ServiceList{}
+		if err := r.List(ctx, serviceList, client.InNamespace(ns)); err != nil {
+			log.Error(err, "Failed to list services after config change", "namespace", ns)
+			continue
+		}
+		for _, service := range serviceList.Items {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: service.Name, Namespace: service.Namespace},
+			})
+		}
+	}
+
+	log.Info("Operator config changed, reconciling watched services", "services", len(requests))
+	return requests
+}
+
+// namespaceToRequests reconciles every default namespace service when a namespace matching a developer
+// namespace pattern or selector is created, deleted or relabelled, so placeholders and developer routes
+// follow the namespace
+func (r *ServiceReconciler) namespaceToRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := ctrl.LoggerFrom(ctx)
+
+	// A namespace that no longer matches the selector must still trigger cleanup
+	wasWatched := r.ConfigManager.IsWatchedNamespace(ctx, obj.GetName())
+
+	// Refresh the watched namespace set so the service predicate picks up the new namespace
+	watchedNamespaces, err := r.ConfigManager.GetWatchedNamespaces(ctx)
+	if err != nil {
+		log.Error(err, "Failed to refresh watched namespaces after namespace change", "namespace", obj.GetName())
+		return nil
+	}
+	r.checkCacheScope(ctx, watchedNamespaces)
+
+	operatorConfig, err := r.ConfigManager.GetConfig(ctx)
+	if err != nil {
+		log.Error(err, "Failed to get operator config after namespace change", "namespace", obj.GetName())
+		return nil
+	}
+	if obj.GetName() == operatorConfig.DefaultNamespace {
+		return nil
+	}
+	if !wasWatched && !isDeveloperNamespace(obj.GetName(), operatorConfig) {
+		return nil
+	}
+	if wasWatched && !isDeveloperNamespace(obj.GetName(), operatorConfig) {
+		if err := r.cleanupFormerDeveloperNamespace(ctx, obj.GetName(), operatorConfig); err != nil {
+			log.Error(err, "Failed to clean up former developer namespace", "namespace", obj.GetName())
+		}
+	}
+
+	serviceList := &corev1.ServiceList{}
+	if err := r.List(ctx, serviceList, client.InNamespace(operatorConfig.DefaultNamespace)); err != nil {
+		log.Error(err, "Failed to list default namespace services after namespace change", "namespace", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, service := range serviceList.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: service.Name, Namespace: service.Namespace},
+		})
+	}
+
+	log.Info("Developer namespace changed, reconciling default namespace services", "namespace", obj.GetName(), "services", len(requests))
+	return requests
+}
+
+// namespacePredicate filters objects by watched namespace. This runs for every Service event in the cluster,
+// so it only consults the in-memory namespace set that the ConfigMap watch keeps current.
+func (r *ServiceReconciler) namespacePredicate() predicate.Funcs {
+	return predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return r.ConfigManager.IsWatchedNamespace(context.Background(), object.GetNamespace())
 	})
 }
 
+// selectorPredicate skips default namespace services outside the service selector. An update passes if either
+// version matches, so a service that stops matching is still reconciled and cleaned up.
+func (r *ServiceReconciler) selectorPredicate() predicate.Funcs {
+	selected := func(object client.Object) bool {
+		return r.ConfigManager.IsSelectedService(context.Background(), object)
+	}
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return selected(e.Object) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return selected(e.Object) },
+		UpdateFunc:  func(e event.UpdateEvent) bool { return selected(e.ObjectOld) || selected(e.ObjectNew) },
+		GenericFunc: func(e event.GenericEvent) bool { return selected(e.Object) },
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	// Create a predicate that filters services based on watched namespaces
-	namespacePredicate := predicate.NewPredicateFuncs(func(object client.Object) bool {
-		watchedNamespaces, err := r.ConfigManager.GetWatchedNamespaces(context.Background())
-		if err != nil {
-			return false
-		}
+	namespacePredicate := r.namespacePredicate()
+	selectorPredicate := r.selectorPredicate()
 
-		for _, ns := range watchedNamespaces {
-			if ns == object.GetNamespace() {
-				return true
-			}
-		}
-		return false
+	configMapKey := r.ConfigManager.ConfigMapKey()
+	configMapPredicate := predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return object.GetNamespace() == configMapKey.Namespace && object.GetName() == configMapKey.Name
 	})
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Service{}).
-		WithEventFilter(namespacePredicate).
-		Complete(r)
+	// Namespace creation and deletion can change what a developer namespace pattern resolves to,
+	// a label change what the developer namespace selector resolves to, and adding or removing
+	// the watch annotation enrolls or unenrolls the namespace
+	namespaceLifecyclePredicate := predicate.Funcs{
+		CreateFunc: func(event.CreateEvent) bool { return true },
+		DeleteFunc: func(event.DeleteEvent) bool { return true },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !reflect.DeepEqual(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels()) ||
+				e.ObjectOld.GetAnnotations()[config.WatchAnnotation] != e.ObjectNew.GetAnnotations()[config.WatchAnnotation]
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+
+	// Only a service appearing or disappearing can move it between namespaces
+	lifecyclePredicate := predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return true },
+		DeleteFunc:  func(event.DeleteEvent) bool { return true },
+		UpdateFunc:  func(event.UpdateEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+
+	// Publish the aggregate status document, if configured, while this instance is the leader
+	if err := mgr.Add(&statusReporter{reconciler: r}); err != nil {
+		return fmt.Errorf("failed to add status reporter: %w", err)
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Service{}, serviceNameIndex, indexServiceName); err != nil {
+		return fmt.Errorf("failed to index services by name: %w", err)
+	}
+
+	controller := ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Service{}, builder.WithPredicates(namespacePredicate, selectorPredicate)).
+		Watches(&corev1.Service{}, handler.EnqueueRequestsFromMapFunc(r.sameNameServiceRequests), builder.WithPredicates(namespacePredicate, lifecyclePredicate)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.configMapToRequests), builder.WithPredicates(configMapPredicate)).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.namespaceToRequests), builder.WithPredicates(namespaceLifecyclePredicate)).
+		Watches(&discoveryv1.EndpointSlice{}, handler.EnqueueRequestsFromMapFunc(r.endpointSliceToRequests), builder.WithPredicates(namespacePredicate, endpointReadinessPredicate)).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.podToRequests), builder.WithPredicates(namespacePredicate, r.podVersionPredicate()))
+	if r.EnablePolicies {
+		if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1alpha1.VirtualServicePolicy{}, policyTargetIndex, indexPolicyTarget); err != nil {
+			return fmt.Errorf("failed to index VirtualServicePolicies by target service: %w", err)
+		}
+		r.policiesIndexed = true
+
+		// Policies are only consulted in the default namespace, the service predicate drops the rest
+		controller = controller.Watches(&v1alpha1.VirtualServicePolicy{}, handler.EnqueueRequestsFromMapFunc(r.policyToRequests), builder.WithPredicates(namespacePredicate))
+	}
+	return controller.Complete(r)
 }
@@ -0,0 +1,515 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/config"
+	"virtualservice-operator/internal/utils"
+)
+
+const handlerTestConfig = `
+defaultNamespace: default
+developerNamespaces: [alice, bob]
+enablePlaceholderServices: true
+`
+
+func TestHandleDefaultNamespaceServiceCreatesVirtualService(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", nil),
+	})
+
+	env.reconcile("default", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if vs == nil {
+		t.Fatal("VirtualService was not created")
+	}
+	if !utils.IsManagedByOperator(vs) {
+		t.Error("VirtualService is not labeled as managed by the operator")
+	}
+	if got := vs.Spec.Hosts; !reflect.DeepEqual(got, []string{"app"}) {
+		t.Errorf("hosts = %v, want [app]", got)
+	}
+	if len(vs.Spec.Http) != 1 || len(vs.Spec.Http[0].Match) != 0 {
+		t.Fatalf("want only the default route, got %d routes", len(vs.Spec.Http))
+	}
+	if host := vs.Spec.Http[0].Route[0].Destination.Host; host != "app.default.svc.cluster.local" {
+		t.Errorf("default route host = %q", host)
+	}
+	if owners := vs.GetOwnerReferences(); len(owners) != 1 || owners[0].Name != "app" {
+		t.Errorf("owner references = %v, want the service", owners)
+	}
+
+	for _, ns := range []string{"alice", "bob"} {
+		placeholder := env.service(ns, "app")
+		if placeholder == nil {
+			t.Fatalf("no placeholder in namespace %s", ns)
+		}
+		if placeholder.Spec.Type != corev1.ServiceTypeExternalName || placeholder.Spec.ExternalName != "app.default.svc.cluster.local" {
+			t.Errorf("placeholder in %s = %s %q", ns, placeholder.Spec.Type, placeholder.Spec.ExternalName)
+		}
+	}
+}
+
+func TestHandleDefaultNamespaceServiceAddsExistingDeveloperRoutes(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+	})
+
+	env.reconcile("default", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if got := routedDeveloperNamespaces(vs); !reflect.DeepEqual(got, []string{"alice"}) {
+		t.Fatalf("developer routes = %v, want [alice]", got)
+	}
+	if last := vs.Spec.Http[len(vs.Spec.Http)-1]; len(last.Match) != 0 {
+		t.Error("default route is not the last route")
+	}
+
+	// The real developer service is left alone, bob only has the placeholder
+	if alice := env.service("alice", "app"); alice.Spec.Type != corev1.ServiceTypeClusterIP {
+		t.Errorf("developer service was turned into a %s service", alice.Spec.Type)
+	}
+	if bob := env.service("bob", "app"); bob == nil || bob.Spec.Type != corev1.ServiceTypeExternalName {
+		t.Error("no placeholder in namespace bob")
+	}
+}
+
+func TestHandleDefaultNamespaceServiceUpdatesManagedVirtualService(t *testing.T) {
+	service := newService("default", "app", nil)
+	stale := utils.GenerateVirtualService(service, "default", nil, utils.RouteOptions{})
+	stale.Spec.Hosts = []string{"stale"}
+
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{service, stale, newService("bob", "app", nil)})
+
+	env.reconcile("default", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if got := vs.Spec.Hosts; !reflect.DeepEqual(got, []string{"app"}) {
+		t.Errorf("hosts = %v, want [app]", got)
+	}
+	if got := routedDeveloperNamespaces(vs); !reflect.DeepEqual(got, []string{"bob"}) {
+		t.Errorf("developer routes = %v, want [bob]", got)
+	}
+}
+
+func TestHandleDefaultNamespaceServiceLeavesUnmanagedVirtualService(t *testing.T) {
+	unmanaged := &istionetworkingv1beta1.VirtualService{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app-virtual-service"},
+	}
+	unmanaged.Spec.Hosts = []string{"hand-written"}
+
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{newService("default", "app", nil), unmanaged})
+
+	env.reconcile("default", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if utils.IsManagedByOperator(vs) || !reflect.DeepEqual(vs.Spec.Hosts, []string{"hand-written"}) {
+		t.Error("unmanaged VirtualService was modified")
+	}
+	if getAnnotation(env.service("default", "app"), conflictAnnotation) == "" {
+		t.Error("conflict is not recorded on the service")
+	}
+}
+
+func TestHandleDeveloperNamespaceServiceAddsRoute(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{newService("default", "app", nil)})
+	env.reconcile("default", "app")
+
+	// The developer deploys the service, replacing its placeholder
+	env.deleteObject(env.service("alice", "app"))
+	if err := env.client.Create(context.Background(), newService("alice", "app", nil)); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile("alice", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if got := routedDeveloperNamespaces(vs); !reflect.DeepEqual(got, []string{"alice"}) {
+		t.Fatalf("developer routes = %v, want [alice]", got)
+	}
+	route := vs.Spec.Http[0]
+	if value := route.Match[0].Headers["x-developer"].GetExact(); value != "alice" {
+		t.Errorf("route matches x-developer %q, want alice", value)
+	}
+	if host := route.Route[0].Destination.Host; host != "app.alice.svc.cluster.local" {
+		t.Errorf("route host = %q", host)
+	}
+}
+
+func TestHandleDeveloperNamespaceServiceSkipsPlaceholder(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{newService("default", "app", nil)})
+	env.reconcile("default", "app")
+	env.takeWrites()
+
+	env.reconcile("alice", "app")
+
+	if writes := env.takeWrites(); len(writes) != 0 {
+		t.Errorf("reconciling a placeholder wrote %d objects", len(writes))
+	}
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); len(got) != 0 {
+		t.Errorf("placeholder got a route: %v", got)
+	}
+}
+
+func TestHandleDeveloperNamespaceServiceWithoutDefaultService(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "other", nil),
+		newService("alice", "app", nil),
+	})
+
+	env.reconcile("alice", "app")
+
+	if vs := env.virtualService("default", "app-virtual-service"); vs != nil {
+		t.Error("VirtualService created without a default namespace service")
+	}
+	// The namespace is backfilled with placeholders of the default namespace services
+	if placeholder := env.service("alice", "other"); placeholder == nil || placeholder.Spec.Type != corev1.ServiceTypeExternalName {
+		t.Error("namespace was not backfilled with placeholders")
+	}
+}
+
+func TestHandleServiceDeletionOfDeveloperService(t *testing.T) {
+	developer := newService("alice", "app", nil)
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", nil),
+		developer,
+		newService("bob", "app", nil),
+	})
+	env.reconcile("default", "app")
+
+	env.deleteObject(developer)
+	env.reconcile("alice", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if got := routedDeveloperNamespaces(vs); !reflect.DeepEqual(got, []string{"bob"}) {
+		t.Errorf("developer routes = %v, want [bob]", got)
+	}
+	if placeholder := env.service("alice", "app"); placeholder == nil || placeholder.Spec.Type != corev1.ServiceTypeExternalName {
+		t.Error("placeholder was not recreated for the deleted developer service")
+	}
+}
+
+func TestHandleServiceDeletionOfDefaultService(t *testing.T) {
+	service := newService("default", "app", nil)
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{service, newService("alice", "app", nil)})
+	env.reconcile("default", "app")
+
+	env.deleteObject(service)
+	env.reconcile("default", "app")
+
+	if vs := env.virtualService("default", "app-virtual-service"); vs != nil {
+		t.Error("VirtualService of the deleted service still exists")
+	}
+	if placeholder := env.service("bob", "app"); placeholder != nil {
+		t.Error("placeholder of the deleted service still exists")
+	}
+	if developer := env.service("alice", "app"); developer == nil {
+		t.Error("real developer service was deleted")
+	}
+}
+
+func TestHandleServiceDeletionKeepsUnmanagedVirtualService(t *testing.T) {
+	unmanaged := &istionetworkingv1beta1.VirtualService{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app-virtual-service"},
+	}
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{unmanaged})
+
+	env.reconcile("default", "app")
+
+	if vs := env.virtualService("default", "app-virtual-service"); vs == nil {
+		t.Error("unmanaged VirtualService was deleted")
+	}
+}
+
+// virtualServiceWrites returns the recorded writes of VirtualServices
+func virtualServiceWrites(writes []write) []*istionetworkingv1beta1.VirtualService {
+	var written []*istionetworkingv1beta1.VirtualService
+	for _, w := range writes {
+		if vs, ok := w.object.(*istionetworkingv1beta1.VirtualService); ok {
+			written = append(written, vs)
+		}
+	}
+	return written
+}
+
+func TestHandleDefaultNamespaceServiceWritesCompleteRoutesOnce(t *testing.T) {
+	service := newService("default", "app", nil)
+	stale := utils.GenerateVirtualService(service, "default", []string{"alice", "bob"}, utils.RouteOptions{})
+	stale.Spec.Hosts = []string{"stale"}
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		service, stale, newService("alice", "app", nil), newService("bob", "app", nil),
+	})
+
+	for _, tt := range []struct {
+		name   string
+		change func()
+	}{
+		{name: "update"},
+		{name: "create", change: func() { env.deleteObject(env.virtualService("default", "app-virtual-service")) }},
+	} {
+		if tt.change != nil {
+			tt.change()
+		}
+		env.reconcile("default", "app")
+
+		written := virtualServiceWrites(env.takeWrites())
+		if len(written) != 1 {
+			t.Fatalf("%s: VirtualService written %d times in one reconcile, want once", tt.name, len(written))
+		}
+		if got := routedDeveloperNamespaces(written[0]); !reflect.DeepEqual(got, []string{"alice", "bob"}) {
+			t.Errorf("%s: written developer routes = %v, want [alice bob]", tt.name, got)
+		}
+		if got := written[0].Spec.Hosts; !reflect.DeepEqual(got, []string{"app"}) {
+			t.Errorf("%s: written hosts = %v, want [app]", tt.name, got)
+		}
+	}
+}
+
+func TestReconcileRequeuesAfterResyncPeriod(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"resyncPeriod: 5m\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+	})
+
+	if result := env.reconcile("default", "app"); result.RequeueAfter != 5*time.Minute {
+		t.Errorf("default namespace service requeued after %v, want 5m", result.RequeueAfter)
+	}
+	if result := env.reconcile("alice", "app"); result.RequeueAfter != 5*time.Minute {
+		t.Errorf("developer service requeued after %v, want 5m", result.RequeueAfter)
+	}
+
+	env = newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", nil),
+	})
+	if result := env.reconcile("default", "app"); result.RequeueAfter != 0 {
+		t.Errorf("requeued after %v without a resync period", result.RequeueAfter)
+	}
+}
+
+func TestWithResync(t *testing.T) {
+	operatorConfig := testConfig(t, handlerTestConfig+"resyncPeriod: 5m\n")
+	failure := errors.New("conflict")
+
+	tests := []struct {
+		name    string
+		result  ctrl.Result
+		err     error
+		want    ctrl.Result
+		wantErr error
+	}{
+		{name: "success", want: ctrl.Result{RequeueAfter: 5 * time.Minute}},
+		{name: "earlier requeue kept", result: ctrl.Result{RequeueAfter: time.Minute}, want: ctrl.Result{RequeueAfter: time.Minute}},
+		{name: "later requeue shortened", result: ctrl.Result{RequeueAfter: time.Hour}, want: ctrl.Result{RequeueAfter: 5 * time.Minute}},
+		{name: "immediate requeue kept", result: ctrl.Result{Requeue: true}, want: ctrl.Result{Requeue: true}},
+		{name: "error", err: failure, wantErr: failure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := withResync(tt.result, tt.err, operatorConfig)
+			if got != tt.want || err != tt.wantErr {
+				t.Errorf("withResync() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnmanagedVirtualServicePolicy(t *testing.T) {
+	tests := []struct {
+		policy       string
+		wantAdopted  bool
+		wantConflict bool
+		wantEvent    string
+	}{
+		{policy: "adopt", wantAdopted: true, wantEvent: "Adopted"},
+		{policy: "ignore"},
+		{policy: "warn", wantConflict: true, wantEvent: "UnmanagedVirtualService"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			unmanaged := &istionetworkingv1beta1.VirtualService{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app-virtual-service"},
+			}
+			unmanaged.Spec.Hosts = []string{"hand-written"}
+			env := newTestEnv(t, testConfig(t, handlerTestConfig+"unmanagedVirtualServicePolicy: "+tt.policy+"\n"), []client.Object{
+				newService("default", "app", nil),
+				newService("alice", "app", nil),
+				unmanaged,
+			})
+
+			env.reconcile("default", "app")
+
+			vs := env.virtualService("default", "app-virtual-service")
+			if adopted := utils.IsManagedByOperator(vs); adopted != tt.wantAdopted {
+				t.Fatalf("managed = %v, want %v", adopted, tt.wantAdopted)
+			}
+			if tt.wantAdopted {
+				if !isOwnedByService(vs, env.service("default", "app")) {
+					t.Errorf("adopted VirtualService owner references = %v, want the service", vs.GetOwnerReferences())
+				}
+				if got := routedDeveloperNamespaces(vs); !reflect.DeepEqual(got, []string{"alice"}) {
+					t.Errorf("adopted VirtualService routes = %v, want [alice]", got)
+				}
+			} else if !reflect.DeepEqual(vs.Spec.Hosts, []string{"hand-written"}) || len(vs.Spec.Http) != 0 || len(vs.GetOwnerReferences()) != 0 {
+				t.Errorf("unmanaged VirtualService was modified: %v", vs)
+			}
+
+			if conflict := getAnnotation(env.service("default", "app"), conflictAnnotation) != ""; conflict != tt.wantConflict {
+				t.Errorf("conflict recorded = %v, want %v", conflict, tt.wantConflict)
+			}
+			if tt.wantEvent != "" && !recordedEvent(env.recorder, tt.wantEvent) {
+				t.Errorf("no %s event", tt.wantEvent)
+			}
+		})
+	}
+}
+
+func TestOwnedVirtualServiceWithoutLabelIsRepaired(t *testing.T) {
+	service := newService("default", "app", nil)
+	owned := utils.GenerateVirtualService(service, "default", nil, utils.RouteOptions{})
+	delete(owned.Labels, utils.ManagedByLabel)
+
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{service, newService("alice", "app", nil), owned})
+	env.reconcile("default", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if !utils.IsManagedByOperator(vs) {
+		t.Error("managed-by label was not repaired")
+	}
+	if got := routedDeveloperNamespaces(vs); !reflect.DeepEqual(got, []string{"alice"}) {
+		t.Errorf("routed namespaces = %v, want the repaired VirtualService updated", got)
+	}
+	if conflict := getAnnotation(env.service("default", "app"), conflictAnnotation); conflict != "" {
+		t.Errorf("repaired VirtualService recorded as a conflict: %q", conflict)
+	}
+}
+
+func TestVirtualServiceOwnedByAnotherServiceIsNotRepaired(t *testing.T) {
+	// Owned by an earlier service of the same name, which has another UID
+	previous := newService("default", "app", nil)
+	previous.UID = "previous"
+	stale := utils.GenerateVirtualService(previous, "default", nil, utils.RouteOptions{})
+	delete(stale.Labels, utils.ManagedByLabel)
+
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{newService("default", "app", nil), stale})
+	env.reconcile("default", "app")
+
+	if utils.IsManagedByOperator(env.virtualService("default", "app-virtual-service")) {
+		t.Error("VirtualService owned by another service was labeled as managed")
+	}
+	if getAnnotation(env.service("default", "app"), conflictAnnotation) == "" {
+		t.Error("conflict is not recorded on the service")
+	}
+}
+
+func TestDefaultNamespaceMatchingDeveloperSelectorGetsNoSelfRoute(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testConfigMapKey.Namespace, Name: testConfigMapKey.Name},
+		Data: map[string]string{"config.yaml": `defaultNamespace: default
+enablePlaceholderServices: true
+developerNamespaceSelector:
+  matchLabels:
+    env: dev
+`},
+	}
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		configMap,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"env": "dev"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "alice", Labels: map[string]string{"env": "dev"}}},
+		newService("default", "app", nil),
+	})
+	env.reconciler.ConfigManager = config.NewConfigManager(env.client, testConfigMapKey.Namespace, testConfigMapKey.Name)
+
+	env.reconcile("default", "app")
+
+	vs := env.virtualService("default", "app-virtual-service")
+	if got := routedDeveloperNamespaces(vs); len(got) != 0 {
+		t.Errorf("routed namespaces = %v, want no route to the default namespace itself", got)
+	}
+	if host := vs.Spec.Http[len(vs.Spec.Http)-1].Route[0].Destination.Host; host != "app.default.svc.cluster.local" {
+		t.Errorf("default route host = %q", host)
+	}
+	if placeholder := env.service("alice", "app"); placeholder == nil {
+		t.Error("no placeholder in the selected developer namespace")
+	}
+	if env.reconciler.isPlaceholderService(context.Background(), env.service("default", "app"), testConfig(t, handlerTestConfig)) {
+		t.Error("default namespace service was replaced by a placeholder")
+	}
+}
+
+func TestWatchAnnotationEnrollsNamespace(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testConfigMapKey.Namespace, Name: testConfigMapKey.Name},
+		Data: map[string]string{"config.yaml": `defaultNamespace: default
+watchAnnotatedNamespaces: true
+enablePlaceholderServices: true
+generateSidecars: true
+generateDestinationRules: true
+`},
+	}
+	teamA := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Annotations: map[string]string{config.WatchAnnotation: "true"}}}
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		configMap,
+		teamA,
+		newService("default", "app", nil),
+		newService("default", "other", nil),
+		newService("team-a", "app", map[string]string{subsetAnnotation: "v2"}),
+	})
+	env.reconciler.ConfigManager = config.NewConfigManager(env.client, testConfigMapKey.Namespace, testConfigMapKey.Name)
+	ctx := context.Background()
+
+	env.reconcile("default", "app")
+	env.reconcile("default", "other")
+	env.reconcile("team-a", "app")
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); !reflect.DeepEqual(got, []string{"team-a"}) {
+		t.Fatalf("developer routes = %v, want the annotated namespace", got)
+	}
+	if env.service("team-a", "other") == nil || env.sidecar("team-a") == nil || env.destinationRule("team-a", utils.DestinationRuleName("app")) == nil {
+		t.Fatal("the annotated namespace has no placeholder, Sidecar or DestinationRule")
+	}
+
+	// Removing the annotation requeues the default namespace services, which drop the route
+	if err := env.client.Get(ctx, client.ObjectKeyFromObject(teamA), teamA); err != nil {
+		t.Fatal(err)
+	}
+	teamA.Annotations = nil
+	if err := env.client.Update(ctx, teamA); err != nil {
+		t.Fatal(err)
+	}
+	requests := env.reconciler.namespaceToRequests(ctx, teamA)
+	if want := []ctrl.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app"}}, {NamespacedName: types.NamespacedName{Namespace: "default", Name: "other"}}}; !reflect.DeepEqual(requests, want) {
+		t.Fatalf("requests = %v, want the default namespace services", requests)
+	}
+	for _, request := range requests {
+		env.reconcile(request.Namespace, request.Name)
+	}
+	if got := routedDeveloperNamespaces(env.virtualService("default", "app-virtual-service")); len(got) != 0 {
+		t.Errorf("developer routes = %v after removing the watch annotation, want none", got)
+	}
+
+	// What the operator generated in the namespace goes with it, the namespace's own service stays
+	if env.service("team-a", "other") != nil {
+		t.Error("placeholder left behind after removing the watch annotation")
+	}
+	if env.sidecar("team-a") != nil {
+		t.Error("Sidecar left behind after removing the watch annotation")
+	}
+	if env.destinationRule("team-a", utils.DestinationRuleName("app")) != nil {
+		t.Error("DestinationRule left behind after removing the watch annotation")
+	}
+	if env.service("team-a", "app") == nil {
+		t.Error("the namespace's own service was deleted")
+	}
+}
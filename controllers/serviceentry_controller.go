@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"virtualservice-operator/internal/config"
+	"virtualservice-operator/internal/utils"
+)
+
+// serviceEntryAnnotation set to "true" on a default namespace ServiceEntry opts it in to a generated
+// VirtualService. Developers override it with a ServiceEntry of the same name in their namespace.
+const serviceEntryAnnotation = "virtualservice-operator/route-service-entry"
+
+// ServiceEntryReconciler generates VirtualServices for external services modeled as ServiceEntries. The
+// default namespace entry gets the default route, every same-named entry in a developer namespace gets
+// a developer route to its own host. It shares the VirtualService handling of the ServiceReconciler.
+type ServiceEntryReconciler struct {
+	*ServiceReconciler
+}
+
+// Reconcile regenerates the VirtualService of a default namespace ServiceEntry; events in developer
+// namespaces are mapped to the default namespace entry
+func (r *ServiceEntryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	config, err := r.ConfigManager.GetConfig(ctx)
+	if err != nil {
+		log.Error(err, "Failed to get operator config")
+		return ctrl.Result{}, err
+	}
+	if config.Paused {
+		return ctrl.Result{}, nil
+	}
+
+	vs, err := r.desiredServiceEntryVirtualService(ctx, req.Name, config)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	vsName := utils.ServiceEntryVirtualServiceName(req.Name)
+	existingVS := &istionetworkingv1beta1.VirtualService{}
+	err = r.Get(ctx, types.NamespacedName{Name: vsName, Namespace: config.DefaultNamespace}, existingVS)
+	if err != nil && !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	exists := err == nil
+	if exists && !utils.IsManagedByOperator(existingVS) {
+		log.Info("ServiceEntry VirtualService exists but isn't managed by the operator, leaving it alone", "serviceEntry", req.Name, "virtualService", vsName)
+		return ctrl.Result{}, nil
+	}
+
+	if vs == nil {
+		if !exists {
+			return ctrl.Result{}, nil
+		}
+		log.Info("Deleting VirtualService of ServiceEntry", "serviceEntry", req.Name, "virtualService", vsName)
+		if err := r.Delete(ctx, existingVS); err != nil && !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !exists {
+		log.Info("Creating VirtualService for ServiceEntry", "serviceEntry", req.Name, "virtualService", vsName)
+		utils.StampDeveloperRoutes(vs, r.clock().Now(), 0)
+		if err := r.dryRunVirtualService(ctx, vs, true); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.Create(ctx, vs)
+	}
+
+	return ctrl.Result{}, r.retryVirtualServiceUpdate(ctx, existingVS, func(latest *istionetworkingv1beta1.VirtualService) error {
+		latest.Spec.Hosts = vs.Spec.Hosts
+		latest.Spec.Http = vs.Spec.Http
+		utils.SyncManagedAnnotations(latest, vs)
+
+		desired := map[string]bool{}
+		for _, route := range vs.Spec.Http {
+			if devNamespace, ok := utils.DeveloperRouteNamespace(route); ok {
+				desired[devNamespace] = true
+			}
+		}
+		for devNamespace := range utils.RouteTimestamps(latest) {
+			if !desired[devNamespace] {
+				utils.ForgetRouteTimestamp(latest, devNamespace)
+			}
+		}
+		return nil
+	})
+}
+
+// desiredServiceEntryVirtualService computes the VirtualService of a default namespace ServiceEntry without
+// writing anything. It returns nil when the entry is gone, not opted in, or has no hosts.
+func (r *ServiceEntryReconciler) desiredServiceEntryVirtualService(ctx context.Context, name string, config *config.OperatorConfig) (*istionetworkingv1beta1.VirtualService, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	entry := &istionetworkingv1beta1.ServiceEntry{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: config.DefaultNamespace}, entry)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ServiceEntry %s/%s: %w", config.DefaultNamespace, name, err)
+	}
+	if !entry.DeletionTimestamp.IsZero() || getAnnotation(entry, serviceEntryAnnotation) != "true" {
+		return nil, nil
+	}
+	if len(entry.Spec.Hosts) == 0 {
+		log.Info("ServiceEntry has no hosts, not generating a VirtualService", "serviceEntry", name)
+		return nil, nil
+	}
+
+	hosts := utils.ServiceEntryHosts{config.DefaultNamespace: entry.Spec.Hosts[0]}
+	var devNamespaces []string
+	for _, devNamespace := range config.DeveloperNamespaces {
+		devEntry := &istionetworkingv1beta1.ServiceEntry{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: devNamespace}, devEntry)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ServiceEntry %s/%s: %w", devNamespace, name, err)
+		}
+		if !devEntry.DeletionTimestamp.IsZero() || len(devEntry.Spec.Hosts) == 0 {
+			continue
+		}
+		hosts[devNamespace] = devEntry.Spec.Hosts[0]
+		devNamespaces = append(devNamespaces, devNamespace)
+	}
+
+	vs := utils.GenerateServiceEntryVirtualService(entry, hosts, utils.RouteOptions{
+		Annotations: config.VirtualServiceAnnotations,
+	})
+	if err := ctrl.SetControllerReference(entry, vs, r.Scheme); err != nil {
+		return nil, err
+	}
+	for _, devNamespace := range devNamespaces {
+		utils.UpdateVirtualServiceRoutes(vs, name, devNamespace, utils.RouteOptions{
+			Hosts:                hosts,
+			MatchSourceNamespace: config.RoutingStrategy == "source",
+			HeaderPrefix:         config.DevHeaderPrefix,
+			HeaderSuffix:         config.DevHeaderSuffix,
+			FallbackHeader:       config.FallbackHeader,
+		})
+	}
+	return vs, nil
+}
+
+// serviceEntryRequests maps a ServiceEntry event in any watched namespace to the default namespace entry
+func (r *ServiceEntryReconciler) serviceEntryRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	operatorConfig, err := r.ConfigManager.GetConfig(ctx)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to get operator config for ServiceEntry event", "serviceEntry", obj.GetName(), "namespace", obj.GetNamespace())
+		return nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: obj.GetName(), Namespace: operatorConfig.DefaultNamespace},
+	}}
+}
+
+// SetupWithManager sets up the ServiceEntry controller with the Manager
+func (r *ServiceEntryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	namespacePredicate := predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return r.ConfigManager.IsWatchedNamespace(context.Background(), object.GetNamespace())
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("serviceentry").
+		Watches(&istionetworkingv1beta1.ServiceEntry{}, handler.EnqueueRequestsFromMapFunc(r.serviceEntryRequests), builder.WithPredicates(namespacePredicate)).
+		Complete(r)
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/utils"
+)
+
+const serviceEntryTestConfig = `
+defaultNamespace: default
+developerNamespaces: [alice, bob]
+`
+
+// newServiceEntry builds a ServiceEntry with the hosts, opted in to a VirtualService if optIn is set
+func newServiceEntry(namespace, name string, optIn bool, hosts ...string) *istionetworkingv1beta1.ServiceEntry {
+	entry := &istionetworkingv1beta1.ServiceEntry{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, UID: types.UID(namespace + "-" + name)},
+	}
+	if optIn {
+		entry.Annotations = map[string]string{serviceEntryAnnotation: "true"}
+	}
+	entry.Spec.Hosts = hosts
+	return entry
+}
+
+// reconcileServiceEntry runs the ServiceEntry reconciler for the default namespace entry
+func (e *testEnv) reconcileServiceEntry(name string) {
+	e.t.Helper()
+	reconciler := &ServiceEntryReconciler{ServiceReconciler: e.reconciler}
+	if _, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}}); err != nil {
+		e.t.Fatalf("reconcile of ServiceEntry %s failed: %v", name, err)
+	}
+}
+
+func TestServiceEntryVirtualService(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, serviceEntryTestConfig), []client.Object{
+		newServiceEntry("default", "payments", true, "payments.example.com"),
+		newServiceEntry("alice", "payments", false, "payments-alice.example.com"),
+		newServiceEntry("bob", "payments", false),
+	})
+	env.reconcileServiceEntry("payments")
+
+	vs := env.virtualService("default", "payments-serviceentry-virtual-service")
+	if vs == nil {
+		t.Fatal("VirtualService not created")
+	}
+	if want := []string{"payments.example.com"}; !reflect.DeepEqual(vs.Spec.Hosts, want) {
+		t.Errorf("hosts = %v, want %v", vs.Spec.Hosts, want)
+	}
+	if vs.Labels[utils.ServiceEntryLabel] != "payments" || !utils.IsManagedByOperator(vs) {
+		t.Errorf("labels = %v, want the managed ServiceEntry labels", vs.Labels)
+	}
+	if owner := metav1.GetControllerOf(vs); owner == nil || owner.Kind != "ServiceEntry" || owner.Name != "payments" {
+		t.Errorf("controller = %v, want the ServiceEntry", owner)
+	}
+
+	// bob's entry has no host to route to
+	if got := routedDeveloperNamespaces(vs); !reflect.DeepEqual(got, []string{"alice"}) {
+		t.Errorf("developer routes = %v, want [alice]", got)
+	}
+	if got := developerDestinations(vs)["alice"].GetHost(); got != "payments-alice.example.com" {
+		t.Errorf("alice destination = %q, want the alice ServiceEntry host", got)
+	}
+	defaultRoute := vs.Spec.Http[len(vs.Spec.Http)-1]
+	if got := defaultRoute.Route[0].Destination.Host; got != "payments.example.com" {
+		t.Errorf("default destination = %q, want the default ServiceEntry host", got)
+	}
+}
+
+func TestServiceEntryVirtualServiceLifecycle(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, serviceEntryTestConfig), []client.Object{
+		newServiceEntry("default", "payments", true, "payments.example.com"),
+		newServiceEntry("alice", "payments", false, "payments-alice.example.com"),
+	})
+	env.reconcileServiceEntry("payments")
+
+	// Removing the developer entry removes its route
+	env.deleteObject(newServiceEntry("alice", "payments", false))
+	env.reconcileServiceEntry("payments")
+	vs := env.virtualService("default", "payments-serviceentry-virtual-service")
+	if got := routedDeveloperNamespaces(vs); len(got) != 0 {
+		t.Errorf("developer routes = %v after deleting the developer ServiceEntry, want none", got)
+	}
+	if _, stamped := utils.RouteTimestamps(vs)["alice"]; stamped {
+		t.Error("timestamp of the removed route was kept")
+	}
+
+	// Opting out deletes the VirtualService
+	entry := &istionetworkingv1beta1.ServiceEntry{}
+	if err := env.client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "payments"}, entry); err != nil {
+		t.Fatal(err)
+	}
+	entry.Annotations = nil
+	if err := env.client.Update(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcileServiceEntry("payments")
+	if env.virtualService("default", "payments-serviceentry-virtual-service") != nil {
+		t.Error("VirtualService kept after the ServiceEntry opted out")
+	}
+}
+
+func TestServiceEntryWithoutOptIn(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, serviceEntryTestConfig), []client.Object{
+		newServiceEntry("default", "payments", false, "payments.example.com"),
+		newServiceEntry("default", "empty", true),
+	})
+	env.reconcileServiceEntry("payments")
+	env.reconcileServiceEntry("empty")
+	env.reconcileServiceEntry("missing")
+
+	if writes := env.takeWrites(); len(writes) != 0 {
+		t.Errorf("%d writes for ServiceEntries without a VirtualService", len(writes))
+	}
+}
+
+func TestServiceEntryLeavesUnmanagedVirtualService(t *testing.T) {
+	unmanaged := &istionetworkingv1beta1.VirtualService{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "payments-serviceentry-virtual-service"},
+	}
+	env := newTestEnv(t, testConfig(t, serviceEntryTestConfig), []client.Object{
+		newServiceEntry("default", "payments", true, "payments.example.com"),
+		unmanaged,
+	})
+	env.reconcileServiceEntry("payments")
+
+	if writes := env.takeWrites(); len(writes) != 0 {
+		t.Errorf("%d writes to a VirtualService the operator doesn't manage", len(writes))
+	}
+}
+
+func TestServiceEntryRequests(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, serviceEntryTestConfig), nil)
+	reconciler := &ServiceEntryReconciler{ServiceReconciler: env.reconciler}
+
+	requests := reconciler.serviceEntryRequests(context.Background(), newServiceEntry("alice", "payments", false))
+	want := types.NamespacedName{Namespace: "default", Name: "payments"}
+	if len(requests) != 1 || requests[0].NamespacedName != want {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/config"
+	"virtualservice-operator/internal/utils"
+)
+
+// reconcileSidecars brings the egress Sidecar of every developer namespace in line with the default
+// namespace services. With GenerateSidecars disabled, Sidecars created earlier are removed.
+func (r *ServiceReconciler) reconcileSidecars(ctx context.Context, config *config.OperatorConfig) error {
+	var serviceNames []string
+	if config.GenerateSidecars {
+		serviceList := &corev1.ServiceList{}
+		if err := r.List(ctx, serviceList, client.InNamespace(config.DefaultNamespace)); err != nil {
+			return fmt.Errorf("failed to list services in default namespace: %w", err)
+		}
+		for i := range serviceList.Items {
+			service := &serviceList.Items[i]
+			if r.isSystemService(service.Name) || !config.SelectsService(service) {
+				continue
+			}
+			serviceNames = append(serviceNames, service.Name)
+		}
+	}
+
+	for _, devNamespace := range config.DeveloperNamespaces {
+		if devNamespace == config.DefaultNamespace {
+			continue
+		}
+		if err := r.reconcileSidecar(ctx, devNamespace, serviceNames, config); err != nil {
+			return err
+		}
+	}
+	return r.pruneSidecars(ctx, config)
+}
+
+// pruneSidecars deletes the managed Sidecars of namespaces that are no longer developer namespaces,
+// which would otherwise keep narrowing their egress to the old host list
+func (r *ServiceReconciler) pruneSidecars(ctx context.Context, config *config.OperatorConfig) error {
+	sidecarList := &istionetworkingv1beta1.SidecarList{}
+	if err := r.List(ctx, sidecarList, client.MatchingLabels{utils.ManagedByLabel: utils.OperatorName}); err != nil {
+		return fmt.Errorf("failed to list managed Sidecars: %w", err)
+	}
+
+	developerNamespaces := make(map[string]bool, len(config.DeveloperNamespaces))
+	for _, ns := range config.DeveloperNamespaces {
+		developerNamespaces[ns] = ns != config.DefaultNamespace
+	}
+	for _, sidecar := range sidecarList.Items {
+		if sidecar.Name != utils.SidecarName || developerNamespaces[sidecar.Namespace] {
+			continue
+		}
+		if err := r.Delete(ctx, sidecar); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Sidecar %s/%s: %w", sidecar.Namespace, sidecar.Name, err)
+		}
+		ctrl.LoggerFrom(ctx).Info("Deleted Sidecar of former developer namespace", "sidecar", sidecar.Name, "namespace", sidecar.Namespace)
+	}
+	return nil
+}
+
+// reconcileSidecar creates, updates or deletes the egress Sidecar of one developer namespace.
+// A Sidecar with the same name that the operator doesn't manage is left alone.
+func (r *ServiceReconciler) reconcileSidecar(ctx context.Context, namespace string, serviceNames []string, config *config.OperatorConfig) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	existing := &istionetworkingv1beta1.Sidecar{}
+	err := r.Get(ctx, types.NamespacedName{Name: utils.SidecarName, Namespace: namespace}, existing)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get Sidecar in namespace %s: %w", namespace, err)
+	}
+	exists := err == nil
+
+	if exists && !utils.IsManagedByOperator(existing) {
+		log.V(1).Info("Sidecar exists and is not managed by the operator, leaving it alone", "sidecar", existing.Name, "namespace", namespace)
+		return nil
+	}
+
+	if !config.GenerateSidecars {
+		if exists {
+			if err := r.Delete(ctx, existing); err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete Sidecar %s/%s: %w", namespace, existing.Name, err)
+			}
+			log.Info("Deleted Sidecar after Sidecar generation was disabled", "sidecar", existing.Name, "namespace", namespace)
+		}
+		return nil
+	}
+
+	desired := utils.GenerateSidecar(namespace, config.DefaultNamespace, config.ClusterDomain, serviceNames)
+
+	if !exists {
+		if err := r.Create(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create Sidecar %s/%s: %w", namespace, desired.Name, err)
+		}
+		log.Info("Created Sidecar", "sidecar", desired.Name, "namespace", namespace)
+		return nil
+	}
+
+	if proto.Equal(&existing.Spec, &desired.Spec) {
+		return nil
+	}
+
+	existing.Spec.Egress = desired.Spec.Egress
+	if err := r.Update(ctx, existing); err != nil {
+		return fmt.Errorf("failed to update Sidecar %s/%s: %w", namespace, existing.Name, err)
+	}
+	log.Info("Updated Sidecar", "sidecar", existing.Name, "namespace", namespace)
+	return nil
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/utils"
+)
+
+func TestSidecarGeneration(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"generateSidecars: true\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("default", "kubernetes", nil),
+	})
+	env.reconcile("default", "app")
+
+	for _, ns := range []string{"alice", "bob"} {
+		sidecar := env.sidecar(ns)
+		if sidecar == nil {
+			t.Fatalf("no Sidecar in %s", ns)
+		}
+		want := []string{"./*", "istio-system/*", "default/app.default.svc.cluster.local"}
+		if got := sidecar.Spec.Egress[0].Hosts; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s egress hosts = %v, want %v", ns, got, want)
+		}
+	}
+
+	// A new default namespace service is added to every Sidecar
+	if err := env.client.Create(context.Background(), newService("default", "api", nil)); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile("default", "api")
+	want := []string{"./*", "istio-system/*", "default/api.default.svc.cluster.local", "default/app.default.svc.cluster.local"}
+	if got := env.sidecar("alice").Spec.Egress[0].Hosts; !reflect.DeepEqual(got, want) {
+		t.Errorf("egress hosts after adding api = %v, want %v", got, want)
+	}
+
+	// Disabling generation removes the Sidecars
+	env.config.set(testConfig(t, handlerTestConfig))
+	env.reconcile("default", "app")
+	for _, ns := range []string{"alice", "bob"} {
+		if env.sidecar(ns) != nil {
+			t.Errorf("Sidecar in %s kept after disabling generation", ns)
+		}
+	}
+}
+
+func TestSidecarGenerationLeavesUnmanagedSidecar(t *testing.T) {
+	unmanaged := &istionetworkingv1beta1.Sidecar{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "alice", Name: utils.SidecarName},
+		Spec:       istiov1beta1.Sidecar{Egress: []*istiov1beta1.IstioEgressListener{{Hosts: []string{"*/*"}}}},
+	}
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"generateSidecars: true\n"), []client.Object{
+		newService("default", "app", nil),
+		unmanaged,
+	})
+	env.reconcile("default", "app")
+
+	if got := env.sidecar("alice").Spec.Egress[0].Hosts; !reflect.DeepEqual(got, []string{"*/*"}) {
+		t.Errorf("unmanaged Sidecar egress hosts = %v, want them untouched", got)
+	}
+	if env.sidecar("bob") == nil {
+		t.Error("no Sidecar in bob")
+	}
+}
+
+func TestSidecarRemovedFromFormerDeveloperNamespace(t *testing.T) {
+	unmanaged := &istionetworkingv1beta1.Sidecar{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "carol", Name: utils.SidecarName},
+		Spec:       istiov1beta1.Sidecar{Egress: []*istiov1beta1.IstioEgressListener{{Hosts: []string{"*/*"}}}},
+	}
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"generateSidecars: true\n"), []client.Object{
+		newService("default", "app", nil),
+		unmanaged,
+	})
+	env.reconcile("default", "app")
+	if env.sidecar("bob") == nil {
+		t.Fatal("no Sidecar in bob")
+	}
+
+	// bob leaves the developer namespaces, its Sidecar goes while alice's and carol's unmanaged one stay
+	env.config.set(testConfig(t, "defaultNamespace: default\ndeveloperNamespaces: [alice]\ngenerateSidecars: true\n"))
+	env.reconcile("default", "app")
+	if env.sidecar("bob") != nil {
+		t.Error("Sidecar kept in bob after it left the developer namespaces")
+	}
+	if env.sidecar("alice") == nil {
+		t.Error("Sidecar removed from alice, which is still a developer namespace")
+	}
+	if env.sidecar("carol") == nil {
+		t.Error("unmanaged Sidecar in carol was deleted")
+	}
+}
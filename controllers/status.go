@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/utils"
+)
+
+// statusKey is the ConfigMap key the status document is written to
+const statusKey = "status.json"
+
+// defaultStatusInterval is how often the status document is written when statusInterval isn't set,
+// and how often the config is checked again while no status ConfigMap is configured
+const defaultStatusInterval = time.Minute
+
+// StatusDocument is the aggregate status of the services the operator manages
+type StatusDocument struct {
+	GeneratedAt time.Time       `json:"generatedAt"`
+	Services    []ServiceStatus `json:"services"`
+}
+
+// ServiceStatus is the state of one default namespace service with a managed VirtualService
+type ServiceStatus struct {
+	Name           string `json:"name"`
+	VirtualService string `json:"virtualService"`
+	// Group is set for services routed by a group VirtualService
+	Group string `json:"group,omitempty"`
+	// ServiceEntry is set when Name is a ServiceEntry rather than a Service
+	ServiceEntry bool `json:"serviceEntry,omitempty"`
+	// DeveloperNamespaces have a developer route in the VirtualService. For a group it lists
+	// the developer namespaces of any member.
+	DeveloperNamespaces []string `json:"developerNamespaces"`
+	// LastReconciled is unset for services not reconciled since this operator instance started
+	LastReconciled *metav1.Time `json:"lastReconciled,omitempty"`
+}
+
+// statusReporter periodically writes the status document to the configured status ConfigMap, for
+// dashboards that can't query Istio. It only runs on the leader.
+type statusReporter struct {
+	reconciler *ServiceReconciler
+}
+
+// Start writes the status document on every interval until the context is cancelled
+func (s *statusReporter) Start(ctx context.Context) error {
+	log := ctrl.LoggerFrom(ctx).WithName("status")
+	for {
+		interval, err := s.reconciler.writeStatus(ctx)
+		if err != nil {
+			log.Error(err, "Failed to write status document")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.reconciler.clock().After(interval):
+		}
+	}
+}
+
+// recordReconcile notes the time a service was last reconciled for the status document
+func (r *ServiceReconciler) recordReconcile(key types.NamespacedName, now time.Time) {
+	r.reconciled.Store(key, now)
+}
+
+// writeStatus writes the status document if a status ConfigMap is configured and returns the interval
+// to the next write
+func (r *ServiceReconciler) writeStatus(ctx context.Context) (time.Duration, error) {
+	config, err := r.ConfigManager.GetConfig(ctx)
+	if err != nil {
+		return defaultStatusInterval, fmt.Errorf("failed to get operator config: %w", err)
+	}
+	interval := config.StatusInterval.Duration
+	if interval <= 0 {
+		interval = defaultStatusInterval
+	}
+	if config.StatusConfigMap == "" || config.Paused {
+		return interval, nil
+	}
+
+	document, err := r.BuildStatus(ctx)
+	if err != nil {
+		return interval, err
+	}
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return interval, fmt.Errorf("failed to marshal status document: %w", err)
+	}
+
+	key := types.NamespacedName{Namespace: r.ConfigManager.ConfigMapKey().Namespace, Name: config.StatusConfigMap}
+	if key == r.ConfigManager.ConfigMapKey() {
+		return interval, fmt.Errorf("statusConfigMap must not be the operator ConfigMap %s", key)
+	}
+	configMap := &corev1.ConfigMap{}
+	err = r.Get(ctx, key, configMap)
+	if errors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels:    map[string]string{utils.ManagedByLabel: utils.OperatorName},
+			},
+			Data: map[string]string{statusKey: string(data)},
+		}
+		return interval, r.Create(ctx, configMap)
+	}
+	if err != nil {
+		return interval, fmt.Errorf("failed to get status ConfigMap %s: %w", key, err)
+	}
+	if !utils.IsManagedByOperator(configMap) {
+		return interval, fmt.Errorf("status ConfigMap %s exists but isn't managed by the operator", key)
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[statusKey] = string(data)
+	return interval, r.Update(ctx, configMap)
+}
+
+// BuildStatus builds the status document from the managed VirtualServices in the default namespace
+func (r *ServiceReconciler) BuildStatus(ctx context.Context) (*StatusDocument, error) {
+	config, err := r.ConfigManager.GetConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operator config: %w", err)
+	}
+
+	vsList := &istionetworkingv1beta1.VirtualServiceList{}
+	if err := r.List(ctx, vsList, client.InNamespace(config.DefaultNamespace), client.MatchingLabels{utils.ManagedByLabel: utils.OperatorName}); err != nil {
+		return nil, fmt.Errorf("failed to list managed VirtualServices: %w", err)
+	}
+
+	document := &StatusDocument{GeneratedAt: r.clock().Now().UTC(), Services: []ServiceStatus{}}
+	for _, vs := range vsList.Items {
+		if !utils.IsManagedByOperator(vs) {
+			continue
+		}
+
+		namespaces := map[string]bool{}
+		for _, route := range vs.Spec.Http {
+			if devNamespace, ok := utils.DeveloperRouteNamespace(route); ok {
+				namespaces[devNamespace] = true
+			}
+		}
+
+		group := vs.Labels[utils.GroupLabel]
+		entry := vs.Labels[utils.ServiceEntryLabel]
+		services := []string{utils.GetServiceNameFromVirtualService(vs.Name)}
+		if group != "" {
+			services = utils.GroupMembers(vs)
+		} else if entry != "" {
+			services = []string{entry}
+		}
+
+		for _, name := range services {
+			status := ServiceStatus{
+				Name:                name,
+				VirtualService:      vs.Name,
+				Group:               group,
+				ServiceEntry:        entry != "",
+				DeveloperNamespaces: sortedKeys(namespaces),
+			}
+			if status.DeveloperNamespaces == nil {
+				status.DeveloperNamespaces = []string{}
+			}
+			if last, ok := r.reconciled.Load(types.NamespacedName{Name: name, Namespace: config.DefaultNamespace}); ok {
+				status.LastReconciled = &metav1.Time{Time: last.(time.Time).UTC()}
+			}
+			document.Services = append(document.Services, status)
+		}
+	}
+
+	sort.Slice(document.Services, func(i, j int) bool {
+		return document.Services[i].Name < document.Services[j].Name
+	})
+	return document, nil
+}
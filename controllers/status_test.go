@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const statusTestConfig = `
+defaultNamespace: default
+developerNamespaces: [alice, bob]
+statusConfigMap: virtualservice-operator-status
+statusInterval: 30s
+`
+
+// statusTestObjects are a standalone service with a developer service, one without, and a group of two
+func statusTestObjects() []client.Object {
+	group := map[string]string{groupAnnotation: "api"}
+	unmanaged := &istionetworkingv1beta1.VirtualService{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "manual-virtual-service"}}
+	return []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+		newService("default", "other", nil),
+		newService("default", "users", group),
+		newService("default", "orders", group),
+		newService("bob", "orders", nil),
+		unmanaged,
+	}
+}
+
+// statusDocument reads the status document from the status ConfigMap
+func (e *testEnv) statusDocument() *StatusDocument {
+	e.t.Helper()
+	configMap := &corev1.ConfigMap{}
+	key := types.NamespacedName{Namespace: testConfigMapKey.Namespace, Name: "virtualservice-operator-status"}
+	if err := e.client.Get(context.Background(), key, configMap); err != nil {
+		e.t.Fatalf("failed to get status ConfigMap: %v", err)
+	}
+	document := &StatusDocument{}
+	if err := json.Unmarshal([]byte(configMap.Data[statusKey]), document); err != nil {
+		e.t.Fatalf("failed to unmarshal status document: %v", err)
+	}
+	return document
+}
+
+func TestBuildStatus(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, statusTestConfig), statusTestObjects())
+	reconciled := &metav1.Time{Time: env.clock.Now()}
+	for _, name := range []string{"app", "other", "users", "orders"} {
+		env.reconcile("default", name)
+	}
+	env.clock.advance(time.Minute)
+
+	document, err := env.reconciler.BuildStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !document.GeneratedAt.Equal(env.clock.Now()) {
+		t.Errorf("generatedAt = %v, want %v", document.GeneratedAt, env.clock.Now())
+	}
+	want := []ServiceStatus{
+		{Name: "app", VirtualService: "app-virtual-service", DeveloperNamespaces: []string{"alice"}},
+		{Name: "orders", VirtualService: "api-group-virtual-service", Group: "api", DeveloperNamespaces: []string{"bob"}},
+		{Name: "other", VirtualService: "other-virtual-service", DeveloperNamespaces: []string{}},
+		{Name: "users", VirtualService: "api-group-virtual-service", Group: "api", DeveloperNamespaces: []string{"bob"}},
+	}
+	for i := range want {
+		want[i].LastReconciled = reconciled
+	}
+	if !reflect.DeepEqual(document.Services, want) {
+		t.Errorf("services = %+v, want %+v", document.Services, want)
+	}
+}
+
+func TestWriteStatus(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, statusTestConfig), statusTestObjects())
+	env.reconcile("default", "app")
+
+	interval, err := env.reconciler.writeStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if interval != 30*time.Second {
+		t.Errorf("interval = %v, want the configured 30s", interval)
+	}
+	if got := env.statusDocument().Services; len(got) != 1 || !reflect.DeepEqual(got[0].DeveloperNamespaces, []string{"alice"}) {
+		t.Fatalf("services = %+v, want app routed to alice", got)
+	}
+
+	// The next write follows the managed state
+	env.deleteObject(env.service("alice", "app"))
+	env.reconcile("alice", "app")
+	env.reconcile("default", "other")
+	if _, err := env.reconciler.writeStatus(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, service := range env.statusDocument().Services {
+		if len(service.DeveloperNamespaces) != 0 {
+			t.Errorf("%s routed to %v after the developer service was deleted", service.Name, service.DeveloperNamespaces)
+		}
+		names = append(names, service.Name)
+	}
+	if want := []string{"app", "other"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("services = %v, want %v", names, want)
+	}
+}
+
+func TestWriteStatusRefusesUnmanagedConfigMap(t *testing.T) {
+	existing := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: testConfigMapKey.Namespace, Name: "virtualservice-operator-status"}}
+	env := newTestEnv(t, testConfig(t, statusTestConfig), []client.Object{existing})
+
+	if _, err := env.reconciler.writeStatus(context.Background()); err == nil {
+		t.Error("writeStatus() overwrote a ConfigMap the operator doesn't manage")
+	}
+}
+
+func TestWriteStatusWithoutStatusConfigMap(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{newService("default", "app", nil)})
+	env.reconcile("default", "app")
+	env.takeWrites()
+
+	interval, err := env.reconciler.writeStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if interval != defaultStatusInterval {
+		t.Errorf("interval = %v, want %v", interval, defaultStatusInterval)
+	}
+	if writes := env.takeWrites(); len(writes) != 0 {
+		t.Errorf("%d writes without a status ConfigMap", len(writes))
+	}
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// hashOnAnnotation on a developer service makes its developer route sticky with consistent hashing,
+// e.g. "header:x-session-id", "cookie:session", "cookie:session:1h" or "sourceIP"
+const hashOnAnnotation = "virtualservice-operator/hash-on"
+
+// parseHashOn parses the hash-on annotation into the consistent hash load balancer settings
+func parseHashOn(value string) (*istiov1beta1.LoadBalancerSettings_ConsistentHashLB, error) {
+	source, operand, _ := strings.Cut(strings.TrimSpace(value), ":")
+
+	switch source {
+	case "header":
+		if err := validateHeaderName(operand); err != nil {
+			return nil, err
+		}
+		return &istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+			HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HttpHeaderName{HttpHeaderName: operand},
+		}, nil
+	case "cookie":
+		name, ttl, hasTTL := strings.Cut(operand, ":")
+		if name == "" {
+			return nil, fmt.Errorf("invalid hash-on %q, expected cookie:name or cookie:name:ttl", value)
+		}
+		cookie := &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HTTPCookie{Name: name}
+		if hasTTL {
+			duration, err := time.ParseDuration(ttl)
+			if err != nil || duration <= 0 {
+				return nil, fmt.Errorf("invalid hash-on %q: cookie ttl must be a positive duration", value)
+			}
+			// With a ttl the proxy sets the cookie itself when the request has none
+			cookie.Ttl = durationpb.New(duration)
+		}
+		return &istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+			HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HttpCookie{HttpCookie: cookie},
+		}, nil
+	case "sourceIP":
+		if operand != "" {
+			return nil, fmt.Errorf("invalid hash-on %q, sourceIP takes no argument", value)
+		}
+		return &istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+			HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_UseSourceIp{UseSourceIp: true},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown hash-on source %q, expected header, cookie or sourceIP", source)
+	}
+}
+
+// consistentHash returns the consistent hash settings a developer service asks for, nil if none or invalid
+func (r *ServiceReconciler) consistentHash(ctx context.Context, service *corev1.Service) *istiov1beta1.LoadBalancerSettings_ConsistentHashLB {
+	if !hasAnnotation(service, hashOnAnnotation) {
+		return nil
+	}
+
+	hash, err := parseHashOn(getAnnotation(service, hashOnAnnotation))
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Info("Ignoring invalid hash-on annotation", "service", service.Name, "namespace", service.Namespace, "error", err.Error())
+		r.recorder().Eventf(service, corev1.EventTypeWarning, "InvalidAnnotation", "Ignoring hash-on: %v", err)
+		return nil
+	}
+	return hash
+}
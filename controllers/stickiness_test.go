@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"virtualservice-operator/internal/utils"
+)
+
+func TestParseHashOn(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *istiov1beta1.LoadBalancerSettings_ConsistentHashLB
+		wantErr bool
+	}{
+		{
+			value: "header:x-session-id",
+			want: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+				HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HttpHeaderName{HttpHeaderName: "x-session-id"},
+			},
+		},
+		{
+			value: "cookie:session",
+			want: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+				HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HttpCookie{
+					HttpCookie: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HTTPCookie{Name: "session"},
+				},
+			},
+		},
+		{
+			value: "cookie:session:1h",
+			want: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+				HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HttpCookie{
+					HttpCookie: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_HTTPCookie{Name: "session", Ttl: durationpb.New(time.Hour)},
+				},
+			},
+		},
+		{
+			value: "sourceIP",
+			want: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB{
+				HashKey: &istiov1beta1.LoadBalancerSettings_ConsistentHashLB_UseSourceIp{UseSourceIp: true},
+			},
+		},
+		{value: "header:", wantErr: true},
+		{value: "header:not a header", wantErr: true},
+		{value: "cookie:", wantErr: true},
+		{value: "cookie:session:forever", wantErr: true},
+		{value: "cookie:session:-1h", wantErr: true},
+		{value: "sourceIP:10.0.0.1", wantErr: true},
+		{value: "query:id", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseHashOn(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHashOn(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !proto.Equal(got, tt.want) {
+				t.Errorf("parseHashOn(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashOnDestinationRule(t *testing.T) {
+	tests := []struct {
+		hashOn string
+		want   func(*istiov1beta1.LoadBalancerSettings_ConsistentHashLB) bool
+	}{
+		{
+			hashOn: "header:x-session-id",
+			want: func(hash *istiov1beta1.LoadBalancerSettings_ConsistentHashLB) bool {
+				return hash.GetHttpHeaderName() == "x-session-id"
+			},
+		},
+		{
+			hashOn: "cookie:session:1h",
+			want: func(hash *istiov1beta1.LoadBalancerSettings_ConsistentHashLB) bool {
+				return hash.GetHttpCookie().GetName() == "session" && hash.GetHttpCookie().GetTtl().AsDuration() == time.Hour
+			},
+		},
+		{
+			hashOn: "sourceIP",
+			want: func(hash *istiov1beta1.LoadBalancerSettings_ConsistentHashLB) bool {
+				return hash.GetUseSourceIp()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hashOn, func(t *testing.T) {
+			env := newTestEnv(t, testConfig(t, handlerTestConfig+"generateDestinationRules: true\n"), []client.Object{
+				newService("default", "app", nil),
+				newService("alice", "app", map[string]string{hashOnAnnotation: tt.hashOn}),
+			})
+			env.reconcile("default", "app")
+			env.reconcile("alice", "app")
+
+			dr := env.destinationRule("alice", utils.DestinationRuleName("app"))
+			if dr == nil {
+				t.Fatal("no DestinationRule for the consistent hashing")
+			}
+			if dr.Spec.Host != "app.alice.svc.cluster.local" || len(dr.Spec.Subsets) != 0 {
+				t.Errorf("DestinationRule host %q, subsets %v, want the alice host without subsets", dr.Spec.Host, dr.Spec.Subsets)
+			}
+			if hash := dr.Spec.GetTrafficPolicy().GetLoadBalancer().GetConsistentHash(); hash == nil || !tt.want(hash) {
+				t.Errorf("consistent hash = %v, want %s", hash, tt.hashOn)
+			}
+
+			// Removing the annotation deletes the DestinationRule
+			env.updateService("alice", "app", func(service *corev1.Service) {
+				delete(service.Annotations, hashOnAnnotation)
+			})
+			env.reconcile("alice", "app")
+			if env.destinationRule("alice", utils.DestinationRuleName("app")) != nil {
+				t.Error("DestinationRule kept after removing the hash-on annotation")
+			}
+		})
+	}
+}
+
+func TestHashOnWithSubset(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"generateDestinationRules: true\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", map[string]string{hashOnAnnotation: "sourceIP", subsetAnnotation: "v2"}),
+	})
+	env.reconcile("default", "app")
+	env.reconcile("alice", "app")
+
+	dr := env.destinationRule("alice", utils.DestinationRuleName("app"))
+	if dr == nil {
+		t.Fatal("no DestinationRule")
+	}
+	if len(dr.Spec.Subsets) != 1 || dr.Spec.Subsets[0].Name != "v2" {
+		t.Errorf("subsets = %v, want v2", dr.Spec.Subsets)
+	}
+	if !dr.Spec.GetTrafficPolicy().GetLoadBalancer().GetConsistentHash().GetUseSourceIp() {
+		t.Errorf("traffic policy = %v, want sourceIP hashing", dr.Spec.TrafficPolicy)
+	}
+}
+
+func TestInvalidHashOn(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, handlerTestConfig+"generateDestinationRules: true\n"), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", map[string]string{hashOnAnnotation: "query:id"}),
+	})
+	env.reconcile("default", "app")
+	env.reconcile("alice", "app")
+
+	if env.destinationRule("alice", utils.DestinationRuleName("app")) != nil {
+		t.Error("DestinationRule created for an invalid hash-on annotation")
+	}
+	if !recordedEvent(env.recorder, "InvalidAnnotation", "hash-on") {
+		t.Error("no InvalidAnnotation warning for the hash-on annotation")
+	}
+}
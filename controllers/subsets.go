@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"virtualservice-operator/internal/config"
+)
+
+// developerSubset returns the DestinationRule subset the developer route of a service is pinned to.
+// The subset annotation wins; otherwise, with DeriveSubsetsFromPods, the subset is the version label
+// shared by all pods the service selects. An empty result leaves the route unpinned.
+func (r *ServiceReconciler) developerSubset(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) string {
+	if subset := getAnnotation(service, subsetAnnotation); subset != "" {
+		return subset
+	}
+	if !config.DeriveSubsetsFromPods || !config.GenerateDestinationRules {
+		return ""
+	}
+
+	subset, err := r.podVersionSubset(ctx, service, config.SubsetLabel)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to derive subset from pods, leaving route unpinned", "service", service.Name, "namespace", service.Namespace)
+		return ""
+	}
+	return subset
+}
+
+// podVersionSubset reads the subset label from the pods selected by the service. It only returns a subset
+// when every labeled pod agrees on it, since pinning to one of several versions would drop the others.
+func (r *ServiceReconciler) podVersionSubset(ctx context.Context, service *corev1.Service, subsetLabel string) (string, error) {
+	if len(service.Spec.Selector) == 0 {
+		return "", nil
+	}
+
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList, client.InNamespace(service.Namespace), client.MatchingLabels(service.Spec.Selector)); err != nil {
+		return "", fmt.Errorf("failed to list pods of service %s/%s: %w", service.Namespace, service.Name, err)
+	}
+
+	versions := map[string]bool{}
+	for _, pod := range podList.Items {
+		if pod.DeletionTimestamp != nil {
+			continue // Terminating pods of a previous version shouldn't block the new one
+		}
+		if version := pod.Labels[subsetLabel]; version != "" {
+			versions[version] = true
+		}
+	}
+
+	if len(versions) != 1 {
+		if len(versions) > 1 {
+			found := make([]string, 0, len(versions))
+			for version := range versions {
+				found = append(found, version)
+			}
+			sort.Strings(found)
+			ctrl.LoggerFrom(ctx).Info("Pods of developer service run several versions, not deriving a subset",
+				"service", service.Name, "namespace", service.Namespace, "label", subsetLabel, "versions", found)
+		}
+		return "", nil
+	}
+
+	for version := range versions {
+		return version, nil
+	}
+	return "", nil
+}
+
+// podVersionPredicate passes pod events that can change the subset derived for a service: pods appearing,
+// disappearing or starting to terminate, and changes to the subset label. Status updates, by far the most
+// frequent pod updates, are dropped without loading the config.
+func (r *ServiceReconciler) podVersionPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(event.CreateEvent) bool { return true },
+		DeleteFunc: func(event.DeleteEvent) bool { return true },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if (e.ObjectOld.GetDeletionTimestamp() == nil) != (e.ObjectNew.GetDeletionTimestamp() == nil) {
+				return true
+			}
+			if reflect.DeepEqual(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels()) {
+				return false
+			}
+			operatorConfig, err := r.ConfigManager.GetConfig(context.Background())
+			if err != nil {
+				return true
+			}
+			return e.ObjectOld.GetLabels()[operatorConfig.SubsetLabel] != e.ObjectNew.GetLabels()[operatorConfig.SubsetLabel]
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// podToRequests reconciles the developer services selecting a pod when subsets are derived from pods, so the
+// route and DestinationRule follow a rollout to a new version instead of pinning the route to a subset whose
+// pods are gone
+func (r *ServiceReconciler) podToRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := ctrl.LoggerFrom(ctx)
+
+	operatorConfig, err := r.ConfigManager.GetConfig(ctx)
+	if err != nil {
+		log.Error(err, "Failed to get operator config for pod event", "pod", obj.GetName(), "namespace", obj.GetNamespace())
+		return nil
+	}
+	if !operatorConfig.DeriveSubsetsFromPods || !operatorConfig.GenerateDestinationRules || !isDeveloperNamespace(obj.GetNamespace(), operatorConfig) {
+		return nil
+	}
+
+	serviceList := &corev1.ServiceList{}
+	if err := r.List(ctx, serviceList, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error(err, "Failed to list services for pod event", "pod", obj.GetName(), "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range serviceList.Items {
+		service := &serviceList.Items[i]
+		// The subset annotation wins over the pods, and selectorless services select no pods
+		if getAnnotation(service, subsetAnnotation) != "" || len(service.Spec.Selector) == 0 {
+			continue
+		}
+		if labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(obj.GetLabels())) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: service.Name, Namespace: service.Namespace},
+			})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"virtualservice-operator/internal/utils"
+)
+
+const subsetsTestConfig = handlerTestConfig + `generateDestinationRules: true
+deriveSubsetsFromPods: true
+subsetLabel: release
+`
+
+// newPod builds a pod of the app in a namespace with the labels on top of the app selector label
+func newPod(namespace, name string, labels map[string]string) *corev1.Pod {
+	podLabels := map[string]string{"app": "app"}
+	for key, value := range labels {
+		podLabels[key] = value
+	}
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: podLabels}}
+}
+
+func TestPodVersionSubset(t *testing.T) {
+	terminating := newPod("alice", "app-old", map[string]string{"release": "v1"})
+	now := metav1.Now()
+	terminating.DeletionTimestamp = &now
+	terminating.Finalizers = []string{"example.com/hold"}
+
+	tests := []struct {
+		name string
+		pods []client.Object
+		want string
+	}{
+		{name: "one version", pods: []client.Object{newPod("alice", "app-1", map[string]string{"release": "v2"}), newPod("alice", "app-2", map[string]string{"release": "v2"})}, want: "v2"},
+		{name: "several versions", pods: []client.Object{newPod("alice", "app-1", map[string]string{"release": "v1"}), newPod("alice", "app-2", map[string]string{"release": "v2"})}},
+		{name: "unlabeled pods are ignored", pods: []client.Object{newPod("alice", "app-1", map[string]string{"release": "v2"}), newPod("alice", "app-2", nil)}, want: "v2"},
+		{name: "terminating pods are ignored", pods: []client.Object{newPod("alice", "app-1", map[string]string{"release": "v2"}), terminating}, want: "v2"},
+		{name: "other label key", pods: []client.Object{newPod("alice", "app-1", map[string]string{"version": "v2"})}},
+		{name: "pods of another service", pods: []client.Object{&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "alice", Name: "other", Labels: map[string]string{"app": "other", "release": "v2"}}}}},
+		{name: "pods in another namespace", pods: []client.Object{newPod("bob", "app-1", map[string]string{"release": "v2"})}},
+		{name: "no pods"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, testConfig(t, subsetsTestConfig), tt.pods)
+			got, err := env.reconciler.podVersionSubset(context.Background(), newService("alice", "app", nil), "release")
+			if err != nil || got != tt.want {
+				t.Errorf("podVersionSubset() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	// A selectorless service selects no pods
+	env := newTestEnv(t, testConfig(t, subsetsTestConfig), []client.Object{newPod("alice", "app-1", map[string]string{"release": "v2"})})
+	selectorless := newService("alice", "app", nil)
+	selectorless.Spec.Selector = nil
+	if got, err := env.reconciler.podVersionSubset(context.Background(), selectorless, "release"); got != "" || err != nil {
+		t.Errorf("podVersionSubset() of a selectorless service = %q, %v", got, err)
+	}
+}
+
+func TestDerivedSubsetPinsDeveloperRoute(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, subsetsTestConfig), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+		newService("bob", "app", map[string]string{subsetAnnotation: "canary"}),
+		newPod("alice", "app-1", map[string]string{"release": "v2"}),
+		newPod("bob", "app-1", map[string]string{"release": "v2"}),
+	})
+	env.reconcile("default", "app")
+	env.reconcile("alice", "app")
+	env.reconcile("bob", "app")
+
+	destinations := developerDestinations(env.virtualService("default", "app-virtual-service"))
+	if got := destinations["alice"].GetSubset(); got != "v2" {
+		t.Errorf("alice subset = %q, want v2 derived from its pods", got)
+	}
+	// The annotation wins over the pods
+	if got := destinations["bob"].GetSubset(); got != "canary" {
+		t.Errorf("bob subset = %q, want the annotated canary", got)
+	}
+
+	dr := env.destinationRule("alice", utils.DestinationRuleName("app"))
+	if dr == nil || len(dr.Spec.Subsets) != 1 || dr.Spec.Subsets[0].Name != "v2" || dr.Spec.Subsets[0].Labels["release"] != "v2" {
+		t.Fatalf("alice DestinationRule = %v, want a v2 subset selecting release=v2", dr)
+	}
+
+	// A second version rolling out unpins the route, routing to every version again
+	if err := env.client.Create(context.Background(), newPod("alice", "app-2", map[string]string{"release": "v3"})); err != nil {
+		t.Fatal(err)
+	}
+	env.reconcile("alice", "app")
+	if got := developerDestinations(env.virtualService("default", "app-virtual-service"))["alice"].GetSubset(); got != "" {
+		t.Errorf("alice subset = %q with two versions running, want none", got)
+	}
+	if env.destinationRule("alice", utils.DestinationRuleName("app")) != nil {
+		t.Error("DestinationRule kept with two versions running")
+	}
+}
+
+// reconcilePodEvent reconciles the services a pod event maps to, like the pod watch would
+func (e *testEnv) reconcilePodEvent(pod *corev1.Pod) []reconcile.Request {
+	e.t.Helper()
+	requests := e.reconciler.podToRequests(context.Background(), pod)
+	for _, request := range requests {
+		e.reconcile(request.Namespace, request.Name)
+	}
+	return requests
+}
+
+func TestPodRolloutMovesDerivedSubset(t *testing.T) {
+	v1 := newPod("alice", "app-1", map[string]string{"release": "v1"})
+	env := newTestEnv(t, testConfig(t, subsetsTestConfig), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+		v1,
+	})
+	env.reconcile("default", "app")
+	env.reconcile("alice", "app")
+
+	// assertSubset checks the developer route and the DestinationRule are pinned to the subset
+	assertSubset := func(step, want string) {
+		t.Helper()
+		if got := developerDestinations(env.virtualService("default", "app-virtual-service"))["alice"].GetSubset(); got != want {
+			t.Errorf("%s: alice route subset = %q, want %q", step, got, want)
+		}
+		dr := env.destinationRule("alice", utils.DestinationRuleName("app"))
+		switch {
+		case want == "" && dr != nil:
+			t.Errorf("%s: DestinationRule kept without a subset", step)
+		case want != "" && (dr == nil || len(dr.Spec.Subsets) != 1 || dr.Spec.Subsets[0].Labels["release"] != want):
+			t.Errorf("%s: DestinationRule = %v, want a %s subset", step, dr, want)
+		}
+	}
+	assertSubset("before the rollout", "v1")
+
+	// The new version's pod unpins the route, the old pod going away pins it to the new version
+	v2 := newPod("alice", "app-2", map[string]string{"release": "v2"})
+	if err := env.client.Create(context.Background(), v2); err != nil {
+		t.Fatal(err)
+	}
+	if requests := env.reconcilePodEvent(v2); len(requests) != 1 || requests[0].Name != "app" {
+		t.Fatalf("requests = %v, want alice's app", requests)
+	}
+	assertSubset("during the rollout", "")
+
+	env.deleteObject(v1)
+	env.reconcilePodEvent(v1)
+	assertSubset("after the rollout", "v2")
+}
+
+func TestPodToRequests(t *testing.T) {
+	pod := newPod("alice", "app-1", map[string]string{"release": "v1"})
+	tests := []struct {
+		name   string
+		config string
+		pod    *corev1.Pod
+		want   []string
+	}{
+		{name: "selecting service", config: subsetsTestConfig, pod: pod, want: []string{"app"}},
+		{name: "subsets not derived", config: handlerTestConfig + "generateDestinationRules: true\n", pod: pod},
+		{name: "default namespace pod", config: subsetsTestConfig, pod: newPod("default", "app-1", map[string]string{"release": "v1"})},
+		{name: "pod of no service", config: subsetsTestConfig, pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "alice", Name: "job", Labels: map[string]string{"app": "job"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, testConfig(t, tt.config), []client.Object{
+				newService("alice", "app", nil),
+				newService("alice", "pinned", map[string]string{subsetAnnotation: "canary"}),
+				newService("default", "app", nil),
+			})
+			// The annotated service selects the pod too, but its subset doesn't come from the pods
+			env.updateService("alice", "pinned", func(service *corev1.Service) { service.Spec.Selector = map[string]string{"app": "app"} })
+
+			var got []string
+			for _, request := range env.reconciler.podToRequests(context.Background(), tt.pod) {
+				got = append(got, request.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requests = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodVersionPredicate(t *testing.T) {
+	env := newTestEnv(t, testConfig(t, subsetsTestConfig), nil)
+	predicate := env.reconciler.podVersionPredicate()
+	old := newPod("alice", "app-1", map[string]string{"release": "v1"})
+	now := metav1.Now()
+
+	tests := []struct {
+		name   string
+		change func(pod *corev1.Pod)
+		want   bool
+	}{
+		{name: "status update", change: func(pod *corev1.Pod) { pod.Status.Phase = corev1.PodRunning }},
+		{name: "subset label changed", change: func(pod *corev1.Pod) { pod.Labels["release"] = "v2" }, want: true},
+		{name: "other label changed", change: func(pod *corev1.Pod) { pod.Labels["team"] = "web" }},
+		{name: "terminating", change: func(pod *corev1.Pod) { pod.DeletionTimestamp = &now }, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := old.DeepCopy()
+			tt.change(updated)
+			if got := predicate.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: updated}); got != tt.want {
+				t.Errorf("Update() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,394 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"virtualservice-operator/api/v1alpha1"
+	"virtualservice-operator/internal/config"
+	"virtualservice-operator/internal/utils"
+)
+
+// testScheme holds every type the reconciler reads or writes
+var testScheme = func() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(istionetworkingv1beta1.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	return scheme
+}()
+
+// testConfigMapKey is the operator ConfigMap the fake config claims to be read from
+var testConfigMapKey = types.NamespacedName{Namespace: "virtualservice-operator-system", Name: "virtualservice-operator-config"}
+
+// testConfig parses a config.yaml the way the operator does, so the defaults apply
+func testConfig(t testing.TB, configYAML string) *config.OperatorConfig {
+	t.Helper()
+	operatorConfig, err := config.ParseConfig([]byte(configYAML), config.FormatYAML)
+	if err != nil {
+		t.Fatalf("invalid test config: %v", err)
+	}
+	return operatorConfig
+}
+
+// fakeConfig is a config.Provider serving a fixed configuration without a ConfigMap
+type fakeConfig struct {
+	mu     sync.Mutex
+	config *config.OperatorConfig
+}
+
+var _ config.Provider = &fakeConfig{}
+
+// set replaces the served configuration, e.g. to pause the operator
+func (f *fakeConfig) set(operatorConfig *config.OperatorConfig) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.config = operatorConfig
+}
+
+func (f *fakeConfig) GetConfig(context.Context) (*config.OperatorConfig, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// Callers may modify their copy, like they may modify a freshly parsed config
+	operatorConfig := *f.config
+	operatorConfig.DeveloperNamespaces = append([]string(nil), f.config.DeveloperNamespaces...)
+	return &operatorConfig, nil
+}
+
+func (f *fakeConfig) GetWatchedNamespaces(ctx context.Context) ([]string, error) {
+	operatorConfig, _ := f.GetConfig(ctx)
+	return append([]string{operatorConfig.DefaultNamespace}, operatorConfig.DeveloperNamespaces...), nil
+}
+
+func (f *fakeConfig) IsWatchedNamespace(ctx context.Context, namespace string) bool {
+	watched, _ := f.GetWatchedNamespaces(ctx)
+	for _, ns := range watched {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeConfig) IsSelectedService(ctx context.Context, service metav1.Object) bool {
+	operatorConfig, _ := f.GetConfig(ctx)
+	return service.GetNamespace() != operatorConfig.DefaultNamespace || operatorConfig.SelectsService(service)
+}
+
+func (f *fakeConfig) ConfigMapKey() types.NamespacedName {
+	return testConfigMapKey
+}
+
+// fakeClock is a Clock that only moves when advanced
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.Now().Add(d)
+	return ch
+}
+
+// advance moves the clock forward
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// write is a create, update, patch or delete that reached the fake API server, dry-runs excluded
+type write struct {
+	verb   string
+	object client.Object
+}
+
+// testEnv is a ServiceReconciler backed by a fake API server that records the writes made through it
+type testEnv struct {
+	t          testing.TB
+	reconciler *ServiceReconciler
+	client     client.WithWatch
+	config     *fakeConfig
+	clock      *fakeClock
+	recorder   *record.FakeRecorder
+
+	mu     sync.Mutex
+	writes []write
+}
+
+// testEnvOption customizes the fake client of a testEnv
+type testEnvOption func(*fake.ClientBuilder, *interceptor.Funcs)
+
+// withInterceptor runs intercepting functions before the write recording, e.g. to inject API errors
+func withInterceptor(funcs interceptor.Funcs) testEnvOption {
+	return func(_ *fake.ClientBuilder, f *interceptor.Funcs) {
+		*f = funcs
+	}
+}
+
+// withBuilder customizes the fake client builder, e.g. to register an index
+func withBuilder(customize func(*fake.ClientBuilder)) testEnvOption {
+	return func(b *fake.ClientBuilder, _ *interceptor.Funcs) {
+		customize(b)
+	}
+}
+
+// newTestEnv creates a reconciler for the configuration with the objects already in the fake API server
+func newTestEnv(t testing.TB, operatorConfig *config.OperatorConfig, objects []client.Object, opts ...testEnvOption) *testEnv {
+	t.Helper()
+	env := &testEnv{
+		t:        t,
+		config:   &fakeConfig{config: operatorConfig},
+		clock:    &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		recorder: record.NewFakeRecorder(100),
+	}
+
+	builder := fake.NewClientBuilder().
+		WithScheme(testScheme).
+		WithObjects(objects...).
+		WithIndex(&corev1.Service{}, serviceNameIndex, indexServiceName)
+	var injected interceptor.Funcs
+	for _, opt := range opts {
+		opt(builder, &injected)
+	}
+
+	env.client = interceptor.NewClient(builder.Build(), env.recordingFuncs(injected))
+	env.reconciler = &ServiceReconciler{
+		Client:        env.client,
+		Scheme:        testScheme,
+		ConfigManager: env.config,
+		Recorder:      env.recorder,
+		Clock:         env.clock,
+	}
+	return env
+}
+
+// recordingFuncs records the writes that pass the injected functions
+func (e *testEnv) recordingFuncs(injected interceptor.Funcs) interceptor.Funcs {
+	funcs := injected
+	funcs.Create = func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+		if injected.Create != nil {
+			if err := injected.Create(ctx, c, obj, opts...); err != nil {
+				return err
+			}
+		}
+		createOpts := &client.CreateOptions{}
+		createOpts.ApplyOptions(opts)
+		if err := c.Create(ctx, obj, opts...); err != nil || len(createOpts.DryRun) > 0 {
+			return err
+		}
+		e.record("create", obj)
+		return nil
+	}
+	funcs.Update = func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+		if injected.Update != nil {
+			if err := injected.Update(ctx, c, obj, opts...); err != nil {
+				return err
+			}
+		}
+		updateOpts := &client.UpdateOptions{}
+		updateOpts.ApplyOptions(opts)
+		if err := c.Update(ctx, obj, opts...); err != nil || len(updateOpts.DryRun) > 0 {
+			return err
+		}
+		e.record("update", obj)
+		return nil
+	}
+	funcs.Patch = func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+		if injected.Patch != nil {
+			if err := injected.Patch(ctx, c, obj, patch, opts...); err != nil {
+				return err
+			}
+		}
+		if err := c.Patch(ctx, obj, patch, opts...); err != nil {
+			return err
+		}
+		e.record("patch", obj)
+		return nil
+	}
+	funcs.Delete = func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+		if injected.Delete != nil {
+			if err := injected.Delete(ctx, c, obj, opts...); err != nil {
+				return err
+			}
+		}
+		if err := c.Delete(ctx, obj, opts...); err != nil {
+			return err
+		}
+		e.record("delete", obj)
+		return nil
+	}
+	return funcs
+}
+
+func (e *testEnv) record(verb string, obj client.Object) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.writes = append(e.writes, write{verb: verb, object: obj.DeepCopyObject().(client.Object)})
+}
+
+// takeWrites returns the writes recorded since the last call
+func (e *testEnv) takeWrites() []write {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	writes := e.writes
+	e.writes = nil
+	return writes
+}
+
+// reconcile runs one reconcile of a service and fails the test on error
+func (e *testEnv) reconcile(namespace, name string) ctrl.Result {
+	e.t.Helper()
+	result, err := e.reconciler.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: namespace, Name: name},
+	})
+	if err != nil {
+		e.t.Fatalf("reconcile of %s/%s failed: %v", namespace, name, err)
+	}
+	return result
+}
+
+// virtualService returns the VirtualService, nil if it doesn't exist
+func (e *testEnv) virtualService(namespace, name string) *istionetworkingv1beta1.VirtualService {
+	e.t.Helper()
+	vs := &istionetworkingv1beta1.VirtualService{}
+	if err := e.client.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, vs); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			e.t.Fatalf("failed to get VirtualService %s/%s: %v", namespace, name, err)
+		}
+		return nil
+	}
+	return vs
+}
+
+// service returns the service, nil if it doesn't exist
+func (e *testEnv) service(namespace, name string) *corev1.Service {
+	e.t.Helper()
+	service := &corev1.Service{}
+	if err := e.client.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, service); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			e.t.Fatalf("failed to get service %s/%s: %v", namespace, name, err)
+		}
+		return nil
+	}
+	return service
+}
+
+// destinationRule returns the DestinationRule, nil if it doesn't exist
+func (e *testEnv) destinationRule(namespace, name string) *istionetworkingv1beta1.DestinationRule {
+	e.t.Helper()
+	dr := &istionetworkingv1beta1.DestinationRule{}
+	if err := e.client.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, dr); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			e.t.Fatalf("failed to get DestinationRule %s/%s: %v", namespace, name, err)
+		}
+		return nil
+	}
+	return dr
+}
+
+// sidecar returns the egress Sidecar of a namespace, nil if it doesn't exist
+func (e *testEnv) sidecar(namespace string) *istionetworkingv1beta1.Sidecar {
+	e.t.Helper()
+	sidecar := &istionetworkingv1beta1.Sidecar{}
+	if err := e.client.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: utils.SidecarName}, sidecar); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			e.t.Fatalf("failed to get Sidecar in %s: %v", namespace, err)
+		}
+		return nil
+	}
+	return sidecar
+}
+
+// updateService changes a service in the fake API server without recording the write, like a user would
+func (e *testEnv) updateService(namespace, name string, mutate func(*corev1.Service)) {
+	e.t.Helper()
+	service := e.service(namespace, name)
+	if service == nil {
+		e.t.Fatalf("service %s/%s doesn't exist", namespace, name)
+	}
+	mutate(service)
+	if err := e.client.Update(context.Background(), service); err != nil {
+		e.t.Fatalf("failed to update service %s/%s: %v", namespace, name, err)
+	}
+	e.takeWrites()
+}
+
+// deleteObject deletes an object from the fake API server without recording the write
+func (e *testEnv) deleteObject(obj client.Object) {
+	e.t.Helper()
+	if err := e.client.Delete(context.Background(), obj); err != nil {
+		e.t.Fatalf("failed to delete %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+	}
+	e.takeWrites()
+}
+
+// developerDestinations returns the destination of the developer route of each namespace
+func developerDestinations(vs *istionetworkingv1beta1.VirtualService) map[string]*istiov1beta1.Destination {
+	destinations := map[string]*istiov1beta1.Destination{}
+	for _, route := range vs.Spec.Http {
+		if ns, ok := utils.DeveloperRouteNamespace(route); ok {
+			destinations[ns] = route.Route[0].Destination
+		}
+	}
+	return destinations
+}
+
+// recordedEvent drains the recorded events and reports if one of them contains every fragment
+func recordedEvent(recorder *record.FakeRecorder, fragments ...string) bool {
+	found := false
+	for {
+		select {
+		case e := <-recorder.Events:
+			matches := true
+			for _, fragment := range fragments {
+				matches = matches && strings.Contains(e, fragment)
+			}
+			found = found || matches
+		default:
+			return found
+		}
+	}
+}
+
+// newService builds a ClusterIP service with an HTTP port
+func newService(namespace, name string, annotations map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name,
+			UID:         types.UID(namespace + "-" + name),
+			Annotations: annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: "10.0.0.1",
+			Selector:  map[string]string{"app": name},
+			Ports:     []corev1.ServicePort{{Name: "http", Port: 80, Protocol: corev1.ProtocolTCP}},
+		},
+	}
+}
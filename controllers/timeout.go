@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"virtualservice-operator/internal/config"
+)
+
+// timeoutAnnotation on a default namespace service overrides the configured routeTimeout for the default
+// and developer routes of its VirtualService, e.g. "30s"
+const timeoutAnnotation = "virtualservice-operator/timeout"
+
+// parseRouteTimeout parses the timeout annotation, which must be a positive duration
+func parseRouteTimeout(value string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", value, err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q: must be positive", value)
+	}
+	return timeout, nil
+}
+
+// routeTimeout returns the timeout for the routes of a default namespace service: the timeout annotation
+// if present and valid, otherwise the configured routeTimeout. Zero leaves the routes without a timeout.
+func (r *ServiceReconciler) routeTimeout(ctx context.Context, service *corev1.Service, config *config.OperatorConfig) time.Duration {
+	if service == nil || !hasAnnotation(service, timeoutAnnotation) {
+		return config.RouteTimeout.Duration
+	}
+
+	timeout, err := parseRouteTimeout(getAnnotation(service, timeoutAnnotation))
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Info("Ignoring invalid timeout annotation, using the configured route timeout",
+			"service", service.Name, "namespace", service.Namespace, "error", err.Error())
+		r.recorder().Eventf(service, corev1.EventTypeWarning, "InvalidAnnotation", "Ignoring timeout: %v", err)
+		return config.RouteTimeout.Duration
+	}
+	return timeout
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestParseRouteTimeout(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "30s", want: 30 * time.Second},
+		{value: "1m30s", want: 90 * time.Second},
+		{value: "soon", wantErr: true},
+		{value: "30", wantErr: true},
+		{value: "0s", wantErr: true},
+		{value: "-5s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseRouteTimeout(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRouteTimeout(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseRouteTimeout(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteTimeoutAnnotation(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		annotation  string
+		want        time.Duration
+		wantWarning bool
+	}{
+		{name: "no timeout", config: "developerNamespaces: [alice, bob]\n"},
+		{name: "configured", config: "developerNamespaces: [alice, bob]\nrouteTimeout: 10s\n", want: 10 * time.Second},
+		{name: "override", config: "developerNamespaces: [alice, bob]\nrouteTimeout: 10s\n", annotation: "30s", want: 30 * time.Second},
+		{name: "override without configured timeout", config: "developerNamespaces: [alice, bob]\n", annotation: "30s", want: 30 * time.Second},
+		{name: "invalid falls back", config: "developerNamespaces: [alice, bob]\nrouteTimeout: 10s\n", annotation: "soon", want: 10 * time.Second, wantWarning: true},
+		{name: "negative falls back", config: "developerNamespaces: [alice, bob]\nrouteTimeout: 10s\n", annotation: "-5s", want: 10 * time.Second, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var annotations map[string]string
+			if tt.annotation != "" {
+				annotations = map[string]string{timeoutAnnotation: tt.annotation}
+			}
+			env := newTestEnv(t, testConfig(t, tt.config), []client.Object{
+				newService("default", "app", annotations),
+				newService("alice", "app", nil),
+			})
+			env.reconcile("default", "app")
+
+			// A developer service appearing later gets the timeout too
+			if err := env.client.Create(context.Background(), newService("bob", "app", nil)); err != nil {
+				t.Fatal(err)
+			}
+			env.reconcile("bob", "app")
+
+			vs := env.virtualService("default", "app-virtual-service")
+			if len(vs.Spec.Http) != 3 {
+				t.Fatalf("%d routes, want alice, bob and the default route", len(vs.Spec.Http))
+			}
+			for i, route := range vs.Spec.Http {
+				var got time.Duration
+				if route.Timeout != nil {
+					got = route.Timeout.AsDuration()
+				}
+				if got != tt.want {
+					t.Errorf("route %d timeout = %v, want %v", i, got, tt.want)
+				}
+			}
+			if warned := recordedEvent(env.recorder, "InvalidAnnotation", "timeout"); warned != tt.wantWarning {
+				t.Errorf("InvalidAnnotation warning = %v, want %v", warned, tt.wantWarning)
+			}
+		})
+	}
+}
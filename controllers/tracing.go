@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the reconcile spans. It uses the global tracer provider, which is a no-op
+// unless main installs an exporter, so tracing costs nothing by default.
+var tracer = otel.Tracer("virtualservice-operator/controllers")
+
+// endSpan records the outcome of the traced operation and ends the span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	spanExporter    = tracetest.NewInMemoryExporter()
+	installProvider sync.Once
+)
+
+// recordSpans routes the spans of the package tracer to the in-memory exporter. The global provider
+// can only be delegated to once, so it is installed for the whole test binary and reset per test.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	installProvider.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanExporter)))
+	})
+	spanExporter.Reset()
+	t.Cleanup(spanExporter.Reset)
+	return spanExporter
+}
+
+// spanNamed returns the first recorded span with the name
+func spanNamed(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("no %s span recorded", name)
+	return tracetest.SpanStub{}
+}
+
+// assertChildOf fails the test unless the span was started within the parent span
+func assertChildOf(t *testing.T, child, parent tracetest.SpanStub) {
+	t.Helper()
+	if child.Parent.SpanID() != parent.SpanContext.SpanID() {
+		t.Errorf("%s span is not a child of the %s span", child.Name, parent.Name)
+	}
+}
+
+// assertAttributes fails the test unless the span has every attribute
+func assertAttributes(t *testing.T, span tracetest.SpanStub, want ...attribute.KeyValue) {
+	t.Helper()
+	got := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes {
+		got[kv.Key] = kv.Value
+	}
+	for _, kv := range want {
+		if value, ok := got[kv.Key]; !ok || value != kv.Value {
+			t.Errorf("%s span attribute %s = %v, want %v", span.Name, kv.Key, value.Emit(), kv.Value.Emit())
+		}
+	}
+}
+
+func TestReconcileSpansForCreate(t *testing.T) {
+	exporter := recordSpans(t)
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{
+		newService("default", "app", nil),
+		newService("alice", "app", nil),
+	})
+
+	env.reconcile("default", "app")
+
+	spans := exporter.GetSpans()
+	reconcile := spanNamed(t, spans, "Reconcile")
+	handle := spanNamed(t, spans, "handleDefaultNamespaceService")
+	collect := spanNamed(t, spans, "collectDeveloperRoutes")
+
+	if reconcile.Parent.IsValid() {
+		t.Error("Reconcile span is not a root span")
+	}
+	assertChildOf(t, handle, reconcile)
+	assertChildOf(t, collect, handle)
+
+	assertAttributes(t, reconcile, attribute.String("namespace", "default"), attribute.String("service", "app"))
+	assertAttributes(t, handle, attribute.String("namespace", "default"), attribute.String("service", "app"), attribute.String("action", "create"))
+	assertAttributes(t, collect, attribute.String("service", "app"))
+}
+
+func TestReconcileSpansForUpdate(t *testing.T) {
+	exporter := recordSpans(t)
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{newService("default", "app", nil)})
+	env.reconcile("default", "app")
+	exporter.Reset()
+
+	env.reconcile("default", "app")
+
+	spans := exporter.GetSpans()
+	handle := spanNamed(t, spans, "handleDefaultNamespaceService")
+	update := spanNamed(t, spans, "retryVirtualServiceUpdate")
+
+	assertChildOf(t, update, handle)
+	assertAttributes(t, handle, attribute.String("action", "update"))
+	assertAttributes(t, update,
+		attribute.String("namespace", "default"),
+		attribute.String("virtualService", "app-virtual-service"),
+		attribute.Int("retries", 0),
+	)
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+)
+
+// dryRunVirtualService submits the VirtualService with server-side dry-run before it is written, so the
+// API server and Istio's validating webhook check the exact object without persisting it. A rejected
+// spec is reported with a Warning event and returned as a terminal error: requeueing can't fix it and
+// would only hit the API server in a tight loop.
+func (r *ServiceReconciler) dryRunVirtualService(ctx context.Context, vs *istionetworkingv1beta1.VirtualService, create bool) error {
+	candidate := vs.DeepCopy()
+
+	var err error
+	if create {
+		err = r.Create(ctx, candidate, client.DryRunAll)
+	} else {
+		err = r.Update(ctx, candidate, client.DryRunAll)
+	}
+	if err == nil || !isRejectedSpec(err) {
+		return err
+	}
+
+	ctrl.LoggerFrom(ctx).Error(err, "VirtualService rejected by dry-run validation", "virtualService", vs.Name, "namespace", vs.Namespace)
+	r.recorder().Eventf(vs, corev1.EventTypeWarning, "InvalidVirtualService",
+		"Generated VirtualService was rejected by validation: %v", err)
+	return reconcile.TerminalError(fmt.Errorf("generated VirtualService %s/%s is invalid: %w", vs.Namespace, vs.Name, err))
+}
+
+// isRejectedSpec checks if the API server refused the object itself. Schema validation fails with
+// Invalid, a denying admission webhook such as Istio's validation with BadRequest.
+func isRejectedSpec(err error) bool {
+	return errors.IsInvalid(err) || errors.IsBadRequest(err)
+}
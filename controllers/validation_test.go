@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// dryRunRejecter fails dry-run writes of VirtualServices with err while enabled and counts them
+type dryRunRejecter struct {
+	err     error
+	enabled atomic.Bool
+	dryRuns atomic.Int32
+}
+
+func (d *dryRunRejecter) reject(obj client.Object, dryRun []string) error {
+	if _, ok := obj.(*istionetworkingv1beta1.VirtualService); !ok || len(dryRun) == 0 || !d.enabled.Load() {
+		return nil
+	}
+	d.dryRuns.Add(1)
+	return d.err
+}
+
+func (d *dryRunRejecter) option() testEnvOption {
+	return withInterceptor(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			createOpts := &client.CreateOptions{}
+			createOpts.ApplyOptions(opts)
+			return d.reject(obj, createOpts.DryRun)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			updateOpts := &client.UpdateOptions{}
+			updateOpts.ApplyOptions(opts)
+			return d.reject(obj, updateOpts.DryRun)
+		},
+	})
+}
+
+// reconcileErr runs one reconcile of a service and returns its error instead of failing the test
+func (e *testEnv) reconcileErr(namespace, name string) error {
+	_, err := e.reconciler.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: namespace, Name: name},
+	})
+	return err
+}
+
+func TestDryRunRejectionOnCreate(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{
+			name: "schema validation",
+			err: apierrors.NewInvalid(schema.GroupKind{Group: "networking.istio.io", Kind: "VirtualService"}, "app-virtual-service",
+				field.ErrorList{field.Invalid(field.NewPath("spec", "hosts"), "app", "rejected")}),
+		},
+		{
+			name: "admission webhook",
+			err:  apierrors.NewBadRequest(`admission webhook "validation.istio.io" denied the request`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rejecter := &dryRunRejecter{err: tt.err}
+			rejecter.enabled.Store(true)
+			env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{newService("default", "app", nil)}, rejecter.option())
+
+			err := env.reconcileErr("default", "app")
+			if !errors.Is(err, reconcile.TerminalError(nil)) {
+				t.Fatalf("reconcile error = %v, want a terminal error", err)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("reconcile error = %v, want it to wrap %v", err, tt.err)
+			}
+			if env.virtualService("default", "app-virtual-service") != nil {
+				t.Error("rejected VirtualService was created")
+			}
+			if !recordedEvent(env.recorder, "InvalidVirtualService") {
+				t.Error("no InvalidVirtualService event")
+			}
+			if n := rejecter.dryRuns.Load(); n != 1 {
+				t.Errorf("%d dry-runs, want 1", n)
+			}
+		})
+	}
+}
+
+func TestDryRunRejectionOnUpdate(t *testing.T) {
+	rejecter := &dryRunRejecter{err: apierrors.NewBadRequest(`admission webhook "validation.istio.io" denied the request`)}
+	env := newTestEnv(t, testConfig(t, "defaultNamespace: default\ndeveloperNamespaces: [alice, bob]\n"), []client.Object{newService("default", "app", nil)}, rejecter.option())
+	env.reconcile("default", "app")
+	before := env.virtualService("default", "app-virtual-service")
+	if before == nil {
+		t.Fatal("VirtualService not created")
+	}
+
+	// The alice route changes the VirtualService, its update is rejected
+	if err := env.client.Create(context.Background(), newService("alice", "app", nil)); err != nil {
+		t.Fatal(err)
+	}
+	rejecter.enabled.Store(true)
+
+	err := env.reconcileErr("default", "app")
+	if !errors.Is(err, reconcile.TerminalError(nil)) {
+		t.Fatalf("reconcile error = %v, want a terminal error", err)
+	}
+	if n := rejecter.dryRuns.Load(); n != 1 {
+		t.Errorf("%d dry-runs, want 1 without retries", n)
+	}
+	if !recordedEvent(env.recorder, "InvalidVirtualService") {
+		t.Error("no InvalidVirtualService event")
+	}
+	after := env.virtualService("default", "app-virtual-service")
+	if after.ResourceVersion != before.ResourceVersion {
+		t.Error("rejected update was written")
+	}
+	if namespaces := routedDeveloperNamespaces(after); len(namespaces) != 0 {
+		t.Errorf("developer routes = %v, want none", namespaces)
+	}
+}
+
+func TestDryRunOtherErrorIsNotTerminal(t *testing.T) {
+	rejecter := &dryRunRejecter{err: apierrors.NewForbidden(schema.GroupResource{Group: "networking.istio.io", Resource: "virtualservices"}, "app-virtual-service", errors.New("no RBAC"))}
+	rejecter.enabled.Store(true)
+	env := newTestEnv(t, testConfig(t, handlerTestConfig), []client.Object{newService("default", "app", nil)}, rejecter.option())
+
+	err := env.reconcileErr("default", "app")
+	if err == nil {
+		t.Fatal("reconcile succeeded, want the dry-run error")
+	}
+	if errors.Is(err, reconcile.TerminalError(nil)) {
+		t.Errorf("reconcile error = %v is terminal, want it requeued", err)
+	}
+	if recordedEvent(env.recorder, "InvalidVirtualService") {
+		t.Error("InvalidVirtualService event for an error that isn't a rejection")
+	}
+}
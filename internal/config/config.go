@@ -2,27 +2,161 @@ package config
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"path"
+	"strings"
+	"sync/atomic"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/yaml"
 )
 
+// Config formats accepted by ParseConfig
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// WatchAnnotation set to "true" on a namespace enrolls it as a developer namespace when
+// watchAnnotatedNamespaces is enabled, so teams can onboard their namespaces themselves
+const WatchAnnotation = "virtualservice-operator/watch"
+
 // OperatorConfig represents the operator configuration
 type OperatorConfig struct {
-	DefaultNamespace          string   `yaml:"defaultNamespace"`
-	DeveloperNamespaces       []string `yaml:"developerNamespaces"`
-	VirtualServiceTemplate    string   `yaml:"virtualServiceTemplate"`
-	EnablePlaceholderServices bool     `yaml:"enablePlaceholderServices"`
+	DefaultNamespace    string   `yaml:"defaultNamespace"`
+	DeveloperNamespaces []string `yaml:"developerNamespaces"`
+	// Paused stops the operator from creating, updating or deleting anything while events are still received
+	Paused bool `yaml:"paused"`
+	// DeveloperNamespaceSelector adds every namespace whose labels match it to the developer namespaces
+	DeveloperNamespaceSelector *metav1.LabelSelector `yaml:"developerNamespaceSelector"`
+	// WatchAnnotatedNamespaces adds every namespace annotated with WatchAnnotation to the developer namespaces
+	WatchAnnotatedNamespaces bool `yaml:"watchAnnotatedNamespaces"`
+	// DeveloperNamespacePatterns holds the glob entries of developerNamespaces (e.g. "dev-*").
+	// It's filled in when the config is loaded, DeveloperNamespaces then only lists concrete namespaces.
+	DeveloperNamespacePatterns []string `json:"-" yaml:"-"`
+	VirtualServiceTemplate     string   `yaml:"virtualServiceTemplate"`
+	EnablePlaceholderServices  bool     `yaml:"enablePlaceholderServices"`
+	// Gateways attaches generated VirtualServices to these gateways ("mesh" or "namespace/name").
+	// Empty leaves them on the mesh only.
+	Gateways []string `yaml:"gateways"`
+	// VirtualServiceAnnotations are added to every generated VirtualService, e.g. hints for Kiali or Flagger
+	VirtualServiceAnnotations map[string]string `yaml:"virtualServiceAnnotations"`
+	// AnnotationPassthroughPrefixes copies annotations of a default namespace service starting with one of
+	// these prefixes (e.g. "kiali.io/") to its VirtualService
+	AnnotationPassthroughPrefixes []string `yaml:"annotationPassthroughPrefixes"`
+	// RemoteDeveloperNamespaces are developer namespaces looked up in the remote cluster, if one is configured
+	RemoteDeveloperNamespaces []string `yaml:"remoteDeveloperNamespaces"`
+	// RemoteClusterDomain is the cluster domain used in destination hosts of remote developer services
+	RemoteClusterDomain string `yaml:"remoteClusterDomain"`
+	// ClusterDomain is the domain of the local cluster, used to recognize in-cluster service names. Defaults to cluster.local.
+	ClusterDomain string `yaml:"clusterDomain"`
+	// UseFQDNHosts makes the host of generated VirtualServices the <service>.<namespace>.svc.<clusterDomain>
+	// FQDN instead of the short service name, for VirtualServices consumed cross-namespace or through a gateway
+	UseFQDNHosts bool `yaml:"useFQDNHosts"`
+	// PlaceholderServiceType is "ExternalName" (default) or "Headless", a selectorless ClusterIP: None service
+	// with Endpoints resolving to the default namespace service
+	PlaceholderServiceType string `yaml:"placeholderServiceType"`
+	// PlaceholderLabels are added to every placeholder service, e.g. for NetworkPolicy selection
+	PlaceholderLabels map[string]string `yaml:"placeholderLabels"`
+	// PlaceholderBackfillWindow spreads namespace-wide placeholder backfills randomly over this window. Zero runs them immediately.
+	PlaceholderBackfillWindow metav1.Duration `yaml:"placeholderBackfillWindow"`
+	// RoutingStrategy selects how developer routes reach the developer namespace: "host" (default) routes
+	// to the developer service, "authority" keeps the default destination and rewrites the authority,
+	// "source" routes to the developer service but matches the calling workload's namespace instead of the header
+	RoutingStrategy string `yaml:"routingStrategy"`
+	// DevHeaderPrefix and DevHeaderSuffix surround the namespace in the x-developer header value developer
+	// routes match, e.g. "ns-" matches "x-developer: ns-<namespace>". Not used by the "source" strategy.
+	DevHeaderPrefix string `yaml:"devHeaderPrefix"`
+	DevHeaderSuffix string `yaml:"devHeaderSuffix"`
+	// FallbackHeader gives every developer route a second route matching this header, e.g. "x-fallback",
+	// tried after all x-developer routes. Empty disables fallback routes.
+	FallbackHeader string `yaml:"fallbackHeader"`
+	// UnmanagedVirtualServicePolicy decides what happens when a VirtualService the operator would create
+	// already exists without the managed-by label: "adopt", "ignore" or "warn" (default)
+	UnmanagedVirtualServicePolicy string `yaml:"unmanagedVirtualServicePolicy"`
+	// GenerateDestinationRules creates a DestinationRule declaring the subset a developer service is pinned to
+	GenerateDestinationRules bool `yaml:"generateDestinationRules"`
+	// GenerateSidecars creates a Sidecar in every developer namespace limiting egress to the namespace itself,
+	// the Istio control plane and the default namespace services
+	GenerateSidecars bool `yaml:"generateSidecars"`
+	// SubsetLabel is the pod label a generated subset selects on, defaults to "version"
+	SubsetLabel string `yaml:"subsetLabel"`
+	// DeriveSubsetsFromPods pins developer routes without a subset annotation to the SubsetLabel value
+	// shared by the developer service's pods. Requires GenerateDestinationRules.
+	DeriveSubsetsFromPods bool `yaml:"deriveSubsetsFromPods"`
+	// ResyncPeriod requeues every successfully reconciled service after this period. Zero disables resync.
+	ResyncPeriod metav1.Duration `yaml:"resyncPeriod"`
+	// MinReconcileInterval is the sustained minimum interval between reconciles of a single service, allowing
+	// short bursts. Reconciles arriving faster are postponed. Zero disables throttling.
+	MinReconcileInterval metav1.Duration `yaml:"minReconcileInterval"`
+	// RouteTimeout is the timeout of the default and developer routes of every VirtualService. Zero sets no timeout.
+	RouteTimeout metav1.Duration `yaml:"routeTimeout"`
+	// MaxRouteAge removes developer routes this long after they were first added, for stale experiments.
+	// A removed route comes back only once its developer service is recreated. Zero keeps routes forever.
+	MaxRouteAge metav1.Duration `yaml:"maxRouteAge"`
+	// StatusConfigMap names a ConfigMap in the operator's namespace the aggregate status document is written to.
+	// No status document is written when empty.
+	StatusConfigMap string `yaml:"statusConfigMap"`
+	// StatusInterval is how often the status document is written, defaults to one minute
+	StatusInterval metav1.Duration `yaml:"statusInterval"`
+	// RequireReadyEndpoints withholds the route of a developer service until it has ready endpoints
+	RequireReadyEndpoints bool `yaml:"requireReadyEndpoints"`
+	// ServiceSelector limits VirtualService generation to default namespace services matching it. All services are selected when unset.
+	ServiceSelector *metav1.LabelSelector `yaml:"serviceSelector"`
 }
 
+// SelectsService checks if a default namespace service matches the ServiceSelector
+func (c *OperatorConfig) SelectsService(service metav1.Object) bool {
+	if c.ServiceSelector == nil {
+		return true
+	}
+	selector, err := metav1.LabelSelectorAsSelector(c.ServiceSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(service.GetLabels()))
+}
+
+// Provider supplies the operator configuration to the reconciler.
+// ConfigManager is the ConfigMap-backed implementation; tests and tools can substitute their own.
+type Provider interface {
+	GetConfig(ctx context.Context) (*OperatorConfig, error)
+	GetWatchedNamespaces(ctx context.Context) ([]string, error)
+	// IsWatchedNamespace is a cheap, concurrency-safe check used by event predicates
+	IsWatchedNamespace(ctx context.Context, namespace string) bool
+	// IsSelectedService is a cheap check whether a service passes the service selector.
+	// Services outside the default namespace are always selected.
+	IsSelectedService(ctx context.Context, service metav1.Object) bool
+	// ConfigMapKey identifies the ConfigMap the configuration is read from
+	ConfigMapKey() types.NamespacedName
+}
+
+var _ Provider = &ConfigManager{}
+
 // ConfigManager manages operator configuration
 type ConfigManager struct {
 	client        client.Client
 	namespace     string
 	configMapName string
+
+	// watched holds the watched namespaces of the last successfully loaded config
+	watched atomic.Pointer[watchState]
+}
+
+// watchState is an immutable snapshot of the namespaces the operator watches
+type watchState struct {
+	namespaces       map[string]struct{}
+	patterns         []string
+	defaultNamespace string
+	selector         labels.Selector
 }
 
 // NewConfigManager creates a new ConfigManager
@@ -45,24 +179,342 @@ func (cm *ConfigManager) GetConfig(ctx context.Context) (*OperatorConfig, error)
 		return nil, fmt.Errorf("failed to get ConfigMap %s/%s: %w", cm.namespace, cm.configMapName, err)
 	}
 
-	configData, exists := configMap.Data["config.yaml"]
-	if !exists {
-		return nil, fmt.Errorf("config.yaml not found in ConfigMap %s/%s", cm.namespace, cm.configMapName)
+	configData, format, err := ConfigMapData(configMap)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := ParseConfig(configData, format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config in ConfigMap %s/%s: %w", cm.namespace, cm.configMapName, err)
+	}
+
+	if err := cm.resolveDeveloperNamespaces(ctx, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// GetDeveloperNamespaces returns the developer namespaces with patterns and the namespace selector expanded to
+// the namespaces currently in the cluster. It never includes the default namespace.
+func (cm *ConfigManager) GetDeveloperNamespaces(ctx context.Context) ([]string, error) {
+	config, err := cm.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return config.DeveloperNamespaces, nil
+}
+
+// resolveDeveloperNamespaces expands pattern entries of DeveloperNamespaces, the DeveloperNamespaceSelector and,
+// if enabled, the namespaces annotated with WatchAnnotation by listing cluster namespaces. The default namespace is never a developer namespace, whichever way it was
+// included; routing it to itself would shadow the default route.
+func (cm *ConfigManager) resolveDeveloperNamespaces(ctx context.Context, config *OperatorConfig) error {
+	log := ctrllog.FromContext(ctx)
+
+	var resolved []string
+	seen := map[string]bool{config.DefaultNamespace: true}
+	for _, ns := range config.DeveloperNamespaces {
+		if IsNamespacePattern(ns) {
+			config.DeveloperNamespacePatterns = append(config.DeveloperNamespacePatterns, ns)
+			continue
+		}
+		if ns == config.DefaultNamespace {
+			log.Info("Excluding default namespace from developer namespaces", "namespace", ns, "source", "developerNamespaces")
+			continue
+		}
+		if !seen[ns] {
+			resolved = append(resolved, ns)
+			seen[ns] = true
+		}
 	}
 
+	if len(config.DeveloperNamespacePatterns) == 0 && config.DeveloperNamespaceSelector == nil && !config.WatchAnnotatedNamespaces {
+		config.DeveloperNamespaces = resolved
+		return nil
+	}
+
+	selector := labels.Nothing()
+	if config.DeveloperNamespaceSelector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(config.DeveloperNamespaceSelector); err != nil {
+			return fmt.Errorf("invalid developer namespace selector: %w", err)
+		}
+	}
+
+	namespaceList := &corev1.NamespaceList{}
+	if err := cm.client.List(ctx, namespaceList); err != nil {
+		return fmt.Errorf("failed to list namespaces to resolve developer namespaces: %w", err)
+	}
+
+	for _, namespace := range namespaceList.Items {
+		byPattern := MatchesNamespacePattern(config.DeveloperNamespacePatterns, namespace.Name)
+		bySelector := selector.Matches(labels.Set(namespace.Labels))
+		byAnnotation := config.WatchAnnotatedNamespaces && namespace.Annotations[WatchAnnotation] == "true"
+		if !byPattern && !bySelector && !byAnnotation {
+			continue
+		}
+		if namespace.Name == config.DefaultNamespace {
+			// The default namespace is always watched, the annotation makes no difference there
+			if byPattern || bySelector {
+				log.Info("Excluding default namespace from developer namespaces", "namespace", namespace.Name,
+					"matchedPattern", byPattern, "matchedSelector", bySelector)
+			}
+			continue
+		}
+		if seen[namespace.Name] {
+			continue
+		}
+		resolved = append(resolved, namespace.Name)
+		seen[namespace.Name] = true
+	}
+
+	config.DeveloperNamespaces = resolved
+	return nil
+}
+
+// IsNamespacePattern checks if a developerNamespaces entry is a glob pattern rather than a namespace name
+func IsNamespacePattern(entry string) bool {
+	return strings.ContainsAny(entry, "*?[")
+}
+
+// MatchesNamespacePattern checks if a namespace matches any of the glob patterns
+func MatchesNamespacePattern(patterns []string, namespace string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, namespace); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigMapData returns the operator configuration carried by a ConfigMap and its format. The config.yaml
+// key is preferred; config.json is read when there is no config.yaml, e.g. for configs generated as JSON.
+func ConfigMapData(configMap *corev1.ConfigMap) ([]byte, string, error) {
+	if data, exists := configMap.Data["config.yaml"]; exists {
+		return []byte(data), FormatYAML, nil
+	}
+	if data, exists := configMap.Data["config.json"]; exists {
+		return []byte(data), FormatJSON, nil
+	}
+	return nil, "", fmt.Errorf("neither config.yaml nor config.json found in ConfigMap %s/%s", configMap.Namespace, configMap.Name)
+}
+
+// ParseConfig unmarshals the operator configuration in the given format, applies defaults and validates the result.
+// Both formats use the same field names, so equivalent YAML and JSON configs parse to the same OperatorConfig.
+func ParseConfig(data []byte, format string) (*OperatorConfig, error) {
 	var config OperatorConfig
-	if err := yaml.Unmarshal([]byte(configData), &config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	switch format {
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		}
+	case FormatJSON:
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format %q, must be %q or %q", format, FormatYAML, FormatJSON)
 	}
 
 	// Set default values if not provided
 	if config.DefaultNamespace == "" {
 		config.DefaultNamespace = "default"
 	}
+	if config.ClusterDomain == "" {
+		config.ClusterDomain = "cluster.local"
+	}
+	if config.RemoteClusterDomain == "" {
+		config.RemoteClusterDomain = "cluster.local"
+	}
+	if config.SubsetLabel == "" {
+		config.SubsetLabel = "version"
+	}
+	if config.RoutingStrategy == "" {
+		config.RoutingStrategy = "host"
+	}
+	if config.UnmanagedVirtualServicePolicy == "" {
+		config.UnmanagedVirtualServicePolicy = "warn"
+	}
+	if config.PlaceholderServiceType == "" {
+		config.PlaceholderServiceType = "ExternalName"
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &config, nil
 }
 
+// Validate checks the configuration for values the operator can't act on
+func (c *OperatorConfig) Validate() error {
+	var allErrs field.ErrorList
+
+	for _, msg := range validation.IsDNS1123Label(c.DefaultNamespace) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("defaultNamespace"), c.DefaultNamespace, msg))
+	}
+
+	allErrs = append(allErrs, validateNamespaceList(field.NewPath("developerNamespaces"), c.DeveloperNamespaces)...)
+	allErrs = append(allErrs, validateNamespaceList(field.NewPath("remoteDeveloperNamespaces"), c.RemoteDeveloperNamespaces)...)
+
+	for key := range c.VirtualServiceAnnotations {
+		for _, msg := range validation.IsQualifiedName(key) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("virtualServiceAnnotations").Key(key), key, msg))
+		}
+		if strings.HasPrefix(key, "virtualservice-operator/") {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("virtualServiceAnnotations").Key(key), "annotations of the operator itself can't be set"))
+		}
+	}
+	for i, prefix := range c.AnnotationPassthroughPrefixes {
+		if prefix == "" || strings.HasPrefix(prefix, "virtualservice-operator/") || strings.HasPrefix("virtualservice-operator/", prefix) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("annotationPassthroughPrefixes").Index(i), prefix, "must be non-empty and must not match the operator's own annotations"))
+		}
+	}
+
+	for i, gateway := range c.Gateways {
+		for _, msg := range ValidateGateway(gateway) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("gateways").Index(i), gateway, msg))
+		}
+	}
+
+	for _, msg := range validation.IsDNS1123Subdomain(c.ClusterDomain) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("clusterDomain"), c.ClusterDomain, msg))
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(c.RemoteClusterDomain) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("remoteClusterDomain"), c.RemoteClusterDomain, msg))
+	}
+
+	for _, msg := range validation.IsQualifiedName(c.SubsetLabel) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("subsetLabel"), c.SubsetLabel, msg))
+	}
+
+	if c.DeriveSubsetsFromPods && !c.GenerateDestinationRules {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("deriveSubsetsFromPods"), c.DeriveSubsetsFromPods, "requires generateDestinationRules"))
+	}
+
+	for name, value := range map[string]string{"devHeaderPrefix": c.DevHeaderPrefix, "devHeaderSuffix": c.DevHeaderSuffix} {
+		if strings.IndexFunc(value, func(r rune) bool { return r <= ' ' || r >= 0x7f }) >= 0 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath(name), value, "must only contain visible ASCII characters"))
+		}
+	}
+
+	if c.FallbackHeader != "" {
+		for _, msg := range validation.IsHTTPHeaderName(c.FallbackHeader) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("fallbackHeader"), c.FallbackHeader, msg))
+		}
+		if strings.EqualFold(c.FallbackHeader, "x-developer") {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("fallbackHeader"), c.FallbackHeader, "must differ from x-developer"))
+		}
+	}
+
+	switch c.RoutingStrategy {
+	case "host", "authority", "source":
+	default:
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("routingStrategy"), c.RoutingStrategy, []string{"host", "authority", "source"}))
+	}
+
+	switch c.UnmanagedVirtualServicePolicy {
+	case "adopt", "ignore", "warn":
+	default:
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("unmanagedVirtualServicePolicy"), c.UnmanagedVirtualServicePolicy, []string{"adopt", "ignore", "warn"}))
+	}
+
+	switch c.PlaceholderServiceType {
+	case "ExternalName", "Headless":
+	default:
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("placeholderServiceType"), c.PlaceholderServiceType, []string{"ExternalName", "Headless"}))
+	}
+
+	for key, value := range c.PlaceholderLabels {
+		for _, msg := range validation.IsQualifiedName(key) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("placeholderLabels").Key(key), key, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(value) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("placeholderLabels").Key(key), value, msg))
+		}
+	}
+
+	if c.PlaceholderBackfillWindow.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("placeholderBackfillWindow"), c.PlaceholderBackfillWindow.Duration.String(), "must not be negative"))
+	}
+
+	if c.ResyncPeriod.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("resyncPeriod"), c.ResyncPeriod.Duration.String(), "must not be negative"))
+	}
+
+	if c.MinReconcileInterval.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("minReconcileInterval"), c.MinReconcileInterval.Duration.String(), "must not be negative"))
+	}
+	if c.StatusConfigMap != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(c.StatusConfigMap) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("statusConfigMap"), c.StatusConfigMap, msg))
+		}
+	}
+	if c.StatusInterval.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("statusInterval"), c.StatusInterval.Duration.String(), "must not be negative"))
+	}
+	if c.MaxRouteAge.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("maxRouteAge"), c.MaxRouteAge.Duration.String(), "must not be negative"))
+	}
+	if c.RouteTimeout.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("routeTimeout"), c.RouteTimeout.Duration.String(), "must not be negative"))
+	}
+
+	if c.DeveloperNamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(c.DeveloperNamespaceSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("developerNamespaceSelector"), c.DeveloperNamespaceSelector, err.Error()))
+		}
+	}
+
+	if c.ServiceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(c.ServiceSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("serviceSelector"), c.ServiceSelector, err.Error()))
+		}
+	}
+
+	return allErrs.ToAggregate()
+}
+
+// ValidateGateway checks that a gateway reference is "mesh" or "namespace/name"
+func ValidateGateway(gateway string) []string {
+	if gateway == "mesh" {
+		return nil
+	}
+	namespace, name, found := strings.Cut(gateway, "/")
+	if !found {
+		return []string{`must be "mesh" or "namespace/name"`}
+	}
+	var msgs []string
+	msgs = append(msgs, validation.IsDNS1123Label(namespace)...)
+	msgs = append(msgs, validation.IsDNS1123Subdomain(name)...)
+	return msgs
+}
+
+// validateNamespaceList checks that every entry is a valid namespace name or pattern and appears only once
+func validateNamespaceList(fieldPath *field.Path, namespaces []string) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := map[string]bool{}
+
+	for i, ns := range namespaces {
+		if IsNamespacePattern(ns) {
+			if _, err := path.Match(ns, ""); err != nil {
+				allErrs = append(allErrs, field.Invalid(fieldPath.Index(i), ns, "invalid namespace pattern"))
+			}
+		} else {
+			for _, msg := range validation.IsDNS1123Label(ns) {
+				allErrs = append(allErrs, field.Invalid(fieldPath.Index(i), ns, msg))
+			}
+		}
+		if seen[ns] {
+			allErrs = append(allErrs, field.Duplicate(fieldPath.Index(i), ns))
+		}
+		seen[ns] = true
+	}
+
+	return allErrs
+}
+
 // GetWatchedNamespaces returns all namespaces that should be watched
 func (cm *ConfigManager) GetWatchedNamespaces(ctx context.Context) ([]string, error) {
 	config, err := cm.GetConfig(ctx)
@@ -73,5 +525,62 @@ func (cm *ConfigManager) GetWatchedNamespaces(ctx context.Context) ([]string, er
 	namespaces := []string{config.DefaultNamespace}
 	namespaces = append(namespaces, config.DeveloperNamespaces...)
 
+	// Refresh the cached state used by IsWatchedNamespace
+	state := &watchState{
+		namespaces:       make(map[string]struct{}, len(namespaces)),
+		patterns:         config.DeveloperNamespacePatterns,
+		defaultNamespace: config.DefaultNamespace,
+		selector:         labels.Everything(),
+	}
+	for _, ns := range namespaces {
+		state.namespaces[ns] = struct{}{}
+	}
+	if config.ServiceSelector != nil {
+		// The selector was validated when the config was parsed
+		if selector, err := metav1.LabelSelectorAsSelector(config.ServiceSelector); err == nil {
+			state.selector = selector
+		}
+	}
+	cm.watched.Store(state)
+
 	return namespaces, nil
 }
+
+// IsWatchedNamespace checks a namespace against the watched namespaces of the last loaded config
+// without reading the ConfigMap. The config is only loaded if it hasn't been loaded yet.
+// Namespaces matching a developer namespace pattern are watched even if they were created after the last load.
+func (cm *ConfigManager) IsWatchedNamespace(ctx context.Context, namespace string) bool {
+	state := cm.watched.Load()
+	if state == nil {
+		if _, err := cm.GetWatchedNamespaces(ctx); err != nil {
+			return false
+		}
+		state = cm.watched.Load()
+	}
+
+	if _, watched := state.namespaces[namespace]; watched {
+		return true
+	}
+	return MatchesNamespacePattern(state.patterns, namespace)
+}
+
+// IsSelectedService checks a service against the service selector of the last loaded config
+func (cm *ConfigManager) IsSelectedService(ctx context.Context, service metav1.Object) bool {
+	state := cm.watched.Load()
+	if state == nil {
+		if _, err := cm.GetWatchedNamespaces(ctx); err != nil {
+			return false
+		}
+		state = cm.watched.Load()
+	}
+
+	if service.GetNamespace() != state.defaultNamespace {
+		return true
+	}
+	return state.selector.Matches(labels.Set(service.GetLabels()))
+}
+
+// ConfigMapKey returns the namespace and name of the operator ConfigMap
+func (cm *ConfigManager) ConfigMapKey() types.NamespacedName {
+	return types.NamespacedName{Namespace: cm.namespace, Name: cm.configMapName}
+}
@@ -0,0 +1,294 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testNamespace = "virtualservice-operator-system"
+	testName      = "virtualservice-operator-config"
+)
+
+// newTestConfigManager creates a ConfigManager reading config.yaml from a fake API server holding the objects
+func newTestConfigManager(t *testing.T, configYAML string, objects ...client.Object) (*ConfigManager, client.Client) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	objects = append(objects, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testName},
+		Data:       map[string]string{"config.yaml": configYAML},
+	})
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	return NewConfigManager(c, testNamespace, testName), c
+}
+
+// namespace builds a namespace with labels and annotations
+func namespace(name string, labels, annotations map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels, Annotations: annotations}}
+}
+
+func TestNamespacePatterns(t *testing.T) {
+	cm, c := newTestConfigManager(t, "defaultNamespace: default\ndeveloperNamespaces: [\"dev-*\", team-x]\n",
+		namespace("default", nil, nil),
+		namespace("dev-alice", nil, nil),
+		namespace("dev-bob", nil, nil),
+		namespace("prod", nil, nil),
+	)
+	ctx := context.Background()
+
+	got, err := cm.GetDeveloperNamespaces(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"team-x", "dev-alice", "dev-bob"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetDeveloperNamespaces() = %v, want %v", got, want)
+	}
+
+	watched, err := cm.GetWatchedNamespaces(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"default", "team-x", "dev-alice", "dev-bob"}; !reflect.DeepEqual(watched, want) {
+		t.Errorf("GetWatchedNamespaces() = %v, want %v", watched, want)
+	}
+
+	// A namespace created after the last load is watched as soon as it matches a pattern
+	if err := c.Create(ctx, namespace("dev-carol", nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+	for ns, want := range map[string]bool{"default": true, "dev-alice": true, "dev-carol": true, "team-x": true, "prod": false, "devs": false} {
+		if got := cm.IsWatchedNamespace(ctx, ns); got != want {
+			t.Errorf("IsWatchedNamespace(%q) = %v, want %v", ns, got, want)
+		}
+	}
+}
+
+func TestNamespacePatternExcludesDefaultNamespace(t *testing.T) {
+	cm, _ := newTestConfigManager(t, "defaultNamespace: dev-shared\ndeveloperNamespaces: [\"dev-*\"]\n",
+		namespace("dev-shared", nil, nil),
+		namespace("dev-alice", nil, nil),
+	)
+
+	got, err := cm.GetDeveloperNamespaces(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"dev-alice"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetDeveloperNamespaces() = %v, want %v", got, want)
+	}
+}
+
+func TestMatchesNamespacePattern(t *testing.T) {
+	tests := []struct {
+		namespace string
+		want      bool
+	}{
+		{namespace: "dev-alice", want: true},
+		{namespace: "qa-1", want: true},
+		{namespace: "qa-10"},
+		{namespace: "dev"},
+		{namespace: "prod"},
+	}
+	for _, tt := range tests {
+		if got := MatchesNamespacePattern([]string{"dev-*", "qa-?"}, tt.namespace); got != tt.want {
+			t.Errorf("MatchesNamespacePattern(%q) = %v, want %v", tt.namespace, got, tt.want)
+		}
+	}
+
+	for entry, want := range map[string]bool{"dev-*": true, "qa-?": true, "team-[ab]": true, "alice": false} {
+		if got := IsNamespacePattern(entry); got != want {
+			t.Errorf("IsNamespacePattern(%q) = %v, want %v", entry, got, want)
+		}
+	}
+}
+
+func TestDeveloperNamespaceSelectorExcludesDefaultNamespace(t *testing.T) {
+	cm, _ := newTestConfigManager(t, `defaultNamespace: default
+developerNamespaces: [default, alice]
+developerNamespaceSelector:
+  matchLabels:
+    env: dev
+`,
+		namespace("default", map[string]string{"env": "dev"}, nil),
+		namespace("bob", map[string]string{"env": "dev"}, nil),
+		namespace("prod", map[string]string{"env": "prod"}, nil),
+	)
+	ctx := context.Background()
+
+	got, err := cm.GetDeveloperNamespaces(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"alice", "bob"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetDeveloperNamespaces() = %v, want %v", got, want)
+	}
+
+	// The default namespace is watched once, as the default namespace
+	watched, err := cm.GetWatchedNamespaces(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"default", "alice", "bob"}; !reflect.DeepEqual(watched, want) {
+		t.Errorf("GetWatchedNamespaces() = %v, want %v", watched, want)
+	}
+}
+
+func TestParseConfigYAMLAndJSON(t *testing.T) {
+	configYAML := `
+defaultNamespace: prod
+developerNamespaces: [alice, dev-*]
+enablePlaceholderServices: true
+gateways: [istio-system/public]
+virtualServiceAnnotations:
+  team: payments
+developerNamespaceSelector:
+  matchLabels:
+    team: payments
+routingStrategy: source
+useFQDNHosts: true
+routeTimeout: 30s
+resyncPeriod: 10m
+`
+	configJSON := `{
+  "defaultNamespace": "prod",
+  "developerNamespaces": ["alice", "dev-*"],
+  "enablePlaceholderServices": true,
+  "gateways": ["istio-system/public"],
+  "virtualServiceAnnotations": {"team": "payments"},
+  "developerNamespaceSelector": {"matchLabels": {"team": "payments"}},
+  "routingStrategy": "source",
+  "useFQDNHosts": true,
+  "routeTimeout": "30s",
+  "resyncPeriod": "10m"
+}`
+
+	fromYAML, err := ParseConfig([]byte(configYAML), FormatYAML)
+	if err != nil {
+		t.Fatalf("parsing YAML: %v", err)
+	}
+	fromJSON, err := ParseConfig([]byte(configJSON), FormatJSON)
+	if err != nil {
+		t.Fatalf("parsing JSON: %v", err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Errorf("JSON config differs from YAML config:\n%+v\n%+v", fromJSON, fromYAML)
+	}
+	if fromYAML.RouteTimeout.Duration.String() != "30s" || fromYAML.ClusterDomain != "cluster.local" {
+		t.Errorf("config = %+v, want the parsed timeout and the default cluster domain", fromYAML)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format string
+	}{
+		{name: "malformed yaml", data: "developerNamespaces: [alice", format: FormatYAML},
+		{name: "malformed json", data: `{"developerNamespaces": ["alice"`, format: FormatJSON},
+		{name: "invalid yaml value", data: "routingStrategy: teleport\n", format: FormatYAML},
+		{name: "invalid json value", data: `{"routingStrategy": "teleport"}`, format: FormatJSON},
+		{name: "unsupported format", data: "defaultNamespace = default", format: "toml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseConfig([]byte(tt.data), tt.format); err == nil {
+				t.Error("ParseConfig() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestConfigMapDataPrefersYAML(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       map[string]string
+		wantFormat string
+		wantErr    bool
+	}{
+		{name: "yaml", data: map[string]string{"config.yaml": "x"}, wantFormat: FormatYAML},
+		{name: "json", data: map[string]string{"config.json": "{}"}, wantFormat: FormatJSON},
+		{name: "both", data: map[string]string{"config.yaml": "x", "config.json": "{}"}, wantFormat: FormatYAML},
+		{name: "neither", data: map[string]string{"other": "x"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, format, err := ConfigMapData(&corev1.ConfigMap{Data: tt.data})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigMapData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if format != tt.wantFormat {
+				t.Errorf("format = %q, want %q", format, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestWatchAnnotatedNamespaces(t *testing.T) {
+	watch := map[string]string{WatchAnnotation: "true"}
+	cm, c := newTestConfigManager(t, "defaultNamespace: default\ndeveloperNamespaces: [alice]\nwatchAnnotatedNamespaces: true\n",
+		namespace("default", nil, watch),
+		namespace("team-a", nil, watch),
+		namespace("team-b", nil, map[string]string{WatchAnnotation: "false"}),
+		namespace("team-c", nil, nil),
+	)
+	ctx := context.Background()
+
+	watched, err := cm.GetWatchedNamespaces(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"default", "alice", "team-a"}; !reflect.DeepEqual(watched, want) {
+		t.Errorf("GetWatchedNamespaces() = %v, want %v", watched, want)
+	}
+	if !cm.IsWatchedNamespace(ctx, "team-a") || cm.IsWatchedNamespace(ctx, "team-b") {
+		t.Error("IsWatchedNamespace() doesn't follow the watch annotation")
+	}
+
+	// Removing the annotation unenrolls the namespace
+	teamA := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: "team-a"}, teamA); err != nil {
+		t.Fatal(err)
+	}
+	delete(teamA.Annotations, WatchAnnotation)
+	if err := c.Update(ctx, teamA); err != nil {
+		t.Fatal(err)
+	}
+	watched, err = cm.GetWatchedNamespaces(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"default", "alice"}; !reflect.DeepEqual(watched, want) {
+		t.Errorf("GetWatchedNamespaces() after removing the annotation = %v, want %v", watched, want)
+	}
+	if cm.IsWatchedNamespace(ctx, "team-a") {
+		t.Error("team-a still watched after removing the annotation")
+	}
+}
+
+func TestWatchAnnotationIgnoredWhenDisabled(t *testing.T) {
+	cm, _ := newTestConfigManager(t, "defaultNamespace: default\ndeveloperNamespaces: [alice]\n",
+		namespace("team-a", nil, map[string]string{WatchAnnotation: "true"}),
+	)
+
+	got, err := cm.GetDeveloperNamespaces(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"alice"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetDeveloperNamespaces() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"fmt"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DestinationRuleName returns the name of the DestinationRule generated for a service
+func DestinationRuleName(serviceName string) string {
+	return fmt.Sprintf("%s-destination-rule", serviceName)
+}
+
+// GenerateDestinationRule creates a DestinationRule declaring the given subsets for a service.
+// Each subset selects pods whose subsetLabel equals the subset name. A non-nil consistentHash sets
+// the load balancer of the service's host to consistent hashing, for sticky sessions.
+func GenerateDestinationRule(service *corev1.Service, domain string, subsets []string, subsetLabel string, consistentHash *istiov1beta1.LoadBalancerSettings_ConsistentHashLB) *istionetworkingv1beta1.DestinationRule {
+	var drSubsets []*istiov1beta1.Subset
+	for _, subset := range subsets {
+		drSubsets = append(drSubsets, &istiov1beta1.Subset{
+			Name: subset,
+			Labels: map[string]string{
+				subsetLabel: subset,
+			},
+		})
+	}
+
+	var trafficPolicy *istiov1beta1.TrafficPolicy
+	if consistentHash != nil {
+		trafficPolicy = &istiov1beta1.TrafficPolicy{
+			LoadBalancer: &istiov1beta1.LoadBalancerSettings{
+				LbPolicy: &istiov1beta1.LoadBalancerSettings_ConsistentHash{ConsistentHash: consistentHash},
+			},
+		}
+	}
+
+	return &istionetworkingv1beta1.DestinationRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DestinationRuleName(service.Name),
+			Namespace: service.Namespace,
+			Labels: map[string]string{
+				ManagedByLabel: OperatorName,
+			},
+		},
+		Spec: istiov1beta1.DestinationRule{
+			Host:          ServiceFQDN(service.Name, service.Namespace, domain),
+			Subsets:       drSubsets,
+			TrafficPolicy: trafficPolicy,
+		},
+	}
+}
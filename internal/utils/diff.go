@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// DiffVirtualService returns a human-readable list of differences between the desired and actual
+// VirtualService. Hosts, gateways and exportTo are compared as sets, HTTP routes are compared in order
+// because Istio evaluates them in order, TLS and TCP routes as a whole since the operator generates none
+// and only has to undo edits to them. Labels, annotations and owner references are compared too,
+// since the operator relies on them to recognize its VirtualServices and tools read them. An empty result means no update is needed.
+func DiffVirtualService(desired, actual *istionetworkingv1beta1.VirtualService) []string {
+	var diffs []string
+
+	diffs = append(diffs, diffStringMap("labels", desired.Labels, actual.Labels)...)
+	diffs = append(diffs, diffStringMap("annotations", desired.Annotations, actual.Annotations)...)
+	if !equality.Semantic.DeepEqual(desired.OwnerReferences, actual.OwnerReferences) {
+		diffs = append(diffs, "ownerReferences: change")
+	}
+	diffs = append(diffs, diffStringSet("hosts", desired.Spec.Hosts, actual.Spec.Hosts)...)
+	diffs = append(diffs, diffStringSet("gateways", desired.Spec.Gateways, actual.Spec.Gateways)...)
+	diffs = append(diffs, diffStringSet("exportTo", desired.Spec.ExportTo, actual.Spec.ExportTo)...)
+	diffs = append(diffs, diffHTTPRoutes(desired.Spec.Http, actual.Spec.Http)...)
+	if !protoListsEqual(desired.Spec.Tls, actual.Spec.Tls) {
+		diffs = append(diffs, "tls: change")
+	}
+	if !protoListsEqual(desired.Spec.Tcp, actual.Spec.Tcp) {
+		diffs = append(diffs, "tcp: change")
+	}
+
+	return diffs
+}
+
+// protoListsEqual checks two lists of messages hold equal messages in the same order
+func protoListsEqual[T proto.Message](desired, actual []T) bool {
+	if len(desired) != len(actual) {
+		return false
+	}
+	for i := range desired {
+		if !proto.Equal(desired[i], actual[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// diffStringSet reports entries added to or removed from an unordered list
+func diffStringSet(field string, desired, actual []string) []string {
+	desiredSet := make(map[string]bool, len(desired))
+	for _, v := range desired {
+		desiredSet[v] = true
+	}
+	actualSet := make(map[string]bool, len(actual))
+	for _, v := range actual {
+		actualSet[v] = true
+	}
+
+	var added, removed []string
+	for v := range desiredSet {
+		if !actualSet[v] {
+			added = append(added, v)
+		}
+	}
+	for v := range actualSet {
+		if !desiredSet[v] {
+			removed = append(removed, v)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	var diffs []string
+	if len(added) > 0 {
+		diffs = append(diffs, fmt.Sprintf("%s: add %v", field, added))
+	}
+	if len(removed) > 0 {
+		diffs = append(diffs, fmt.Sprintf("%s: remove %v", field, removed))
+	}
+	return diffs
+}
+
+// diffStringMap reports keys added to, removed from or changed in a map
+func diffStringMap(field string, desired, actual map[string]string) []string {
+	var changed []string
+	for k, v := range desired {
+		if actualValue, exists := actual[k]; !exists || actualValue != v {
+			changed = append(changed, k)
+		}
+	}
+	for k := range actual {
+		if _, exists := desired[k]; !exists {
+			changed = append(changed, k)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	sort.Strings(changed)
+	return []string{fmt.Sprintf("%s: change %v", field, changed)}
+}
+
+// diffHTTPRoutes reports routes that were added, removed or changed at each position
+func diffHTTPRoutes(desired, actual []*istiov1beta1.HTTPRoute) []string {
+	var diffs []string
+
+	count := len(desired)
+	if len(actual) > count {
+		count = len(actual)
+	}
+
+	for i := 0; i < count; i++ {
+		switch {
+		case i >= len(actual):
+			diffs = append(diffs, fmt.Sprintf("http[%d]: add %s route", i, describeRoute(desired[i])))
+		case i >= len(desired):
+			diffs = append(diffs, fmt.Sprintf("http[%d]: remove %s route", i, describeRoute(actual[i])))
+		case !proto.Equal(desired[i], actual[i]):
+			desiredName, actualName := describeRoute(desired[i]), describeRoute(actual[i])
+			if desiredName == actualName {
+				diffs = append(diffs, fmt.Sprintf("http[%d]: change %s route", i, desiredName))
+			} else {
+				diffs = append(diffs, fmt.Sprintf("http[%d]: replace %s route with %s route", i, actualName, desiredName))
+			}
+		}
+	}
+
+	return diffs
+}
+
+// describeRoute names a route by the developer namespace it matches, or "default" for the fallback route
+func describeRoute(route *istiov1beta1.HTTPRoute) string {
+	if namespace, ok := fallbackRouteNamespace(route); ok {
+		return fmt.Sprintf("fallback=%s", namespace)
+	}
+	if len(route.Match) > 0 {
+		if headerMatch, exists := route.Match[0].Headers["x-developer"]; exists {
+			return fmt.Sprintf("x-developer=%s", headerMatch.GetExact())
+		}
+		if source := route.Match[0].SourceNamespace; source != "" {
+			return fmt.Sprintf("sourceNamespace=%s", source)
+		}
+	}
+	if len(route.Match) == 0 {
+		return "default"
+	}
+	return "custom"
+}
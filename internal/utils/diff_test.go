@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// diffTestVirtualService has a developer route for alice followed by the default route
+func diffTestVirtualService() *istionetworkingv1beta1.VirtualService {
+	vs := &istionetworkingv1beta1.VirtualService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "app-virtual-service",
+			Namespace:   "default",
+			Labels:      map[string]string{ManagedByLabel: OperatorName},
+			Annotations: map[string]string{"kiali.io/hint": "a"},
+		},
+	}
+	vs.Spec.Hosts = []string{"app", "app.default.svc.cluster.local"}
+	vs.Spec.Gateways = []string{"mesh", "istio-system/public"}
+	vs.Spec.ExportTo = []string{".", "istio-system"}
+	vs.Spec.Http = []*istiov1beta1.HTTPRoute{
+		diffTestRoute("alice"),
+		{Route: []*istiov1beta1.HTTPRouteDestination{{Destination: &istiov1beta1.Destination{Host: "app.default.svc.cluster.local"}}}},
+	}
+	return vs
+}
+
+func diffTestRoute(namespace string) *istiov1beta1.HTTPRoute {
+	return &istiov1beta1.HTTPRoute{
+		Match: []*istiov1beta1.HTTPMatchRequest{{
+			Headers: map[string]*istiov1beta1.StringMatch{
+				"x-developer": {MatchType: &istiov1beta1.StringMatch_Exact{Exact: namespace}},
+			},
+		}},
+		Route: []*istiov1beta1.HTTPRouteDestination{{Destination: &istiov1beta1.Destination{Host: "app." + namespace + ".svc.cluster.local"}}},
+	}
+}
+
+func TestDiffVirtualService(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(desired *istionetworkingv1beta1.VirtualService)
+		want   []string
+	}{
+		{
+			name:   "identical",
+			change: func(*istionetworkingv1beta1.VirtualService) {},
+		},
+		{
+			name: "hosts in another order",
+			change: func(desired *istionetworkingv1beta1.VirtualService) {
+				desired.Spec.Hosts = []string{"app.default.svc.cluster.local", "app"}
+			},
+		},
+		{
+			name: "host added and removed",
+			change: func(desired *istionetworkingv1beta1.VirtualService) {
+				desired.Spec.Hosts = []string{"app", "app.example.com"}
+			},
+			want: []string{"hosts: add [app.example.com]", "hosts: remove [app.default.svc.cluster.local]"},
+		},
+		{
+			name: "gateways in another order",
+			change: func(desired *istionetworkingv1beta1.VirtualService) {
+				desired.Spec.Gateways = []string{"istio-system/public", "mesh"}
+			},
+		},
+		{
+			name: "gateway removed",
+			change: func(desired *istionetworkingv1beta1.VirtualService) {
+				desired.Spec.Gateways = []string{"mesh"}
+			},
+			want: []string{"gateways: remove [istio-system/public]"},
+		},
+		{
+			name: "exportTo added",
+			change: func(desired *istionetworkingv1beta1.VirtualService) {
+				desired.Spec.ExportTo = append(desired.Spec.ExportTo, "*")
+			},
+			want: []string{"exportTo: add [*]"},
+		},
+		{
+			name: "route added",
+			change: func(desired *istionetworkingv1beta1.VirtualService) {
+				desired.Spec.Http = append([]*istiov1beta1.HTTPRoute{diffTestRoute("bob")}, desired.Spec.Http...)
+			},
+			want: []string{
+				"http[0]: replace x-developer=alice route with x-developer=bob route",
+				"http[1]: replace default route with x-developer=alice route",
+				"http[2]: add default route",
+			},
+		},
+		{
+			name: "route removed",
+			change: func(desired *istionetworkingv1beta1.VirtualService) {
+				desired.Spec.Http = desired.Spec.Http[1:]
+			},
+			want: []string{
+				"http[0]: replace x-developer=alice route with default route",
+				"http[1]: remove default route",
+			},
+		},
+		{
+			name: "route changed",
+			change: func(desired *istionetworkingv1beta1.VirtualService) {
+				desired.Spec.Http[0].Route[0].Destination.Subset = "v2"
+			},
+			want: []string{"http[0]: change x-developer=alice route"},
+		},
+		{
+			name: "routes reordered",
+			change: func(desired *istionetworkingv1beta1.VirtualService) {
+				desired.Spec.Http[0], desired.Spec.Http[1] = desired.Spec.Http[1], desired.Spec.Http[0]
+			},
+			want: []string{
+				"http[0]: replace x-developer=alice route with default route",
+				"http[1]: replace default route with x-developer=alice route",
+			},
+		},
+		{
+			name: "tls route added",
+			change: func(desired *istionetworkingv1beta1.VirtualService) {
+				desired.Spec.Tls = []*istiov1beta1.TLSRoute{{Match: []*istiov1beta1.TLSMatchAttributes{{SniHosts: []string{"app.example.com"}}}}}
+			},
+			want: []string{"tls: change"},
+		},
+		{
+			name: "tcp route added",
+			change: func(desired *istionetworkingv1beta1.VirtualService) {
+				desired.Spec.Tcp = []*istiov1beta1.TCPRoute{{Match: []*istiov1beta1.L4MatchAttributes{{Port: 5432}}}}
+			},
+			want: []string{"tcp: change"},
+		},
+		{
+			name: "label changed",
+			change: func(desired *istionetworkingv1beta1.VirtualService) {
+				desired.Labels[ManagedByLabel] = "someone-else"
+			},
+			want: []string{"labels: change [" + ManagedByLabel + "]"},
+		},
+		{
+			name: "annotation added and removed",
+			change: func(desired *istionetworkingv1beta1.VirtualService) {
+				desired.Annotations = map[string]string{"kiali.io/other": "b"}
+			},
+			want: []string{"annotations: change [kiali.io/hint kiali.io/other]"},
+		},
+		{
+			name: "owner reference added",
+			change: func(desired *istionetworkingv1beta1.VirtualService) {
+				desired.OwnerReferences = []metav1.OwnerReference{{APIVersion: "v1", Kind: "Service", Name: "app"}}
+			},
+			want: []string{"ownerReferences: change"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := diffTestVirtualService()
+			desired := actual.DeepCopy()
+			tt.change(desired)
+
+			if got := DiffVirtualService(desired, actual); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffVirtualService() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeRoute(t *testing.T) {
+	fallback := diffTestRoute("alice")
+	fallback.Name = fallbackRouteNamePrefix + "alice"
+
+	tests := []struct {
+		name  string
+		route *istiov1beta1.HTTPRoute
+		want  string
+	}{
+		{name: "developer", route: diffTestRoute("alice"), want: "x-developer=alice"},
+		{name: "fallback", route: fallback, want: "fallback=alice"},
+		{name: "source namespace", route: &istiov1beta1.HTTPRoute{Match: []*istiov1beta1.HTTPMatchRequest{{SourceNamespace: "bob"}}}, want: "sourceNamespace=bob"},
+		{name: "default", route: &istiov1beta1.HTTPRoute{}, want: "default"},
+		{name: "custom", route: &istiov1beta1.HTTPRoute{Match: []*istiov1beta1.HTTPMatchRequest{{Name: "other"}}}, want: "custom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeRoute(tt.route); got != tt.want {
+				t.Errorf("describeRoute() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
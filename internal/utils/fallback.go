@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"strings"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+)
+
+// fallbackRouteNamePrefix names the fallback routes, which match the fallback header instead of x-developer
+const fallbackRouteNamePrefix = "fallback-"
+
+// fallbackRouteNamespace returns the developer namespace of a fallback route
+func fallbackRouteNamespace(route *istiov1beta1.HTTPRoute) (string, bool) {
+	namespace, ok := strings.CutPrefix(route.Name, fallbackRouteNamePrefix)
+	return namespace, ok && namespace != "" && len(route.Match) > 0
+}
+
+// updateFallbackRoute adds or replaces the fallback route of a developer namespace, or removes it when
+// opts has no FallbackHeader or matches on the source namespace. The fallback route sends requests whose
+// fallback header names the namespace to it, and is otherwise the same as the developer route.
+func updateFallbackRoute(vs *istionetworkingv1beta1.VirtualService, serviceName, devNamespace string, opts RouteOptions) {
+	var fallback *istiov1beta1.HTTPRoute
+	if opts.FallbackHeader != "" && !opts.MatchSourceNamespace {
+		fallback = newDeveloperRoute(vs, serviceName, devNamespace, opts)
+		fallback.Name = fallbackRouteNamePrefix + devNamespace
+		fallback.Match = []*istiov1beta1.HTTPMatchRequest{{
+			Headers: map[string]*istiov1beta1.StringMatch{
+				opts.FallbackHeader: {
+					MatchType: &istiov1beta1.StringMatch_Exact{
+						Exact: opts.HeaderPrefix + devNamespace + opts.HeaderSuffix,
+					},
+				},
+			},
+		}}
+	}
+
+	// Replace an existing fallback route in place so updates don't reorder the fallback routes
+	var routes []*istiov1beta1.HTTPRoute
+	for _, route := range vs.Spec.Http {
+		if ns, ok := fallbackRouteNamespace(route); ok && ns == devNamespace {
+			if fallback != nil {
+				routes = append(routes, fallback)
+				fallback = nil
+			}
+			continue
+		}
+		routes = append(routes, route)
+	}
+	if fallback != nil {
+		routes = append(routes, fallback)
+	}
+	vs.Spec.Http = routes
+
+	orderFallbackRoutes(vs)
+}
+
+// orderFallbackRoutes moves the fallback routes after every other route but the default route, keeping their
+// relative order. A request naming developers in both headers thus reaches the x-developer namespace if it
+// has a service, the fallback namespace if only that one has a service, and the default namespace otherwise.
+func orderFallbackRoutes(vs *istionetworkingv1beta1.VirtualService) {
+	var routes, fallbacks []*istiov1beta1.HTTPRoute
+	for _, route := range vs.Spec.Http {
+		if _, ok := fallbackRouteNamespace(route); ok {
+			fallbacks = append(fallbacks, route)
+			continue
+		}
+		routes = append(routes, route)
+	}
+	if len(fallbacks) == 0 {
+		return
+	}
+
+	// The default route has no match and always stays last
+	if n := len(routes); n > 0 && len(routes[n-1].Match) == 0 {
+		defaultRoute := routes[n-1]
+		routes = append(append(routes[:n-1], fallbacks...), defaultRoute)
+	} else {
+		routes = append(routes, fallbacks...)
+	}
+	vs.Spec.Http = routes
+}
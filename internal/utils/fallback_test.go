@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fallbackTestOptions route on x-developer with x-fallback as the fallback header
+var fallbackTestOptions = RouteOptions{ClusterDomain: "cluster.local", LocalClusterDomain: "cluster.local", FallbackHeader: "x-fallback"}
+
+// routeOrder describes the routes of a VirtualService in order
+func routeOrder(vs *istionetworkingv1beta1.VirtualService) []string {
+	var order []string
+	for _, route := range vs.Spec.Http {
+		order = append(order, describeRoute(route))
+	}
+	return order
+}
+
+// fallbackTestVirtualService has developer routes for the namespaces, added in order
+func fallbackTestVirtualService(opts RouteOptions, namespaces ...string) *istionetworkingv1beta1.VirtualService {
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app"}}
+	vs := GenerateVirtualService(service, "default", nil, opts)
+	for _, namespace := range namespaces {
+		UpdateVirtualServiceRoutes(vs, "app", namespace, opts)
+	}
+	return vs
+}
+
+func TestFallbackRouteOrdering(t *testing.T) {
+	vs := fallbackTestVirtualService(fallbackTestOptions, "alice", "bob")
+	want := []string{"x-developer=alice", "x-developer=bob", "fallback=alice", "fallback=bob", "default"}
+	if got := routeOrder(vs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("routes = %v, want %v", got, want)
+	}
+
+	fallback := vs.Spec.Http[2]
+	if got := fallback.Match[0].Headers["x-fallback"].GetExact(); got != "alice" {
+		t.Errorf("fallback route matches x-fallback %q, want alice", got)
+	}
+	if _, ok := fallback.Match[0].Headers["x-developer"]; ok {
+		t.Error("fallback route also matches x-developer")
+	}
+	if got := fallback.Route[0].Destination.Host; got != "app.alice.svc.cluster.local" {
+		t.Errorf("fallback route host = %q, want alice's service", got)
+	}
+
+	// Updating a namespace keeps the order, a namespace added later still goes before the fallback routes
+	UpdateVirtualServiceRoutes(vs, "app", "alice", fallbackTestOptions)
+	UpdateVirtualServiceRoutes(vs, "app", "carol", fallbackTestOptions)
+	want = []string{"x-developer=alice", "x-developer=bob", "x-developer=carol", "fallback=alice", "fallback=bob", "fallback=carol", "default"}
+	if got := routeOrder(vs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("routes after update = %v, want %v", got, want)
+	}
+
+	// Removing a namespace removes both of its routes
+	if removed := RemoveDeveloperRoutes(vs, "bob"); removed != 2 {
+		t.Errorf("RemoveDeveloperRoutes() = %d, want bob's developer and fallback routes", removed)
+	}
+	want = []string{"x-developer=alice", "x-developer=carol", "fallback=alice", "fallback=carol", "default"}
+	if got := routeOrder(vs); !reflect.DeepEqual(got, want) {
+		t.Errorf("routes after removing bob = %v, want %v", got, want)
+	}
+}
+
+func TestFallbackRouteHeaderValue(t *testing.T) {
+	opts := fallbackTestOptions
+	opts.HeaderPrefix = "ns-"
+	vs := fallbackTestVirtualService(opts, "alice")
+
+	if got := vs.Spec.Http[1].Match[0].Headers["x-fallback"].GetExact(); got != "ns-alice" {
+		t.Errorf("fallback route matches x-fallback %q, want ns-alice", got)
+	}
+	if namespace, ok := DeveloperRouteNamespace(vs.Spec.Http[1]); !ok || namespace != "alice" {
+		t.Errorf("DeveloperRouteNamespace() = %q, %v, want alice", namespace, ok)
+	}
+}
+
+func TestFallbackRouteDisabled(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    func(opts *RouteOptions)
+		wantAlice string
+	}{
+		{name: "no fallback header", change: func(opts *RouteOptions) { opts.FallbackHeader = "" }, wantAlice: "x-developer=alice"},
+		{name: "source namespace routing", change: func(opts *RouteOptions) { opts.MatchSourceNamespace = true }, wantAlice: "sourceNamespace=alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vs := fallbackTestVirtualService(fallbackTestOptions, "alice", "bob")
+			opts := fallbackTestOptions
+			tt.change(&opts)
+
+			// The fallback route of a namespace goes with its next update
+			UpdateVirtualServiceRoutes(vs, "app", "alice", opts)
+			want := []string{tt.wantAlice, "x-developer=bob", "fallback=bob", "default"}
+			if got := routeOrder(vs); !reflect.DeepEqual(got, want) {
+				t.Errorf("routes = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package utils
+
+import "fmt"
+
+// DefaultClusterDomain is the cluster domain used when none is configured
+const DefaultClusterDomain = "cluster.local"
+
+// ServiceFQDN returns the fully qualified name of a service, <name>.<namespace>.svc.<domain>, defaulting to
+// DefaultClusterDomain for an empty domain. Every host the operator writes for a service, VirtualService
+// destinations and placeholder ExternalNames alike, is built here so they never diverge.
+func ServiceFQDN(name, namespace, domain string) string {
+	if domain == "" {
+		domain = DefaultClusterDomain
+	}
+	return fmt.Sprintf("%s.%s.svc.%s", name, namespace, domain)
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestGeneratedHostsUseClusterDomain checks every generated host of the default namespace service is the
+// ServiceFQDN in the given domain, the same name a placeholder ExternalName points at
+func TestGeneratedHostsUseClusterDomain(t *testing.T) {
+	for _, domain := range []string{"cluster.local", "corp.example"} {
+		t.Run(domain, func(t *testing.T) {
+			want := ServiceFQDN("app", "default", domain)
+			service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app"}}
+			weight := int32(50)
+			opts := RouteOptions{ClusterDomain: domain, LocalClusterDomain: domain}
+
+			vs := GenerateVirtualService(service, "default", nil, opts)
+			if got := vs.Spec.Http[0].Route[0].Destination.Host; got != want {
+				t.Errorf("default route host = %q, want %q", got, want)
+			}
+
+			weighted := opts
+			weighted.Weight = &weight
+			route := newDeveloperRoute(vs, "app", "alice", weighted)
+			if got := route.Route[1].Destination.Host; got != want {
+				t.Errorf("weighted developer route remainder host = %q, want %q", got, want)
+			}
+
+			rewrite := opts
+			rewrite.RewriteAuthority = true
+			route = newDeveloperRoute(vs, "app", "alice", rewrite)
+			if got := route.Route[0].Destination.Host; got != want {
+				t.Errorf("rewritten developer route host = %q, want %q", got, want)
+			}
+			if got, want := route.Rewrite.Authority, ServiceFQDN("app", "alice", domain); got != want {
+				t.Errorf("rewritten authority = %q, want %q", got, want)
+			}
+
+			SetDefaultRouteNamespace(vs, "app", "default", domain)
+			if got := vs.Spec.Http[0].Route[0].Destination.Host; got != want {
+				t.Errorf("host after SetDefaultRouteNamespace = %q, want %q", got, want)
+			}
+
+			ApplyDefaultRouteWeight(vs, "app", "default", domain, 50, []string{"alice"})
+			if got := vs.Spec.Http[0].Route[0].Destination.Host; got != want {
+				t.Errorf("host after ApplyDefaultRouteWeight = %q, want %q", got, want)
+			}
+
+			if got := GenerateDestinationRule(service, domain, nil, "version", nil).Spec.Host; got != want {
+				t.Errorf("DestinationRule host = %q, want %q", got, want)
+			}
+
+			hosts := GenerateSidecar("alice", "default", domain, []string{"app"}).Spec.Egress[0].Hosts
+			if got := hosts[len(hosts)-1]; got != "default/"+want {
+				t.Errorf("Sidecar host = %q, want %q", got, "default/"+want)
+			}
+		})
+	}
+}
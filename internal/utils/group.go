@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// GroupLabel holds the group name on a group VirtualService
+	GroupLabel = OperatorAnnotationPrefix + "group"
+	// GroupMembersAnnotation lists the member services a group VirtualService was generated for
+	GroupMembersAnnotation = OperatorAnnotationPrefix + "group-members"
+)
+
+// GroupMember is a default namespace service routed by path within a group VirtualService
+type GroupMember struct {
+	// ServiceName is the name of the service in the default namespace
+	ServiceName string
+	// PathPrefix selects the requests for the member
+	PathPrefix string
+	// DeveloperNamespaces have a developer service for the member, in route order
+	DeveloperNamespaces []string
+	// RouteOptions holds the developer route options by developer namespace
+	RouteOptions map[string]RouteOptions
+	// Timeout of the member's default route, zero sets none
+	Timeout time.Duration
+}
+
+// GroupVirtualServiceName returns the name of the VirtualService of a service group
+func GroupVirtualServiceName(group string) string {
+	return fmt.Sprintf("%s-group-virtual-service", group)
+}
+
+// GenerateGroupVirtualService creates the VirtualService shared by the members of a service group. Every member
+// gets its developer routes followed by its default route, all matching the member's path prefix. Members
+// with longer prefixes come first so a prefix never shadows a more specific one. Requests matching no
+// member prefix are not routed. Gateways and Annotations of opts apply to the VirtualService.
+func GenerateGroupVirtualService(group string, hosts []string, defaultNamespace string, members []GroupMember, opts RouteOptions) *istionetworkingv1beta1.VirtualService {
+	vs := &istionetworkingv1beta1.VirtualService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GroupVirtualServiceName(group),
+			Namespace: defaultNamespace,
+			Labels: map[string]string{
+				ManagedByLabel: OperatorName,
+				GroupLabel:     group,
+			},
+		},
+		Spec: istiov1beta1.VirtualService{
+			Hosts:    hosts,
+			Gateways: opts.Gateways,
+		},
+	}
+
+	sorted := append([]GroupMember(nil), members...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if len(sorted[i].PathPrefix) != len(sorted[j].PathPrefix) {
+			return len(sorted[i].PathPrefix) > len(sorted[j].PathPrefix)
+		}
+		return sorted[i].ServiceName < sorted[j].ServiceName
+	})
+
+	var names []string
+	for _, member := range sorted {
+		prefix := &istiov1beta1.StringMatch{
+			MatchType: &istiov1beta1.StringMatch_Prefix{Prefix: member.PathPrefix},
+		}
+
+		for _, devNamespace := range member.DeveloperNamespaces {
+			route := newDeveloperRoute(vs, member.ServiceName, devNamespace, member.RouteOptions[devNamespace])
+			route.Match[0].Uri = prefix
+			vs.Spec.Http = append(vs.Spec.Http, route)
+		}
+
+		vs.Spec.Http = append(vs.Spec.Http, &istiov1beta1.HTTPRoute{
+			Match:   []*istiov1beta1.HTTPMatchRequest{{Uri: prefix}},
+			Route:   defaultRouteDestinations(member.ServiceName, defaultNamespace, opts.LocalClusterDomain),
+			Timeout: routeTimeout(member.Timeout),
+		})
+		names = append(names, member.ServiceName)
+	}
+
+	setManagedAnnotations(vs, opts.Annotations)
+	if vs.Annotations == nil {
+		vs.Annotations = map[string]string{}
+	}
+	sort.Strings(names)
+	vs.Annotations[GroupMembersAnnotation] = strings.Join(names, ",")
+
+	return vs
+}
+
+// GroupMembers returns the member services recorded on a group VirtualService
+func GroupMembers(vs *istionetworkingv1beta1.VirtualService) []string {
+	value := vs.Annotations[GroupMembersAnnotation]
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+)
+
+// RouteTimestampsAnnotation records when the route of each developer namespace was first added to a
+// VirtualService, as a JSON object of RFC 3339 timestamps keyed by namespace
+const RouteTimestampsAnnotation = OperatorAnnotationPrefix + "route-timestamps"
+
+// RouteTimestamps returns the recorded creation time of the developer routes of a VirtualService.
+// An unreadable annotation is treated as empty, the routes are then stamped again.
+func RouteTimestamps(vs *istionetworkingv1beta1.VirtualService) map[string]time.Time {
+	timestamps := map[string]time.Time{}
+	value := vs.Annotations[RouteTimestampsAnnotation]
+	if value == "" {
+		return timestamps
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return timestamps
+	}
+	for namespace, stamp := range raw {
+		if t, err := time.Parse(time.RFC3339, stamp); err == nil {
+			timestamps[namespace] = t
+		}
+	}
+	return timestamps
+}
+
+// setRouteTimestamps writes the route creation times to a VirtualService, removing the annotation when empty
+func setRouteTimestamps(vs *istionetworkingv1beta1.VirtualService, timestamps map[string]time.Time) {
+	if len(timestamps) == 0 {
+		delete(vs.Annotations, RouteTimestampsAnnotation)
+		return
+	}
+
+	raw := make(map[string]string, len(timestamps))
+	for namespace, t := range timestamps {
+		raw[namespace] = t.UTC().Format(time.RFC3339)
+	}
+	// Map keys are marshalled in sorted order, so an unchanged set of timestamps never causes an update
+	value, _ := json.Marshal(raw)
+	if vs.Annotations == nil {
+		vs.Annotations = map[string]string{}
+	}
+	vs.Annotations[RouteTimestampsAnnotation] = string(value)
+}
+
+// StampDeveloperRoutes records now as the creation time of every developer route without a timestamp and,
+// with a positive maxAge, removes the routes whose timestamp is older than maxAge. A removed route keeps
+// its timestamp, so it isn't added back with a fresh one while the developer service still exists; see
+// ForgetRouteTimestamp. It returns the namespaces whose routes were removed, in sorted order.
+func StampDeveloperRoutes(vs *istionetworkingv1beta1.VirtualService, now time.Time, maxAge time.Duration) []string {
+	timestamps := RouteTimestamps(vs)
+
+	expired := map[string]bool{}
+	for _, route := range vs.Spec.Http {
+		namespace, ok := DeveloperRouteNamespace(route)
+		if !ok {
+			continue
+		}
+		created, stamped := timestamps[namespace]
+		if !stamped {
+			timestamps[namespace] = now
+			continue
+		}
+		if maxAge > 0 && now.Sub(created) > maxAge {
+			expired[namespace] = true
+		}
+	}
+
+	var reaped []string
+	for namespace := range expired {
+		RemoveDeveloperRoutes(vs, namespace)
+		reaped = append(reaped, namespace)
+	}
+	sort.Strings(reaped)
+
+	setRouteTimestamps(vs, timestamps)
+	return reaped
+}
+
+// ForgetRouteTimestamp drops the timestamp of a developer namespace's route, so the route counts as new
+// if it's added again. Used when the developer service itself is gone.
+func ForgetRouteTimestamp(vs *istionetworkingv1beta1.VirtualService, namespace string) {
+	timestamps := RouteTimestamps(vs)
+	if _, exists := timestamps[namespace]; !exists {
+		return
+	}
+	delete(timestamps, namespace)
+	setRouteTimestamps(vs, timestamps)
+}
+
+// NextRouteExpiry returns how long until the oldest developer route of a VirtualService exceeds maxAge,
+// or zero if no route will expire
+func NextRouteExpiry(vs *istionetworkingv1beta1.VirtualService, now time.Time, maxAge time.Duration) time.Duration {
+	if maxAge <= 0 {
+		return 0
+	}
+
+	timestamps := RouteTimestamps(vs)
+	var next time.Duration
+	for _, route := range vs.Spec.Http {
+		namespace, ok := DeveloperRouteNamespace(route)
+		if !ok {
+			continue
+		}
+		created, stamped := timestamps[namespace]
+		if !stamped {
+			continue
+		}
+		remaining := created.Add(maxAge).Sub(now)
+		if remaining <= 0 {
+			remaining = time.Second
+		}
+		if next == 0 || remaining < next {
+			next = remaining
+		}
+	}
+	return next
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// routeAgeTestOptions route to the local cluster
+var routeAgeTestOptions = RouteOptions{ClusterDomain: "cluster.local", LocalClusterDomain: "cluster.local"}
+
+// routeAgeTestVirtualService has developer routes for the namespaces followed by the default route
+func routeAgeTestVirtualService(namespaces ...string) *istionetworkingv1beta1.VirtualService {
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app"}}
+	vs := GenerateVirtualService(service, "default", nil, routeAgeTestOptions)
+	for _, namespace := range namespaces {
+		UpdateVirtualServiceRoutes(vs, "app", namespace, routeAgeTestOptions)
+	}
+	return vs
+}
+
+// routedNamespaces returns the developer namespaces with a route in the VirtualService
+func routedNamespaces(vs *istionetworkingv1beta1.VirtualService) []string {
+	var namespaces []string
+	for _, route := range vs.Spec.Http {
+		if namespace, ok := DeveloperRouteNamespace(route); ok {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+func TestStampDeveloperRoutes(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	vs := routeAgeTestVirtualService("alice")
+
+	if reaped := StampDeveloperRoutes(vs, start, time.Hour); len(reaped) != 0 {
+		t.Fatalf("fresh routes reaped: %v", reaped)
+	}
+	if got := RouteTimestamps(vs); !reflect.DeepEqual(got, map[string]time.Time{"alice": start}) {
+		t.Fatalf("timestamps = %v, want alice stamped at %v", got, start)
+	}
+
+	// A route added later gets its own timestamp, the existing one is preserved
+	UpdateVirtualServiceRoutes(vs, "app", "bob", routeAgeTestOptions)
+	later := start.Add(30 * time.Minute)
+	StampDeveloperRoutes(vs, later, time.Hour)
+	if got, want := RouteTimestamps(vs), map[string]time.Time{"alice": start, "bob": later}; !reflect.DeepEqual(got, want) {
+		t.Errorf("timestamps = %v, want %v", got, want)
+	}
+
+	// Past maxAge the route is removed but keeps its timestamp
+	reaped := StampDeveloperRoutes(vs, start.Add(61*time.Minute), time.Hour)
+	if !reflect.DeepEqual(reaped, []string{"alice"}) {
+		t.Errorf("reaped = %v, want [alice]", reaped)
+	}
+	if got := routedNamespaces(vs); !reflect.DeepEqual(got, []string{"bob"}) {
+		t.Errorf("developer routes = %v, want [bob]", got)
+	}
+	if _, stamped := RouteTimestamps(vs)["alice"]; !stamped {
+		t.Error("timestamp of the reaped route was dropped")
+	}
+
+	// Re-adding the route while its timestamp is kept reaps it again
+	UpdateVirtualServiceRoutes(vs, "app", "alice", routeAgeTestOptions)
+	if reaped := StampDeveloperRoutes(vs, start.Add(62*time.Minute), time.Hour); !reflect.DeepEqual(reaped, []string{"alice"}) {
+		t.Errorf("re-added expired route reaped = %v, want [alice]", reaped)
+	}
+
+	// Once forgotten, the route counts as new
+	ForgetRouteTimestamp(vs, "alice")
+	UpdateVirtualServiceRoutes(vs, "app", "alice", routeAgeTestOptions)
+	now := start.Add(63 * time.Minute)
+	if reaped := StampDeveloperRoutes(vs, now, time.Hour); len(reaped) != 0 {
+		t.Errorf("forgotten route reaped: %v", reaped)
+	}
+	if got := RouteTimestamps(vs)["alice"]; !got.Equal(now) {
+		t.Errorf("alice timestamp = %v, want %v", got, now)
+	}
+}
+
+func TestStampDeveloperRoutesWithoutMaxAge(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	vs := routeAgeTestVirtualService("alice")
+	StampDeveloperRoutes(vs, start, 0)
+
+	if reaped := StampDeveloperRoutes(vs, start.Add(1000*time.Hour), 0); len(reaped) != 0 {
+		t.Errorf("routes reaped without maxAge: %v", reaped)
+	}
+	if got := RouteTimestamps(vs)["alice"]; !got.Equal(start) {
+		t.Errorf("alice timestamp = %v, want %v", got, start)
+	}
+}
+
+func TestRouteTimestampsUnreadableAnnotation(t *testing.T) {
+	vs := routeAgeTestVirtualService("alice")
+	vs.Annotations = map[string]string{RouteTimestampsAnnotation: "not json"}
+	if got := RouteTimestamps(vs); len(got) != 0 {
+		t.Errorf("RouteTimestamps() = %v, want none", got)
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	StampDeveloperRoutes(vs, now, time.Hour)
+	if got := RouteTimestamps(vs)["alice"]; !got.Equal(now) {
+		t.Errorf("alice timestamp = %v, want it stamped again at %v", got, now)
+	}
+}
+
+func TestNextRouteExpiry(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	vs := routeAgeTestVirtualService("alice", "bob")
+	StampDeveloperRoutes(vs, start, 0)
+	ForgetRouteTimestamp(vs, "bob")
+	StampDeveloperRoutes(vs, start.Add(20*time.Minute), 0)
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		maxAge time.Duration
+		want   time.Duration
+	}{
+		{name: "no maxAge", now: start, want: 0},
+		{name: "oldest route first", now: start.Add(10 * time.Minute), maxAge: time.Hour, want: 50 * time.Minute},
+		{name: "overdue", now: start.Add(2 * time.Hour), maxAge: time.Hour, want: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextRouteExpiry(vs, tt.now, tt.maxAge); got != tt.want {
+				t.Errorf("NextRouteExpiry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"fmt"
+
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServiceEntryLabel holds the name of the ServiceEntry a VirtualService was generated for
+const ServiceEntryLabel = OperatorAnnotationPrefix + "service-entry"
+
+// ServiceEntryHosts is the HostSource of ServiceEntries: the first host of the same-named entry in each namespace
+type ServiceEntryHosts map[string]string
+
+// Host returns the host of the ServiceEntry in the namespace
+func (h ServiceEntryHosts) Host(serviceName, namespace string) string {
+	return h[namespace]
+}
+
+// ServiceEntryVirtualServiceName returns the name of the VirtualService generated for a ServiceEntry. It
+// differs from the name used for a Service, so a Service and a ServiceEntry of the same name don't collide.
+func ServiceEntryVirtualServiceName(entryName string) string {
+	return fmt.Sprintf("%s-serviceentry-virtual-service", entryName)
+}
+
+// GenerateServiceEntryVirtualService creates the VirtualService for a default namespace ServiceEntry with only
+// the default route. The VirtualService host and the route destinations come from hosts, which must contain
+// the host of the entry in the default namespace. Developer routes are added with UpdateVirtualServiceRoutes,
+// passing the same hosts in the route options.
+func GenerateServiceEntryVirtualService(entry *istionetworkingv1beta1.ServiceEntry, hosts ServiceEntryHosts, opts RouteOptions) *istionetworkingv1beta1.VirtualService {
+	opts.Hosts = hosts
+	opts.HostDomain = ""
+	opts.DefaultRouteNamespace = ""
+	vs := generateVirtualService(entry.Name, ServiceEntryVirtualServiceName(entry.Name), metav1.OwnerReference{
+		APIVersion: "networking.istio.io/v1beta1",
+		Kind:       "ServiceEntry",
+		Name:       entry.Name,
+		UID:        entry.UID,
+	}, entry.Namespace, opts)
+	vs.Labels[ServiceEntryLabel] = entry.Name
+	return vs
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"sort"
+
+	istiov1beta1 "istio.io/api/networking/v1beta1"
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SidecarName is the name of the Sidecar generated in every developer namespace
+const SidecarName = "virtualservice-operator-egress"
+
+// GenerateSidecar creates a namespace-wide Sidecar for a developer namespace that limits egress to the
+// namespace itself, the Istio control plane and the given default namespace services in the cluster domain
+func GenerateSidecar(namespace, defaultNamespace, domain string, serviceNames []string) *istionetworkingv1beta1.Sidecar {
+	hosts := []string{"./*", "istio-system/*"}
+
+	names := append([]string(nil), serviceNames...)
+	sort.Strings(names)
+	for _, name := range names {
+		hosts = append(hosts, defaultNamespace+"/"+ServiceFQDN(name, defaultNamespace, domain))
+	}
+
+	return &istionetworkingv1beta1.Sidecar{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SidecarName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				ManagedByLabel: OperatorName,
+			},
+		},
+		Spec: istiov1beta1.Sidecar{
+			Egress: []*istiov1beta1.IstioEgressListener{
+				{Hosts: hosts},
+			},
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerateSidecar(t *testing.T) {
+	sidecar := GenerateSidecar("alice", "default", "cluster.local", []string{"web", "api"})
+
+	if sidecar.Name != SidecarName || sidecar.Namespace != "alice" {
+		t.Errorf("Sidecar is %s/%s, want alice/%s", sidecar.Namespace, sidecar.Name, SidecarName)
+	}
+	if !IsManagedByOperator(sidecar) {
+		t.Error("Sidecar is not labeled as managed by the operator")
+	}
+	if sidecar.Spec.WorkloadSelector != nil {
+		t.Errorf("Sidecar selects workloads %v, want it to apply namespace-wide", sidecar.Spec.WorkloadSelector)
+	}
+	if len(sidecar.Spec.Egress) != 1 {
+		t.Fatalf("got %d egress listeners, want 1", len(sidecar.Spec.Egress))
+	}
+	want := []string{
+		"./*",
+		"istio-system/*",
+		"default/api.default.svc.cluster.local",
+		"default/web.default.svc.cluster.local",
+	}
+	if got := sidecar.Spec.Egress[0].Hosts; !reflect.DeepEqual(got, want) {
+		t.Errorf("egress hosts = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateSidecarWithoutServices(t *testing.T) {
+	want := []string{"./*", "istio-system/*"}
+	if got := GenerateSidecar("alice", "default", "", nil).Spec.Egress[0].Hosts; !reflect.DeepEqual(got, want) {
+		t.Errorf("egress hosts = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateSidecarDoesNotReorderInput(t *testing.T) {
+	names := []string{"web", "api"}
+	GenerateSidecar("alice", "default", "", names)
+	if !reflect.DeepEqual(names, []string{"web", "api"}) {
+		t.Errorf("service names reordered to %v", names)
+	}
+}
@@ -2,8 +2,11 @@ package utils
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
+	"google.golang.org/protobuf/types/known/durationpb"
 	istiov1beta1 "istio.io/api/networking/v1beta1"
 	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
 	corev1 "k8s.io/api/core/v1"
@@ -13,6 +16,12 @@ import (
 const (
 	ManagedByLabel = "managed-by"
 	OperatorName   = "virtualservice-operator"
+
+	// OperatorAnnotationPrefix prefixes the annotations the operator owns
+	OperatorAnnotationPrefix = "virtualservice-operator/"
+	// ManagedAnnotationsAnnotation lists the annotations the operator applied to a VirtualService, so ones
+	// dropped from the configuration can be removed without touching annotations set by anyone else
+	ManagedAnnotationsAnnotation = OperatorAnnotationPrefix + "managed-annotations"
 )
 
 // isLikelyPlaceholderService checks if a service is likely a placeholder based on heuristics
@@ -29,113 +38,460 @@ func isLikelyPlaceholderService(serviceName, namespace string) bool {
 	return false // For now, let the controller handle the filtering
 }
 
-// GenerateVirtualService creates a VirtualService for a given service with only the default route
-func GenerateVirtualService(service *corev1.Service, defaultNamespace string, developerNamespaces []string) *istionetworkingv1beta1.VirtualService {
-	serviceName := service.Name
+// GenerateVirtualService creates a VirtualService for a given service with only the default route.
+// Route options that apply to any route, such as header manipulation, are applied to the default route.
+func GenerateVirtualService(service *corev1.Service, defaultNamespace string, developerNamespaces []string, opts RouteOptions) *istionetworkingv1beta1.VirtualService {
+	return generateVirtualService(service.Name, fmt.Sprintf("%s-virtual-service", service.Name), metav1.OwnerReference{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Name:       service.Name,
+		UID:        service.UID,
+	}, defaultNamespace, opts)
+}
 
+// generateVirtualService creates the VirtualService with only the default route for the routed object named serviceName
+func generateVirtualService(serviceName, vsName string, owner metav1.OwnerReference, defaultNamespace string, opts RouteOptions) *istionetworkingv1beta1.VirtualService {
 	// Create HTTP routes - only add default route initially
 	var httpRoutes []*istiov1beta1.HTTPRoute
 
 	// Add default route (no header matching, always last)
+	defaultRouteNamespace := defaultNamespace
+	if opts.DefaultRouteNamespace != "" {
+		defaultRouteNamespace = opts.DefaultRouteNamespace
+	}
 	defaultRoute := &istiov1beta1.HTTPRoute{
-		Route: []*istiov1beta1.HTTPRouteDestination{
-			{
-				Destination: &istiov1beta1.Destination{
-					Host: fmt.Sprintf("%s.%s.svc.cluster.local", serviceName, defaultNamespace),
-				},
-			},
-		},
+		Route:   defaultRouteDestinations(serviceName, defaultRouteNamespace, opts.LocalClusterDomain),
+		Headers: opts.Headers,
+		Timeout: routeTimeout(opts.Timeout),
+	}
+	if opts.Hosts != nil {
+		defaultRoute.Route[0].Destination.Host = opts.Hosts.Host(serviceName, defaultRouteNamespace)
 	}
 	httpRoutes = append(httpRoutes, defaultRoute)
 
+	host := virtualServiceHost(serviceName, defaultNamespace, opts.HostDomain)
+	if opts.Hosts != nil {
+		host = opts.Hosts.Host(serviceName, defaultNamespace)
+	}
+
 	// Create VirtualService
 	vs := &istionetworkingv1beta1.VirtualService{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-virtual-service", serviceName),
+			Name:      vsName,
 			Namespace: defaultNamespace,
 			Labels: map[string]string{
 				ManagedByLabel: OperatorName,
 			},
-			OwnerReferences: []metav1.OwnerReference{
-				{
-					APIVersion: "v1",
-					Kind:       "Service",
-					Name:       service.Name,
-					UID:        service.UID,
-				},
-			},
+			OwnerReferences: []metav1.OwnerReference{owner},
 		},
 		Spec: istiov1beta1.VirtualService{
-			Hosts: []string{serviceName},
-			Http:  httpRoutes,
+			Hosts:    []string{host},
+			Gateways: opts.Gateways,
+			Http:     httpRoutes,
 		},
 	}
+	setManagedAnnotations(vs, opts.Annotations)
 
 	return vs
 }
 
-func UpdateVirtualServiceRoutes(vs *istionetworkingv1beta1.VirtualService, serviceName, devNamespace string) {
+// virtualServiceHost returns the host of the VirtualService of a service: the short service name, or its FQDN
+// when a cluster domain is given. Both resolve to the same service within the namespace, so the routes match
+// the same traffic either way.
+func virtualServiceHost(serviceName, namespace, domain string) string {
+	if domain == "" {
+		return serviceName
+	}
+	return ServiceFQDN(serviceName, namespace, domain)
+}
+
+// RouteOptions customizes the developer route generated for a service
+type RouteOptions struct {
+	// Weight is the percentage of header-matched traffic sent to the developer namespace.
+	// The remainder falls back to the default namespace. Nil sends all matched traffic to the developer namespace.
+	Weight *int32
+	// ClusterDomain is the cluster domain of the developer service, defaults to cluster.local
+	ClusterDomain string
+	// LocalClusterDomain is the cluster domain of the default namespace service, defaults to cluster.local
+	LocalClusterDomain string
+	// Subset pins the developer destination to a DestinationRule subset
+	Subset string
+	// Headers manipulates request and response headers on the route
+	Headers *istiov1beta1.Headers
+	// Timeout of the route, zero sets none
+	Timeout time.Duration
+	// MatchSourceNamespace matches developer traffic on the namespace of the calling workload instead of the
+	// x-developer header. The source namespace comes from the workload identity and can't be spoofed.
+	MatchSourceNamespace bool
+	// FallbackHeader adds a second route for the namespace matching this header, ordered after all x-developer
+	// routes, so a request falls back to the namespace when the x-developer namespace has no service.
+	// Not used when matching on the source namespace.
+	FallbackHeader string
+	// HeaderPrefix and HeaderSuffix surround the namespace in the x-developer header value the route matches,
+	// for gateways that send namespaces in another form. Empty matches the bare namespace.
+	HeaderPrefix string
+	HeaderSuffix string
+	// RewriteAuthority keeps the developer route on the default destination and rewrites the
+	// authority to the developer service host instead, for backends that select behavior by Host
+	RewriteAuthority bool
+	// Gateways the VirtualService is attached to. Only used when generating the VirtualService.
+	Gateways []string
+	// Annotations are added to the VirtualService metadata. Only used when generating the VirtualService.
+	Annotations map[string]string
+	// HostDomain makes the VirtualService host the FQDN of the service in this cluster domain instead of
+	// its short name. Only used when generating the VirtualService.
+	HostDomain string
+	// DefaultRouteNamespace sends traffic without an x-developer header to this namespace instead of
+	// the default namespace. Only used when generating the default route.
+	DefaultRouteNamespace string
+	// Hosts resolves the destination hosts of the routes, nil routes to the cluster-local service FQDN.
+	// When set it also provides the VirtualService host.
+	Hosts HostSource
+}
+
+// HostSource resolves the destination host of a routed service in a namespace, so the same routing can
+// target something other than a Kubernetes Service, such as a ServiceEntry
+type HostSource interface {
+	Host(serviceName, namespace string) string
+}
+
+// routeTimeout converts a route timeout to its protobuf form, nil for no timeout
+func routeTimeout(timeout time.Duration) *durationpb.Duration {
+	if timeout <= 0 {
+		return nil
+	}
+	return durationpb.New(timeout)
+}
+
+// defaultRouteDestinations builds the destination of the default (no-match) route
+func defaultRouteDestinations(serviceName, namespace, domain string) []*istiov1beta1.HTTPRouteDestination {
+	return []*istiov1beta1.HTTPRouteDestination{
+		{
+			Destination: &istiov1beta1.Destination{
+				Host: ServiceFQDN(serviceName, namespace, domain),
+			},
+		},
+	}
+}
+
+// SetDefaultRouteNamespace points the default (last, no-match) route at the service in the given namespace
+// of the cluster domain
+func SetDefaultRouteNamespace(vs *istionetworkingv1beta1.VirtualService, serviceName, namespace, domain string) {
+	if len(vs.Spec.Http) == 0 {
+		return
+	}
+	defaultRoute := vs.Spec.Http[len(vs.Spec.Http)-1]
+	if len(defaultRoute.Match) > 0 {
+		return // Not a route the operator generated as default
+	}
+	defaultRoute.Route = defaultRouteDestinations(serviceName, namespace, domain)
+}
+
+// ApplyDefaultRouteWeight keeps weight percent of the default route on the default namespace and splits the
+// remainder evenly across the target namespaces. Without targets the namespaces of the developer routes
+// are used. Nothing changes for a weight of 100, and all traffic stays on the default namespace when
+// there is nowhere to divert it to.
+func ApplyDefaultRouteWeight(vs *istionetworkingv1beta1.VirtualService, serviceName, defaultNamespace, domain string, weight int32, targets []string) {
+	if weight >= 100 || len(vs.Spec.Http) == 0 {
+		return
+	}
+	defaultRoute := vs.Spec.Http[len(vs.Spec.Http)-1]
+	if len(defaultRoute.Match) > 0 {
+		return
+	}
+
+	if len(targets) == 0 {
+		seen := map[string]bool{}
+		for _, route := range vs.Spec.Http {
+			// A namespace with a fallback route has two routes but is one target
+			if ns, ok := DeveloperRouteNamespace(route); ok && ns != defaultNamespace && !seen[ns] {
+				targets = append(targets, ns)
+				seen[ns] = true
+			}
+		}
+	}
+	if len(targets) == 0 {
+		defaultRoute.Route = defaultRouteDestinations(serviceName, defaultNamespace, domain)
+		return
+	}
+
+	if weight < 0 {
+		weight = 0
+	}
+
+	var destinations []*istiov1beta1.HTTPRouteDestination
+	if weight > 0 {
+		destination := defaultRouteDestinations(serviceName, defaultNamespace, domain)[0]
+		destination.Weight = weight
+		destinations = append(destinations, destination)
+	}
+
+	// Spread the remainder so the weights always add up to 100
+	remainder := 100 - weight
+	share := remainder / int32(len(targets))
+	extra := remainder % int32(len(targets))
+	for i, ns := range targets {
+		destination := defaultRouteDestinations(serviceName, ns, domain)[0]
+		destination.Weight = share
+		if int32(i) < extra {
+			destination.Weight++
+		}
+		destinations = append(destinations, destination)
+	}
+
+	defaultRoute.Route = destinations
+}
+
+// DefaultRouteNamespace returns the namespace the default (last, no-match) route sends traffic to
+func DefaultRouteNamespace(vs *istionetworkingv1beta1.VirtualService, serviceName string) string {
+	if len(vs.Spec.Http) == 0 {
+		return ""
+	}
+	defaultRoute := vs.Spec.Http[len(vs.Spec.Http)-1]
+	if len(defaultRoute.Match) > 0 || len(defaultRoute.Route) == 0 || defaultRoute.Route[0].Destination == nil {
+		return ""
+	}
+	host := strings.TrimPrefix(defaultRoute.Route[0].Destination.Host, serviceName+".")
+	namespace, _, _ := strings.Cut(host, ".")
+	return namespace
+}
+
+// UpdateVirtualServiceRoutes adds or replaces the header-matched route for a developer namespace, and its
+// fallback route if a fallback header is configured
+func UpdateVirtualServiceRoutes(vs *istionetworkingv1beta1.VirtualService, serviceName, devNamespace string, opts RouteOptions) {
 	// Safety check: Don't create routes for services that look like placeholders
 	// Check if this is likely a placeholder service based on naming pattern and namespace
 	if isLikelyPlaceholderService(serviceName, devNamespace) {
-		fmt.Printf("DEBUG: Skipping route creation for likely placeholder service %s/%s\n", devNamespace, serviceName)
 		return
 	}
 
-	fmt.Printf("DEBUG: Creating/updating route for service %s in namespace %s\n", serviceName, devNamespace)
+	newRoute := newDeveloperRoute(vs, serviceName, devNamespace, opts)
 
-	// Add or update route for developer namespace
-	newRoute := &istiov1beta1.HTTPRoute{
-		Match: []*istiov1beta1.HTTPMatchRequest{
-			{
-				Headers: map[string]*istiov1beta1.StringMatch{
-					"x-developer": {
-						MatchType: &istiov1beta1.StringMatch_Exact{
-							Exact: devNamespace,
-						},
-					},
+	// Find if route already exists and update, otherwise add
+	found := false
+	for i, route := range vs.Spec.Http {
+		if _, fallback := fallbackRouteNamespace(route); fallback {
+			continue
+		}
+		if ns, ok := DeveloperRouteNamespace(route); ok && ns == devNamespace {
+			vs.Spec.Http[i] = newRoute
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		// Insert before the default route (last route)
+		if len(vs.Spec.Http) > 0 {
+			vs.Spec.Http = append(vs.Spec.Http[:len(vs.Spec.Http)-1], newRoute, vs.Spec.Http[len(vs.Spec.Http)-1])
+		} else {
+			vs.Spec.Http = append(vs.Spec.Http, newRoute)
+		}
+	}
+
+	updateFallbackRoute(vs, serviceName, devNamespace, opts)
+}
+
+// newDeveloperRoute builds the route sending a developer namespace's traffic to its service. The route matches
+// the x-developer header, or the namespace of the calling workload when MatchSourceNamespace is set.
+func newDeveloperRoute(vs *istionetworkingv1beta1.VirtualService, serviceName, devNamespace string, opts RouteOptions) *istiov1beta1.HTTPRoute {
+	headerValue := opts.HeaderPrefix + devNamespace + opts.HeaderSuffix
+	match := &istiov1beta1.HTTPMatchRequest{
+		Headers: map[string]*istiov1beta1.StringMatch{
+			"x-developer": {
+				MatchType: &istiov1beta1.StringMatch_Exact{
+					Exact: headerValue,
 				},
 			},
 		},
-		Route: []*istiov1beta1.HTTPRouteDestination{
+	}
+	var name string
+	if headerValue != devNamespace {
+		// The header value no longer is the namespace, the route name records it instead
+		name = developerRouteNamePrefix + devNamespace
+	}
+	if opts.MatchSourceNamespace {
+		match = &istiov1beta1.HTTPMatchRequest{SourceNamespace: devNamespace}
+		name = ""
+	}
+
+	newRoute := &istiov1beta1.HTTPRoute{
+		Name:    name,
+		Match:   []*istiov1beta1.HTTPMatchRequest{match},
+		Route:   developerRouteDestinations(vs, serviceName, devNamespace, opts),
+		Headers: opts.Headers,
+		Timeout: routeTimeout(opts.Timeout),
+	}
+
+	if opts.RewriteAuthority {
+		// One backend serves every namespace, the rewritten authority selects the developer behavior
+		newRoute.Rewrite = &istiov1beta1.HTTPRewrite{
+			Authority: newRoute.Route[0].Destination.Host,
+		}
+		newRoute.Route = []*istiov1beta1.HTTPRouteDestination{
 			{
 				Destination: &istiov1beta1.Destination{
-					Host: fmt.Sprintf("%s.%s.svc.cluster.local", serviceName, devNamespace),
+					Host: ServiceFQDN(serviceName, vs.Namespace, opts.LocalClusterDomain),
 				},
 			},
+		}
+	}
+	return newRoute
+}
+
+// developerRouteDestinations builds the destinations of a developer route, splitting traffic
+// with the default namespace when a weight below 100 is requested
+func developerRouteDestinations(vs *istionetworkingv1beta1.VirtualService, serviceName, devNamespace string, opts RouteOptions) []*istiov1beta1.HTTPRouteDestination {
+	devDestination := &istiov1beta1.HTTPRouteDestination{
+		Destination: &istiov1beta1.Destination{
+			Host:   ServiceFQDN(serviceName, devNamespace, opts.ClusterDomain),
+			Subset: opts.Subset,
 		},
 	}
+	if opts.Hosts != nil {
+		devDestination.Destination.Host = opts.Hosts.Host(serviceName, devNamespace)
+	}
 
-	// Find if route already exists and update, otherwise add
-	found := false
-	for i, route := range vs.Spec.Http {
-		if len(route.Match) > 0 && route.Match[0].Headers != nil {
-			if headerMatch, exists := route.Match[0].Headers["x-developer"]; exists {
-				if exact := headerMatch.GetExact(); exact == devNamespace {
-					vs.Spec.Http[i] = newRoute
-					found = true
-					break
-				}
-			}
+	if opts.Weight == nil || *opts.Weight >= 100 {
+		return []*istiov1beta1.HTTPRouteDestination{devDestination}
+	}
+
+	weight := *opts.Weight
+	if weight < 0 {
+		weight = 0
+	}
+	devDestination.Weight = weight
+
+	// The VirtualService lives in the default namespace, so the remainder goes to the default service
+	defaultHost := ServiceFQDN(serviceName, vs.Namespace, opts.LocalClusterDomain)
+	if opts.Hosts != nil {
+		defaultHost = opts.Hosts.Host(serviceName, vs.Namespace)
+	}
+	return []*istiov1beta1.HTTPRouteDestination{
+		devDestination,
+		{
+			Destination: &istiov1beta1.Destination{
+				Host: defaultHost,
+			},
+			Weight: 100 - weight,
+		},
+	}
+}
+
+// developerRouteNamePrefix names the developer routes whose x-developer header value differs from the namespace
+const developerRouteNamePrefix = "developer-"
+
+// DeveloperRouteNamespace returns the developer namespace a route matches, on the x-developer header or on
+// the source namespace. Both forms are recognized whatever the routing strategy, so routes written under
+// another strategy are still found and replaced or removed. A header value with a prefix or suffix is
+// recognized by the route name, so creation and removal agree whatever the configured prefix and suffix.
+// Fallback routes count as routes of their namespace too.
+func DeveloperRouteNamespace(route *istiov1beta1.HTTPRoute) (string, bool) {
+	if len(route.Match) == 0 {
+		return "", false
+	}
+	if namespace, ok := fallbackRouteNamespace(route); ok {
+		return namespace, true
+	}
+	match := route.Match[0]
+	if headerMatch, exists := match.Headers["x-developer"]; exists {
+		if namespace, named := strings.CutPrefix(route.Name, developerRouteNamePrefix); named && namespace != "" {
+			return namespace, true
 		}
+		return headerMatch.GetExact(), true
+	}
+	if match.SourceNamespace != "" {
+		return match.SourceNamespace, true
 	}
+	return "", false
+}
 
-	if !found {
-		// Insert before the default route (last route)
-		if len(vs.Spec.Http) > 0 {
-			vs.Spec.Http = append(vs.Spec.Http[:len(vs.Spec.Http)-1], newRoute, vs.Spec.Http[len(vs.Spec.Http)-1])
-		} else {
-			vs.Spec.Http = append(vs.Spec.Http, newRoute)
+// RemoveDeveloperRoutes removes the routes matching a developer namespace and returns how many were removed.
+// Routes of other namespaces and the default route are never touched. An empty namespace removes nothing,
+// it would otherwise match developer routes whose namespace can't be read.
+func RemoveDeveloperRoutes(vs *istionetworkingv1beta1.VirtualService, devNamespace string) int {
+	if devNamespace == "" {
+		return 0
+	}
+	var routes []*istiov1beta1.HTTPRoute
+	removed := 0
+	for _, route := range vs.Spec.Http {
+		if ns, ok := DeveloperRouteNamespace(route); ok && ns == devNamespace {
+			removed++
+			continue
 		}
+		routes = append(routes, route)
+	}
+	vs.Spec.Http = routes
+	return removed
+}
+
+// RemoveAllDeveloperRoutes removes the routes of every developer namespace and returns how many were removed
+func RemoveAllDeveloperRoutes(vs *istionetworkingv1beta1.VirtualService) int {
+	var routes []*istiov1beta1.HTTPRoute
+	removed := 0
+	for _, route := range vs.Spec.Http {
+		if _, ok := DeveloperRouteNamespace(route); ok {
+			removed++
+			continue
+		}
+		routes = append(routes, route)
+	}
+	vs.Spec.Http = routes
+	return removed
+}
+
+// setManagedAnnotations applies annotations to a VirtualService and records which ones the operator applied.
+// Keys in the operator's own annotation namespace are skipped so they can't be clobbered.
+func setManagedAnnotations(vs *istionetworkingv1beta1.VirtualService, annotations map[string]string) {
+	var keys []string
+	for key, value := range annotations {
+		if strings.HasPrefix(key, OperatorAnnotationPrefix) {
+			continue
+		}
+		if vs.Annotations == nil {
+			vs.Annotations = map[string]string{}
+		}
+		vs.Annotations[key] = value
+		keys = append(keys, key)
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		vs.Annotations[ManagedAnnotationsAnnotation] = strings.Join(keys, ",")
+	}
+}
+
+// managedAnnotationKeys returns the annotations the operator recorded as applied to a VirtualService
+func managedAnnotationKeys(vs *istionetworkingv1beta1.VirtualService) []string {
+	value := vs.Annotations[ManagedAnnotationsAnnotation]
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// SyncManagedAnnotations makes the operator-applied annotations of actual match those of desired,
+// removing ones the operator applied before but no longer wants. Other annotations are left alone.
+func SyncManagedAnnotations(actual, desired *istionetworkingv1beta1.VirtualService) {
+	for _, key := range managedAnnotationKeys(actual) {
+		delete(actual.Annotations, key)
+	}
+	delete(actual.Annotations, ManagedAnnotationsAnnotation)
+
+	wanted := map[string]string{}
+	for _, key := range managedAnnotationKeys(desired) {
+		wanted[key] = desired.Annotations[key]
 	}
+	setManagedAnnotations(actual, wanted)
 }
 
-// IsManagedByOperator checks if a VirtualService is managed by this operator
-func IsManagedByOperator(vs *istionetworkingv1beta1.VirtualService) bool {
-	if vs.Labels == nil {
+// IsManagedByOperator checks if a VirtualService (or another Istio object) is managed by this operator
+func IsManagedByOperator(obj metav1.Object) bool {
+	labels := obj.GetLabels()
+	if labels == nil {
 		return false
 	}
-	return vs.Labels[ManagedByLabel] == OperatorName
+	return labels[ManagedByLabel] == OperatorName
 }
 
 // GetServiceNameFromVirtualService extracts service name from VirtualService name
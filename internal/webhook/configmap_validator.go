@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"virtualservice-operator/internal/config"
+)
+
+// ConfigMapValidatorPath is the path the ConfigMap validating webhook is served on
+const ConfigMapValidatorPath = "/validate-operator-config"
+
+// ConfigMapValidator rejects changes to the operator ConfigMap that contain an invalid config.yaml or config.json.
+// ConfigMaps other than the operator's own are always allowed.
+type ConfigMapValidator struct {
+	name      string
+	namespace string
+	decoder   *admission.Decoder
+}
+
+// NewConfigMapValidator creates a validator for the operator ConfigMap namespace/name
+func NewConfigMapValidator(scheme *runtime.Scheme, namespace, name string) *ConfigMapValidator {
+	return &ConfigMapValidator{
+		name:      name,
+		namespace: namespace,
+		decoder:   admission.NewDecoder(scheme),
+	}
+}
+
+// Handle validates the operator configuration carried by the ConfigMap in the request
+func (v *ConfigMapValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Name != v.name || req.Namespace != v.namespace {
+		return admission.Allowed("not the operator ConfigMap")
+	}
+	if req.Operation == admissionv1.Delete {
+		return admission.Allowed("")
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := v.decoder.Decode(req, configMap); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	configData, format, err := config.ConfigMapData(configMap)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	if _, err := config.ParseConfig(configData, format); err != nil {
+		return admission.Denied(fmt.Sprintf("invalid operator configuration: %v", err))
+	}
+
+	return admission.Allowed("")
+}
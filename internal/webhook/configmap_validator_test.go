@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	testNamespace = "virtualservice-operator-system"
+	testName      = "virtualservice-operator-config"
+)
+
+// admissionRequest builds the admission request for an operation on a ConfigMap
+func admissionRequest(t *testing.T, operation admissionv1.Operation, namespace, name string, data map[string]string) admission.Request {
+	t.Helper()
+	raw, err := json.Marshal(&corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Data:       data,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Operation: operation,
+		Namespace: namespace,
+		Name:      name,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+}
+
+func TestConfigMapValidator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	validator := NewConfigMapValidator(scheme, testNamespace, testName)
+
+	tests := []struct {
+		name        string
+		operation   admissionv1.Operation
+		namespace   string
+		configMap   string
+		data        map[string]string
+		wantAllowed bool
+		wantMessage string
+	}{
+		{
+			name:        "valid yaml",
+			data:        map[string]string{"config.yaml": "defaultNamespace: default\ndeveloperNamespaces: [alice, bob]\n"},
+			wantAllowed: true,
+		},
+		{
+			name:        "valid json",
+			data:        map[string]string{"config.json": `{"defaultNamespace": "default", "developerNamespaces": ["dev-*"]}`},
+			wantAllowed: true,
+		},
+		{
+			name:        "malformed yaml",
+			data:        map[string]string{"config.yaml": "developerNamespaces: [alice"},
+			wantMessage: "invalid operator configuration",
+		},
+		{
+			name:        "invalid developer namespace",
+			data:        map[string]string{"config.yaml": "developerNamespaces: [Not_A_Namespace]\n"},
+			wantMessage: "developerNamespaces[0]",
+		},
+		{
+			name:        "unknown routing strategy",
+			data:        map[string]string{"config.yaml": "routingStrategy: teleport\n"},
+			wantMessage: "routingStrategy",
+		},
+		{
+			name:        "invalid gateway",
+			data:        map[string]string{"config.yaml": "gateways: [\"a/b/c\"]\n"},
+			wantMessage: "gateways[0]",
+		},
+		{
+			name:        "no config key",
+			data:        map[string]string{"other": "x"},
+			wantMessage: "neither config.yaml nor config.json",
+		},
+		{
+			name:        "other ConfigMap",
+			configMap:   "unrelated",
+			data:        map[string]string{"config.yaml": "developerNamespaces: [alice"},
+			wantAllowed: true,
+		},
+		{
+			name:        "other namespace",
+			namespace:   "default",
+			data:        map[string]string{"config.yaml": "developerNamespaces: [alice"},
+			wantAllowed: true,
+		},
+		{
+			name:        "delete",
+			operation:   admissionv1.Delete,
+			wantAllowed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operation, namespace, name := tt.operation, tt.namespace, tt.configMap
+			if operation == "" {
+				operation = admissionv1.Update
+			}
+			if namespace == "" {
+				namespace = testNamespace
+			}
+			if name == "" {
+				name = testName
+			}
+
+			response := validator.Handle(context.Background(), admissionRequest(t, operation, namespace, name, tt.data))
+			if response.Allowed != tt.wantAllowed {
+				t.Fatalf("allowed = %v, want %v (%s)", response.Allowed, tt.wantAllowed, response.Result.Message)
+			}
+			if !strings.Contains(response.Result.Message, tt.wantMessage) {
+				t.Errorf("message %q does not mention %q", response.Result.Message, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"io"
 	"os"
+	"text/tabwriter"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -11,14 +16,29 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientconfig "sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/yaml"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
 	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
 
+	"virtualservice-operator/api/v1alpha1"
 	"virtualservice-operator/controllers"
 	"virtualservice-operator/internal/config"
+	"virtualservice-operator/internal/webhook"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -30,15 +50,38 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(istionetworkingv1beta1.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
 func main() {
+	// One-shot maintenance subcommands run instead of the controller manager
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "drain":
+			os.Exit(runDrain(os.Args[2:]))
+		case "render":
+			os.Exit(runRender(os.Args[2:]))
+		case "migrate-placeholders":
+			os.Exit(runMigratePlaceholders(os.Args[2:]))
+		case "diagnose":
+			os.Exit(runDiagnose(os.Args[2:]))
+		}
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
 	var configMapName string
 	var configMapNamespace string
+	var remoteKubeconfig string
+	var enableConfigWebhook bool
+	var gracefulShutdownTimeout time.Duration
+	var otlpEndpoint string
+	var otlpInsecure bool
+	var cacheWatchedNamespaces bool
+	var enableServiceEntries bool
+	var enablePolicies bool
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -47,6 +90,20 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&configMapName, "config-map-name", "virtualservice-operator-config", "Name of the ConfigMap containing operator configuration.")
 	flag.StringVar(&configMapNamespace, "config-map-namespace", "virtualservice-operator-system", "Namespace of the ConfigMap containing operator configuration.")
+	flag.BoolVar(&enableConfigWebhook, "enable-config-webhook", false,
+		"Serve a validating webhook that rejects invalid operator ConfigMaps. Requires serving certificates.")
+	flag.DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", 30*time.Second,
+		"How long in-flight reconciles may take to finish on shutdown before the manager exits.")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", "", "OTLP gRPC endpoint (host:port) to export reconcile traces to. Tracing is disabled when empty.")
+	flag.BoolVar(&otlpInsecure, "otlp-insecure", false, "Connect to the OTLP endpoint without TLS.")
+	flag.BoolVar(&cacheWatchedNamespaces, "cache-watched-namespaces", false,
+		"Only cache objects in the namespaces watched at startup instead of the whole cluster, to save memory in large clusters. "+
+			"Namespaces watched later, e.g. added to the config or matched by a pattern, are ignored until the operator is restarted.")
+	flag.BoolVar(&enableServiceEntries, "enable-service-entries", false,
+		"Generate VirtualServices for default namespace ServiceEntries annotated with virtualservice-operator/route-service-entry.")
+	flag.BoolVar(&enablePolicies, "enable-policies", false,
+		"Apply VirtualServicePolicy resources to the services they target. Requires the VirtualServicePolicy CRD.")
+	flag.StringVar(&remoteKubeconfig, "remote-kubeconfig", "", "Path to a kubeconfig of a remote cluster to discover developer services in. Disabled when empty.")
 
 	opts := zap.Options{
 		Development: true,
@@ -56,11 +113,36 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	shutdownTracing, err := setupTracing(context.Background(), otlpEndpoint, otlpInsecure)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing", "endpoint", otlpEndpoint)
+		os.Exit(1)
+	}
+	defer shutdownTracing()
+
+	restConfig := ctrl.GetConfigOrDie()
+
+	// The watched namespaces come from the config, so it's read with a direct client before the cache exists
+	var cachedNamespaces []string
+	if cacheWatchedNamespaces {
+		cachedNamespaces, err = startupNamespaces(context.Background(), restConfig, configMapNamespace, configMapName)
+		if err != nil {
+			setupLog.Error(err, "unable to determine the namespaces to cache")
+			os.Exit(1)
+		}
+		setupLog.Info("Limiting cache to watched namespaces", "namespaces", cachedNamespaces)
+	}
+	cacheOptions := namespaceCacheOptions(cachedNamespaces)
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:                 scheme,
+		Cache:                  cacheOptions,
+		Metrics:                metricsserver.Options{BindAddress: metricsAddr},
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "virtualservice-operator-leader-election",
+		// Let in-flight reconciles finish their current update before exiting
+		GracefulShutdownTimeout: &gracefulShutdownTimeout,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -70,16 +152,48 @@ func main() {
 	// Create config manager
 	configManager := config.NewConfigManager(mgr.GetClient(), configMapNamespace, configMapName)
 
+	// Create a read-only client for the remote cluster if configured
+	var remoteClient client.Reader
+	if remoteKubeconfig != "" {
+		remoteConfig, err := clientcmd.BuildConfigFromFlags("", remoteKubeconfig)
+		if err != nil {
+			setupLog.Error(err, "unable to load remote kubeconfig", "path", remoteKubeconfig)
+			os.Exit(1)
+		}
+		remoteClient, err = client.New(remoteConfig, client.Options{Scheme: scheme})
+		if err != nil {
+			setupLog.Error(err, "unable to create remote cluster client")
+			os.Exit(1)
+		}
+	}
+
 	// Setup Service controller
-	if err = (&controllers.ServiceReconciler{
-		Client:        mgr.GetClient(),
-		Scheme:        mgr.GetScheme(),
-		ConfigManager: configManager,
-	}).SetupWithManager(mgr); err != nil {
+	reconciler := &controllers.ServiceReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		ConfigManager:    configManager,
+		Recorder:         mgr.GetEventRecorderFor("virtualservice-operator"),
+		RemoteClient:     remoteClient,
+		CachedNamespaces: cachedNamespaces,
+		EnablePolicies:   enablePolicies,
+	}
+	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Service")
 		os.Exit(1)
 	}
 
+	if enableServiceEntries {
+		if err = (&controllers.ServiceEntryReconciler{ServiceReconciler: reconciler}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ServiceEntry")
+			os.Exit(1)
+		}
+	}
+
+	if enableConfigWebhook {
+		validator := webhook.NewConfigMapValidator(mgr.GetScheme(), configMapNamespace, configMapName)
+		mgr.GetWebhookServer().Register(webhook.ConfigMapValidatorPath, &ctrlwebhook.Admission{Handler: validator})
+	}
+
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -94,6 +208,238 @@ func main() {
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
+		shutdownTracing()
 		os.Exit(1)
 	}
 }
+
+// startupNamespaces reads the operator config with a direct client and returns the namespaces to limit the
+// manager cache to: the watched namespaces plus the namespace of the operator ConfigMap
+func startupNamespaces(ctx context.Context, restConfig *rest.Config, configMapNamespace, configMapName string) ([]string, error) {
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	namespaces, err := config.NewConfigManager(c, configMapNamespace, configMapName).GetWatchedNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, ns := range namespaces {
+		if ns == configMapNamespace {
+			return namespaces, nil
+		}
+	}
+	return append(namespaces, configMapNamespace), nil
+}
+
+// namespaceCacheOptions limits the manager cache to the namespaces, or caches the whole cluster without any.
+// The namespaces are fixed for the lifetime of the cache: objects in namespaces watched later are never
+// seen, so their services get no routes or placeholders until the operator is restarted.
+func namespaceCacheOptions(namespaces []string) cache.Options {
+	if len(namespaces) == 0 {
+		return cache.Options{}
+	}
+	options := cache.Options{DefaultNamespaces: map[string]cache.Config{}}
+	for _, ns := range namespaces {
+		options.DefaultNamespaces[ns] = cache.Config{}
+	}
+	return options
+}
+
+// setupTracing installs a global tracer provider exporting to the OTLP endpoint.
+// Without an endpoint the default no-op provider stays in place. The returned function flushes pending spans.
+func setupTracing(ctx context.Context, endpoint string, insecure bool) (func(), error) {
+	if endpoint == "" {
+		return func() {}, nil
+	}
+
+	exporterOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+	if insecure {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create OTLP exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String("virtualservice-operator"))),
+	)
+	otel.SetTracerProvider(provider)
+	setupLog.Info("exporting traces", "endpoint", endpoint)
+
+	return func() {
+		if err := provider.Shutdown(context.Background()); err != nil {
+			setupLog.Error(err, "failed to flush traces")
+		}
+	}, nil
+}
+
+// subcommand holds the flags shared by the one-shot maintenance subcommands
+type subcommand struct {
+	flags              *flag.FlagSet
+	configMapName      string
+	configMapNamespace string
+	zapOpts            zap.Options
+}
+
+// newSubcommand creates a subcommand with the kubeconfig, ConfigMap and logging flags registered
+func newSubcommand(name string) *subcommand {
+	cmd := &subcommand{flags: flag.NewFlagSet(name, flag.ExitOnError)}
+	cmd.flags.StringVar(&cmd.configMapName, "config-map-name", "virtualservice-operator-config", "Name of the ConfigMap containing operator configuration.")
+	cmd.flags.StringVar(&cmd.configMapNamespace, "config-map-namespace", "virtualservice-operator-system", "Namespace of the ConfigMap containing operator configuration.")
+	clientconfig.RegisterFlags(cmd.flags)
+	cmd.zapOpts.BindFlags(cmd.flags)
+	return cmd
+}
+
+// reconciler parses the subcommand arguments and builds a reconciler backed by a direct (uncached) client
+func (cmd *subcommand) reconciler(args []string) (*controllers.ServiceReconciler, error) {
+	if err := cmd.flags.Parse(args); err != nil {
+		return nil, err
+	}
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&cmd.zapOpts)))
+
+	restConfig, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get kubeconfig: %w", err)
+	}
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create client: %w", err)
+	}
+
+	return &controllers.ServiceReconciler{
+		Client:        c,
+		Scheme:        scheme,
+		ConfigManager: config.NewConfigManager(c, cmd.configMapNamespace, cmd.configMapName),
+		// Policies are read when the CRD is installed, so subcommands see what the operator applies
+		EnablePolicies: true,
+	}, nil
+}
+
+// runDrain deletes every operator-managed VirtualService, DestinationRule, placeholder service and Sidecar
+func runDrain(args []string) int {
+	cmd := newSubcommand("drain")
+	dryRun := cmd.flags.Bool("dry-run", false, "Print the objects that would be deleted without deleting them.")
+
+	reconciler, err := cmd.reconciler(args)
+	if err != nil {
+		setupLog.Error(err, "unable to set up drain")
+		return 1
+	}
+
+	drained, err := reconciler.Drain(context.Background(), *dryRun)
+	for _, obj := range drained {
+		if *dryRun {
+			fmt.Printf("would delete %s\n", obj)
+		} else {
+			fmt.Printf("deleted %s\n", obj)
+		}
+	}
+	if err != nil {
+		setupLog.Error(err, "drain failed")
+		return 1
+	}
+	return 0
+}
+
+// runMigratePlaceholders marks placeholder services identified by the legacy label with the current markers
+func runMigratePlaceholders(args []string) int {
+	cmd := newSubcommand("migrate-placeholders")
+	dryRun := cmd.flags.Bool("dry-run", false, "Print the services that would be migrated without changing them.")
+
+	reconciler, err := cmd.reconciler(args)
+	if err != nil {
+		setupLog.Error(err, "unable to set up placeholder migration")
+		return 1
+	}
+
+	migrated, err := reconciler.MigratePlaceholders(context.Background(), *dryRun)
+	for _, obj := range migrated {
+		if *dryRun {
+			fmt.Printf("would migrate %s\n", obj)
+		} else {
+			fmt.Printf("migrated %s\n", obj)
+		}
+	}
+	if err != nil {
+		setupLog.Error(err, "placeholder migration failed")
+		return 1
+	}
+	return 0
+}
+
+// runDiagnose checks the permissions, ConfigMap and Istio CRDs the operator needs and prints a pass/fail table
+func runDiagnose(args []string) int {
+	cmd := newSubcommand("diagnose")
+	var opts controllers.DiagnoseOptions
+	cmd.flags.BoolVar(&opts.ServiceEntries, "enable-service-entries", false, "Check the permissions and CRD the operator's --enable-service-entries needs.")
+	cmd.flags.BoolVar(&opts.Policies, "enable-policies", false, "Check the permissions and CRD the operator's --enable-policies needs.")
+	cmd.flags.BoolVar(&opts.LeaderElection, "leader-elect", false, "Check the permissions the operator's --leader-elect needs.")
+
+	reconciler, err := cmd.reconciler(args)
+	if err != nil {
+		setupLog.Error(err, "unable to set up diagnose")
+		return 1
+	}
+
+	failed := false
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tRESULT")
+	for _, check := range reconciler.Diagnose(context.Background(), opts) {
+		if check.Err != nil {
+			failed = true
+			fmt.Fprintf(w, "%s\tFAIL: %v\n", check.Name, check.Err)
+		} else {
+			fmt.Fprintf(w, "%s\tPASS\n", check.Name)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		setupLog.Error(err, "unable to print diagnose results")
+		return 1
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// runRender prints the VirtualServices and placeholder services the operator would apply as YAML manifests
+func runRender(args []string) int {
+	cmd := newSubcommand("render")
+
+	reconciler, err := cmd.reconciler(args)
+	if err != nil {
+		setupLog.Error(err, "unable to set up render")
+		return 1
+	}
+
+	objects, err := reconciler.Render(context.Background())
+	if err != nil {
+		setupLog.Error(err, "render failed")
+		return 1
+	}
+
+	if err := writeManifests(os.Stdout, objects); err != nil {
+		setupLog.Error(err, "unable to write manifests")
+		return 1
+	}
+	return 0
+}
+
+// writeManifests writes objects as a multi-document YAML stream that kubectl can apply
+func writeManifests(w io.Writer, objects []client.Object) error {
+	for _, obj := range objects {
+		manifest, err := yaml.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to serialize %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", manifest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
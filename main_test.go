@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	istionetworkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"virtualservice-operator/controllers"
+	"virtualservice-operator/internal/config"
+)
+
+const renderTestConfig = `
+defaultNamespace: default
+developerNamespaces: [alice, bob]
+enablePlaceholderServices: true
+`
+
+func renderTestService(namespace, name string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: "10.0.0.1",
+			Selector:  map[string]string{"app": name},
+			Ports:     []corev1.ServicePort{{Name: "http", Port: 80, Protocol: corev1.ProtocolTCP}},
+		},
+	}
+}
+
+// renderObjects renders a cluster with two default namespace services, one of them deployed to alice,
+// and checks that rendering keeps stdout clean for the manifests
+func renderObjects(t *testing.T) []client.Object {
+	t.Helper()
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "virtualservice-operator-system", Name: "virtualservice-operator-config"},
+		Data:       map[string]string{"config.yaml": renderTestConfig},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		configMap,
+		renderTestService("default", "app"),
+		renderTestService("default", "web"),
+		renderTestService("alice", "app"),
+	).Build()
+	reconciler := &controllers.ServiceReconciler{
+		Client:        c,
+		Scheme:        scheme,
+		ConfigManager: config.NewConfigManager(c, configMap.Namespace, configMap.Name),
+	}
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	objects, renderErr := reconciler.Render(context.Background())
+	os.Stdout = stdout
+	w.Close()
+	written, _ := io.ReadAll(r)
+
+	if renderErr != nil {
+		t.Fatalf("Render() failed: %v", renderErr)
+	}
+	if len(written) > 0 {
+		t.Errorf("Render() wrote to stdout: %q", written)
+	}
+	return objects
+}
+
+func TestRenderObjects(t *testing.T) {
+	var got []string
+	for _, obj := range renderObjects(t) {
+		got = append(got, obj.GetObjectKind().GroupVersionKind().Kind+" "+obj.GetNamespace()+"/"+obj.GetName())
+	}
+	sort.Strings(got)
+
+	want := []string{
+		"Service alice/web",
+		"Service bob/app",
+		"Service bob/web",
+		"VirtualService default/app-virtual-service",
+		"VirtualService default/web-virtual-service",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+}
+
+func TestWriteManifestsRoundTrip(t *testing.T) {
+	objects := renderObjects(t)
+
+	var out bytes.Buffer
+	if err := writeManifests(&out, objects); err != nil {
+		t.Fatal(err)
+	}
+
+	documents := strings.Split(strings.TrimPrefix(out.String(), "---\n"), "---\n")
+	if len(documents) != len(objects) {
+		t.Fatalf("wrote %d documents for %d objects", len(documents), len(objects))
+	}
+
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+	for i, document := range documents {
+		decoded, gvk, err := decoder.Decode([]byte(document), nil, nil)
+		if err != nil {
+			t.Fatalf("document %d does not decode: %v\n%s", i, err, document)
+		}
+		if *gvk != objects[i].GetObjectKind().GroupVersionKind() {
+			t.Errorf("document %d is a %v, want %v", i, gvk, objects[i].GetObjectKind().GroupVersionKind())
+		}
+
+		switch want := objects[i].(type) {
+		case *istionetworkingv1beta1.VirtualService:
+			got := decoded.(*istionetworkingv1beta1.VirtualService)
+			if !equality.Semantic.DeepEqual(got.ObjectMeta, want.ObjectMeta) || !proto.Equal(&got.Spec, &want.Spec) {
+				t.Errorf("VirtualService %s does not round-trip:\n%s", want.Name, document)
+			}
+		case *corev1.Service:
+			got := decoded.(*corev1.Service)
+			if !equality.Semantic.DeepEqual(got.ObjectMeta, want.ObjectMeta) || !equality.Semantic.DeepEqual(got.Spec, want.Spec) {
+				t.Errorf("Service %s/%s does not round-trip:\n%s", want.Namespace, want.Name, document)
+			}
+		default:
+			t.Errorf("unexpected rendered object %T", want)
+		}
+	}
+}
+
+// fakeServiceAPIServer serves lists of the services and watches that never send an event,
+// enough for an informer to sync
+func fakeServiceAPIServer(t *testing.T, services []corev1.Service) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("watch") == "true" {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+
+		namespace := ""
+		if rest, ok := strings.CutPrefix(r.URL.Path, "/api/v1/namespaces/"); ok {
+			namespace, _, _ = strings.Cut(rest, "/")
+		}
+		list := &corev1.ServiceList{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceList"},
+			ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		}
+		for _, service := range services {
+			if namespace == "" || service.Namespace == namespace {
+				list.Items = append(list.Items, service)
+			}
+		}
+		if err := json.NewEncoder(w).Encode(list); err != nil {
+			t.Errorf("failed to encode services: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// cachedServices starts a cache with the options against the API server and returns how many services it
+// holds in the namespaces
+func cachedServices(t *testing.T, server *httptest.Server, options cache.Options, namespaces []string) int {
+	t.Helper()
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion})
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Service"), meta.RESTScopeNamespace)
+	// The multi-namespace cache maps the list kind, which the discovery-based mapper of the manager resolves
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ServiceList"), meta.RESTScopeNamespace)
+	options.Scheme = scheme
+	options.Mapper = mapper
+
+	c, err := cache.New(&rest.Config{Host: server.URL}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		if err := c.Start(ctx); err != nil {
+			t.Errorf("cache failed: %v", err)
+		}
+	}()
+	if !c.WaitForCacheSync(ctx) {
+		t.Fatal("cache did not start")
+	}
+
+	// A cache limited to namespaces refuses to list the others
+	cached := 0
+	for _, ns := range namespaces {
+		services := &corev1.ServiceList{}
+		if err := c.List(ctx, services, client.InNamespace(ns)); err == nil {
+			cached += len(services.Items)
+		}
+	}
+	return cached
+}
+
+func TestNamespaceCacheOptionsLimitCachedObjects(t *testing.T) {
+	// 10 namespaces with 5 services each, 3 of them watched
+	var namespaces []string
+	var services []corev1.Service
+	for i := 0; i < 10; i++ {
+		namespace := fmt.Sprintf("team-%d", i)
+		switch i {
+		case 0:
+			namespace = "default"
+		case 1:
+			namespace = "alice"
+		case 2:
+			namespace = "bob"
+		}
+		namespaces = append(namespaces, namespace)
+		for j := 0; j < 5; j++ {
+			service := renderTestService(namespace, fmt.Sprintf("app-%d", j))
+			service.ResourceVersion = "1"
+			services = append(services, *service)
+		}
+	}
+	server := fakeServiceAPIServer(t, services)
+
+	if n := cachedServices(t, server, namespaceCacheOptions(nil), namespaces); n != 50 {
+		t.Errorf("cluster-wide cache holds %d services, want 50", n)
+	}
+	watched := []string{"default", "alice", "bob", "virtualservice-operator-system"}
+	if n := cachedServices(t, server, namespaceCacheOptions(watched), namespaces); n != 15 {
+		t.Errorf("cache limited to %v holds %d services, want 15", watched, n)
+	}
+}